@@ -0,0 +1,95 @@
+package acceptance
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/onsi/gomega/gbytes"
+	"github.com/onsi/gomega/gexec"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("download-product command", func() {
+	When("downloading from a local directory", func() {
+		var sourceDir string
+
+		BeforeEach(func() {
+			var err error
+			sourceDir, err = os.MkdirTemp("", "")
+			Expect(err).ToNot(HaveOccurred())
+
+			pivotalFile := createPivotalFile("[pivnet-example-slug,1.10.1]example*pivotal", "./fixtures/example-product.yml")
+
+			Expect(os.MkdirAll(filepath.Join(sourceDir, "some", "product"), 0755)).To(Succeed())
+			Expect(os.MkdirAll(filepath.Join(sourceDir, "another", "stemcell"), 0755)).To(Succeed())
+			Expect(os.Rename(pivotalFile, filepath.Join(sourceDir, "some", "product", "[pivnet-example-slug,1.10.1]example-product.pivotal"))).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(sourceDir, "another", "stemcell", "[stemcells-ubuntu-xenial,97.57]light-bosh-stemcell-97.57-vsphere-esxi-ubuntu-xenial-go_agent.tgz"), []byte("stemcell"), 0644)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			Expect(os.RemoveAll(sourceDir)).To(Succeed())
+		})
+
+		It("downloads the product and correct stemcell", func() {
+			tmpDir, err := os.MkdirTemp("", "")
+			Expect(err).ToNot(HaveOccurred())
+
+			command := exec.Command(pathToMain, "download-product",
+				"--file-glob", "example-product.pivotal",
+				"--pivnet-product-slug", "pivnet-example-slug",
+				"--product-version-regex", `1\..*`,
+				"--output-directory", tmpDir,
+				"--source", "local",
+				"--local-directory", sourceDir,
+				"--stemcell-iaas", "vsphere",
+				"--blobstore-stemcell-path", "another/stemcell",
+				"--blobstore-product-path", "some/product",
+			)
+
+			session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+			Expect(err).ToNot(HaveOccurred())
+			Eventually(session, "10s").Should(gexec.Exit(0))
+			Expect(session.Err).To(gbytes.Say(`attempting to download the file.*example-product.pivotal.*from source local`))
+			Expect(session.Err).To(gbytes.Say(`attempting to download the file.*light-bosh-stemcell-97.57-vsphere-esxi-ubuntu-xenial-go_agent.tgz.*from source local`))
+
+			_, err = os.Stat(filepath.Join(tmpDir, "[pivnet-example-slug,1.10.1]example-product.pivotal"))
+			Expect(err).ToNot(HaveOccurred())
+			_, err = os.Stat(filepath.Join(tmpDir, "[stemcells-ubuntu-xenial,97.57]light-bosh-stemcell-97.57-vsphere-esxi-ubuntu-xenial-go_agent.tgz"))
+			Expect(err).ToNot(HaveOccurred())
+
+			contents, err := os.ReadFile(filepath.Join(tmpDir, "download-file.json"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(contents)).To(MatchJSON(`{
+				"product_path": "` + filepath.Join(tmpDir, "[pivnet-example-slug,1.10.1]example-product.pivotal") + `",
+				"product_slug": "pivnet-example-slug",
+				"product_version": "1.10.1",
+				"stemcell_path": "` + filepath.Join(tmpDir, "[stemcells-ubuntu-xenial,97.57]light-bosh-stemcell-97.57-vsphere-esxi-ubuntu-xenial-go_agent.tgz") + `",
+				"stemcell_version": "97.57"
+			}`))
+		})
+
+		When("the directory does not exist", func() {
+			It("gives a helpful error message", func() {
+				tmpDir, err := os.MkdirTemp("", "")
+				Expect(err).ToNot(HaveOccurred())
+
+				command := exec.Command(pathToMain, "download-product",
+					"--file-glob", "*.pivotal",
+					"--pivnet-product-slug", "pivnet-example-slug",
+					"--product-version", "1.10.1",
+					"--output-directory", tmpDir,
+					"--source", "local",
+					"--local-directory", filepath.Join(sourceDir, "unknown"),
+				)
+
+				session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+				Eventually(session, "10s").Should(gexec.Exit(1))
+				Expect(session.Err).To(gbytes.Say(`could not access local directory`))
+			})
+		})
+	})
+})
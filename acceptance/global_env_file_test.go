@@ -70,6 +70,56 @@ var _ = Describe("global env file", func() {
 			Expect(string(session.Out.Contents())).To(MatchJSON(`[ { "name": "p-bosh", "product_version": "999.99" } ]`))
 		})
 
+		It("authenticates with the credentials of the credential-process", func() {
+			server := testServer(false)
+			createConfigFile(fmt.Sprintf(`
+---
+target: %s
+skip-ssl-validation: true
+credential-process: "echo '{\"client-id\": \"some-client-id\", \"client-secret\": \"shhh-secret\"}'"
+`, server.URL))
+			command := exec.Command(pathToMain,
+				"--env", configFile.Name(),
+				"curl",
+				"-p", "/api/v0/available_products",
+			)
+
+			session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(session).Should(gexec.Exit(0))
+			Expect(string(session.Out.Contents())).To(MatchJSON(`[ { "name": "p-bosh", "product_version": "999.99" } ]`))
+		})
+
+		It("uses the context of the env file", func() {
+			server := testServer(true)
+			createConfigFile(fmt.Sprintf(`
+---
+current-context: unreachable
+contexts:
+  unreachable:
+    target: https://127.0.0.1:1
+  test:
+    target: %s
+    username: some-env-provided-username
+    password: {env: TEST_CONTEXT_PASSWORD}
+skip-ssl-validation: true
+`, server.URL))
+			command := exec.Command(pathToMain,
+				"--env", configFile.Name(),
+				"--context", "test",
+				"curl",
+				"-p", "/api/v0/available_products",
+			)
+			command.Env = append(os.Environ(), "TEST_CONTEXT_PASSWORD=some-env-provided-password")
+
+			session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(session).Should(gexec.Exit(0))
+			Expect(string(session.Out.Contents())).To(MatchJSON(`[ { "name": "p-bosh", "product_version": "999.99" } ]`))
+		})
+
 		It("supports a string from --ca-cert", func() {
 			server := testServer(true)
 			cert, err := x509.ParseCertificate(server.TLS.Certificates[0].Certificate[0])
@@ -228,6 +228,34 @@ no-proxy: 10.10.10.10,11.11.11.11
 			Eventually(session, "5s").Should(gexec.Exit(0))
 		})
 
+		It("can be modified by ops files", func() {
+			configFile := writeFile(`
+username: invalid-username-in-JSON-verifier
+password: password
+decryption-passphrase: passphrase
+http-proxy-url: http://http-proxy.com
+https-proxy-url: http://https-proxy.com
+no-proxy: 10.10.10.10,11.11.11.11
+`)
+			command := exec.Command(pathToMain,
+				"--target", server.URL(),
+				"--skip-ssl-validation",
+				"configure-authentication",
+				"--config", configFile,
+				"--ops-file", writeFile(`
+- type: replace
+  path: /username
+  value: ((username))
+`),
+				"--var", "username=username",
+			)
+
+			session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(session, "5s").Should(gexec.Exit(0))
+		})
+
 		When("all the above are provided", func() {
 			var configFile string
 
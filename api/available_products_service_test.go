@@ -1,6 +1,7 @@
 package api_test
 
 import (
+	"io"
 	"net/http"
 	"strings"
 
@@ -140,6 +141,51 @@ var _ = Describe("Available Products", func() {
 			Expect(output).To(Equal(api.UploadAvailableProductOutput{}))
 		})
 
+		It("streams the product to the Ops Manager without buffering it", func() {
+			firstChunk := strings.Repeat("a", 64*1024)
+			received := make(chan struct{})
+
+			progressClient.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/api/v0/available_products"),
+					http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+						chunk := make([]byte, len(firstChunk))
+						_, err := io.ReadFull(req.Body, chunk)
+						Expect(err).ToNot(HaveOccurred())
+						close(received)
+
+						rest, err := io.ReadAll(req.Body)
+						Expect(err).ToNot(HaveOccurred())
+						Expect(string(rest)).To(Equal("the rest"))
+					}),
+				),
+			)
+
+			// the rest of the product is only written once the server
+			// received the start of it, which would never happen if it
+			// were buffered before being sent
+			reader, writer := io.Pipe()
+			go func() {
+				defer GinkgoRecover()
+
+				_, err := writer.Write([]byte(firstChunk))
+				Expect(err).ToNot(HaveOccurred())
+
+				Eventually(received).Should(BeClosed())
+				_, err = writer.Write([]byte("the rest"))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(writer.Close()).To(Succeed())
+			}()
+
+			_, err := service.UploadAvailableProduct(api.UploadAvailableProductInput{
+				ContentLength:   int64(len(firstChunk) + len("the rest")),
+				Product:         reader,
+				ContentType:     "some content-type",
+				PollingInterval: 1,
+			})
+			Expect(err).ToNot(HaveOccurred())
+		})
+
 		When("an error occurs", func() {
 			When("the client errors performing the request", func() {
 				It("returns an error", func() {
@@ -0,0 +1,344 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pivotal-cf/om/network"
+	"gopkg.in/yaml.v2"
+)
+
+// envFile is the env file, which holds the options for one Ops Manager at
+// the top level, or for many in named contexts.
+type envFile struct {
+	options `yaml:",inline"`
+
+	CurrentContext string                `yaml:"current-context"`
+	Contexts       map[string]envContext `yaml:"contexts"`
+}
+
+type envContext struct {
+	Target               string      `yaml:"target,omitempty"`
+	UAATarget            string      `yaml:"uaa-target,omitempty"`
+	Username             string      `yaml:"username,omitempty"`
+	Password             secretValue `yaml:"password,omitempty"`
+	ClientID             string      `yaml:"client-id,omitempty"`
+	ClientSecret         secretValue `yaml:"client-secret,omitempty"`
+	CredentialProcess    string      `yaml:"credential-process,omitempty"`
+	DecryptionPassphrase secretValue `yaml:"decryption-passphrase,omitempty"`
+	CACert               string      `yaml:"ca-cert,omitempty"`
+	SkipSSLValidation    bool        `yaml:"skip-ssl-validation,omitempty"`
+}
+
+// secretValue is a secret of a context, given inline, as the name of an
+// environment variable ({env: NAME}), or as a command that prints it
+// ({command: "..."}).
+type secretValue struct {
+	Value   string
+	Env     string `yaml:"env,omitempty"`
+	Command string `yaml:"command,omitempty"`
+}
+
+func (s *secretValue) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	if err := unmarshal(&s.Value); err == nil {
+		return nil
+	}
+
+	var reference struct {
+		Env     string `yaml:"env"`
+		Command string `yaml:"command"`
+	}
+	err := unmarshal(&reference)
+	if err != nil {
+		return err
+	}
+
+	if (reference.Env == "") == (reference.Command == "") {
+		return errors.New("a secret must be a value, {env: NAME} or {command: COMMAND}")
+	}
+
+	s.Env = reference.Env
+	s.Command = reference.Command
+	return nil
+}
+
+// MarshalYAML keeps inline secrets out of om context show.
+func (s secretValue) MarshalYAML() (interface{}, error) {
+	if s.Env != "" || s.Command != "" {
+		return struct {
+			Env     string `yaml:"env,omitempty"`
+			Command string `yaml:"command,omitempty"`
+		}{s.Env, s.Command}, nil
+	}
+
+	if s.Value != "" {
+		return "[REDACTED]", nil
+	}
+
+	return nil, nil
+}
+
+func (s secretValue) IsZero() bool {
+	return s == secretValue{}
+}
+
+func (s secretValue) resolve() (string, error) {
+	switch {
+	case s.Env != "":
+		value, ok := os.LookupEnv(s.Env)
+		if !ok {
+			return "", fmt.Errorf("the environment variable %s is not set", s.Env)
+		}
+		return value, nil
+	case s.Command != "":
+		shell, flag := "sh", "-c"
+		if runtime.GOOS == "windows" {
+			shell, flag = "cmd", "/C"
+		}
+
+		command := exec.Command(shell, flag, s.Command)
+		command.Stderr = os.Stderr
+		output, err := command.Output()
+		if err != nil {
+			return "", fmt.Errorf("could not run %q: %w", s.Command, err)
+		}
+		return strings.TrimRight(string(output), "\r\n"), nil
+	}
+
+	return s.Value, nil
+}
+
+// setContextProperties sets the options of the context that are not set by
+// flags or environment variables.
+func setContextProperties(global *options, file envFile) error {
+	name := global.Context
+	if name == "" {
+		name = file.CurrentContext
+	}
+	if name == "" {
+		return nil
+	}
+
+	context, ok := file.Contexts[name]
+	if !ok {
+		return fmt.Errorf("the env file has no context %q: om context list shows its contexts", name)
+	}
+
+	if global.Target == "" {
+		global.Target = context.Target
+	}
+	if global.UAATarget == "" {
+		global.UAATarget = context.UAATarget
+	}
+	if global.Username == "" {
+		global.Username = context.Username
+	}
+	if global.ClientID == "" {
+		global.ClientID = context.ClientID
+	}
+	if global.CredentialProcess == "" {
+		global.CredentialProcess = context.CredentialProcess
+	}
+	if global.CACert == "" {
+		global.CACert = context.CACert
+	}
+	if !global.SkipSSLValidation {
+		global.SkipSSLValidation = context.SkipSSLValidation
+	}
+
+	// only the secrets of the way om authenticates are resolved, so that
+	// the others do not run their commands or need their env vars
+	if global.Password == "" && global.Username != "" {
+		password, err := context.Password.resolve()
+		if err != nil {
+			return fmt.Errorf("could not get the password of context %q: %w", name, err)
+		}
+		global.Password = password
+	}
+	if global.ClientSecret == "" && global.ClientID != "" && (global.Username == "" || global.Password == "") {
+		clientSecret, err := context.ClientSecret.resolve()
+		if err != nil {
+			return fmt.Errorf("could not get the client-secret of context %q: %w", name, err)
+		}
+		global.ClientSecret = clientSecret
+	}
+
+	// the decryption passphrase is only resolved when om unlocks Ops Manager
+	if global.DecryptionPassphrase == "" {
+		passphrase := context.DecryptionPassphrase
+		if passphrase.Env == "" && passphrase.Command == "" {
+			global.DecryptionPassphrase = passphrase.Value
+		} else {
+			global.resolveDecryptionPassphrase = func() (string, error) {
+				value, err := passphrase.resolve()
+				if err != nil {
+					return "", fmt.Errorf("could not get the decryption-passphrase of context %q: %w", name, err)
+				}
+				return value, nil
+			}
+		}
+	}
+
+	return nil
+}
+
+// contextDecryptClient unlocks Ops Manager with the decryption passphrase of
+// the context, which it resolves on the first authenticated request.
+type contextDecryptClient struct {
+	authedClient   httpClient
+	unauthedClient httpClient
+	resolve        func() (string, error)
+
+	once   sync.Once
+	client httpClient
+	err    error
+}
+
+func newContextDecryptClient(authedClient, unauthedClient httpClient, resolve func() (string, error)) *contextDecryptClient {
+	return &contextDecryptClient{
+		authedClient:   authedClient,
+		unauthedClient: unauthedClient,
+		resolve:        resolve,
+	}
+}
+
+func (c *contextDecryptClient) Do(request *http.Request) (*http.Response, error) {
+	c.once.Do(func() {
+		var passphrase string
+		passphrase, c.err = c.resolve()
+		if c.err != nil {
+			return
+		}
+
+		c.client = c.authedClient
+		if passphrase != "" {
+			c.client = network.NewDecryptClient(c.authedClient, c.unauthedClient, passphrase, os.Stderr)
+		}
+	})
+	if c.err != nil {
+		return nil, c.err
+	}
+
+	return c.client.Do(request)
+}
+
+var currentContextLine = regexp.MustCompile(`(?m)^current-context:.*$`)
+
+// envFileContexts manages the contexts of the env file for om context. It
+// reads the env file as written, without interpolating it.
+type envFileContexts struct {
+	path    string
+	context string
+}
+
+func newEnvFileContexts(path, context string) *envFileContexts {
+	return &envFileContexts{
+		path:    path,
+		context: context,
+	}
+}
+
+// read leaves out the top level options, which may only parse once the env
+// file is interpolated.
+func (e *envFileContexts) read() ([]byte, envFile, error) {
+	if e.path == "" {
+		return nil, envFile{}, errors.New("--env is required: contexts are kept in the env file")
+	}
+
+	contents, err := os.ReadFile(e.path)
+	if err != nil {
+		return nil, envFile{}, fmt.Errorf("env file does not exist: %s", err)
+	}
+
+	var file struct {
+		CurrentContext string                `yaml:"current-context"`
+		Contexts       map[string]envContext `yaml:"contexts"`
+	}
+	err = yaml.Unmarshal(contents, &file)
+	if err != nil {
+		return nil, envFile{}, fmt.Errorf("could not parse env file: %s", err)
+	}
+
+	return contents, envFile{CurrentContext: file.CurrentContext, Contexts: file.Contexts}, nil
+}
+
+func (e *envFileContexts) List() ([]string, string, error) {
+	_, file, err := e.read()
+	if err != nil {
+		return nil, "", err
+	}
+
+	names := make([]string, 0, len(file.Contexts))
+	for name := range file.Contexts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	current := e.context
+	if current == "" {
+		current = file.CurrentContext
+	}
+
+	return names, current, nil
+}
+
+// Use rewrites only the current-context of the env file, so that its
+// comments and formatting are kept.
+func (e *envFileContexts) Use(name string) error {
+	contents, file, err := e.read()
+	if err != nil {
+		return err
+	}
+
+	if _, ok := file.Contexts[name]; !ok {
+		return fmt.Errorf("the env file has no context %q: om context list shows its contexts", name)
+	}
+
+	line := fmt.Sprintf("current-context: %q", name)
+	if currentContextLine.Match(contents) {
+		contents = currentContextLine.ReplaceAllLiteral(contents, []byte(line))
+	} else if strings.HasPrefix(string(contents), "---\n") {
+		contents = []byte("---\n" + line + "\n" + strings.TrimPrefix(string(contents), "---\n"))
+	} else {
+		contents = []byte(line + "\n" + string(contents))
+	}
+
+	info, err := os.Stat(e.path)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(e.path, contents, info.Mode().Perm())
+}
+
+func (e *envFileContexts) Show(name string) ([]byte, error) {
+	_, file, err := e.read()
+	if err != nil {
+		return nil, err
+	}
+
+	if name == "" {
+		name = e.context
+	}
+	if name == "" {
+		name = file.CurrentContext
+	}
+	if name == "" {
+		return nil, errors.New("there is no current context: provide the name of a context, or run om context use")
+	}
+
+	context, ok := file.Contexts[name]
+	if !ok {
+		return nil, fmt.Errorf("the env file has no context %q: om context list shows its contexts", name)
+	}
+
+	return yaml.Marshal(context)
+}
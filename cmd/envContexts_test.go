@@ -0,0 +1,226 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const contextsEnvFile = `---
+# foundations
+current-context: dev
+contexts:
+  dev:
+    target: https://dev.example.com
+    username: admin
+    password: dev-password
+  prod:
+    target: https://prod.example.com
+    client-id: automation
+    client-secret: {env: OM_TEST_PROD_SECRET}
+    decryption-passphrase: {command: "echo prod-passphrase"}
+    skip-ssl-validation: true
+connect-timeout: 20
+`
+
+var _ = Describe("env file contexts", func() {
+	var path string
+
+	BeforeEach(func() {
+		path = filepath.Join(GinkgoT().TempDir(), "env.yml")
+		Expect(os.WriteFile(path, []byte(contextsEnvFile), 0600)).To(Succeed())
+	})
+
+	Describe("setEnvFileProperties", func() {
+		It("uses the current context", func() {
+			global := options{Env: path, ConnectTimeout: 10, RequestTimeout: 1800}
+
+			Expect(setEnvFileProperties(&global)).To(Succeed())
+			Expect(global.Target).To(Equal("https://dev.example.com"))
+			Expect(global.Username).To(Equal("admin"))
+			Expect(global.Password).To(Equal("dev-password"))
+			Expect(global.ConnectTimeout).To(Equal(20))
+		})
+
+		It("uses the context of --context, with its secrets from env vars and commands", func() {
+			GinkgoT().Setenv("OM_TEST_PROD_SECRET", "prod-secret")
+			global := options{Env: path, Context: "prod", ConnectTimeout: 10, RequestTimeout: 1800}
+
+			Expect(setEnvFileProperties(&global)).To(Succeed())
+			Expect(global.Target).To(Equal("https://prod.example.com"))
+			Expect(global.ClientID).To(Equal("automation"))
+			Expect(global.ClientSecret).To(Equal("prod-secret"))
+			Expect(global.SkipSSLValidation).To(BeTrue())
+			Expect(global.Password).To(BeEmpty())
+
+			Expect(global.DecryptionPassphrase).To(BeEmpty())
+			passphrase, err := global.resolveDecryptionPassphrase()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(passphrase).To(Equal("prod-passphrase"))
+		})
+
+		It("only resolves the secrets of the way om authenticates", func() {
+			Expect(os.WriteFile(path, []byte(`current-context: dev
+contexts:
+  dev:
+    target: https://dev.example.com
+    username: admin
+    password: {command: "echo dev-password"}
+    client-id: automation
+    client-secret: {env: OM_TEST_UNSET_SECRET}
+    decryption-passphrase: {command: "exit 1"}
+`), 0600)).To(Succeed())
+			global := options{Env: path}
+
+			Expect(setEnvFileProperties(&global)).To(Succeed())
+			Expect(global.Password).To(Equal("dev-password"))
+			Expect(global.ClientSecret).To(BeEmpty())
+
+			_, err := global.resolveDecryptionPassphrase()
+			Expect(err).To(MatchError(ContainSubstring(`could not get the decryption-passphrase of context "dev"`)))
+		})
+
+		It("does not resolve the password when authenticating with client credentials", func() {
+			Expect(os.WriteFile(path, []byte(`current-context: ci
+contexts:
+  ci:
+    target: https://ci.example.com
+    client-id: automation
+    client-secret: ci-secret
+    password: {env: OM_TEST_UNSET_PASSWORD}
+    decryption-passphrase: ci-passphrase
+`), 0600)).To(Succeed())
+			global := options{Env: path}
+
+			Expect(setEnvFileProperties(&global)).To(Succeed())
+			Expect(global.ClientSecret).To(Equal("ci-secret"))
+			Expect(global.Password).To(BeEmpty())
+			Expect(global.DecryptionPassphrase).To(Equal("ci-passphrase"))
+			Expect(global.resolveDecryptionPassphrase).To(BeNil())
+		})
+
+		It("prefers flags and environment variables to the context", func() {
+			global := options{Env: path, Context: "prod", ClientSecret: "flag-secret", Target: "https://flag.example.com"}
+
+			Expect(setEnvFileProperties(&global)).To(Succeed())
+			Expect(global.Target).To(Equal("https://flag.example.com"))
+			Expect(global.ClientSecret).To(Equal("flag-secret"))
+		})
+
+		It("uses the max-retries of the env file unless a flag sets it, even to 0", func() {
+			Expect(os.WriteFile(path, []byte("target: https://opsman.example.com\nmax-retries: 5\n"), 0600)).To(Succeed())
+
+			global := options{Env: path}
+			Expect(setEnvFileProperties(&global)).To(Succeed())
+			Expect(*global.MaxRetries).To(Equal(5))
+
+			noRetries := 0
+			global = options{Env: path, MaxRetries: &noRetries}
+			Expect(setEnvFileProperties(&global)).To(Succeed())
+			Expect(*global.MaxRetries).To(Equal(0))
+
+			Expect(os.WriteFile(path, []byte("target: https://opsman.example.com\n"), 0600)).To(Succeed())
+			global = options{Env: path}
+			Expect(setEnvFileProperties(&global)).To(Succeed())
+			Expect(global.MaxRetries).To(BeNil())
+		})
+
+		It("uses the credential-process of the context", func() {
+			Expect(os.WriteFile(path, []byte("current-context: ci\ncontexts:\n  ci:\n    target: https://ci.example.com\n    credential-process: vault-om-credentials ci\ncredential-process: vault-om-credentials default\n"), 0600)).To(Succeed())
+			global := options{Env: path}
+
+			Expect(setEnvFileProperties(&global)).To(Succeed())
+			Expect(global.CredentialProcess).To(Equal("vault-om-credentials ci"))
+		})
+
+		It("errors when a secret env var is not set", func() {
+			global := options{Env: path, Context: "prod"}
+
+			err := setEnvFileProperties(&global)
+			Expect(err).To(MatchError(`could not get the client-secret of context "prod": the environment variable OM_TEST_PROD_SECRET is not set`))
+		})
+
+		It("errors when the context does not exist", func() {
+			global := options{Env: path, Context: "staging"}
+
+			err := setEnvFileProperties(&global)
+			Expect(err).To(MatchError(`the env file has no context "staging": om context list shows its contexts`))
+		})
+	})
+
+	Describe("envFileContexts", func() {
+		It("lists the contexts", func() {
+			names, current, err := newEnvFileContexts(path, "").List()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(names).To(Equal([]string{"dev", "prod"}))
+			Expect(current).To(Equal("dev"))
+
+			_, current, err = newEnvFileContexts(path, "prod").List()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(current).To(Equal("prod"))
+		})
+
+		It("switches the current context, keeping the rest of the file", func() {
+			Expect(newEnvFileContexts(path, "").Use("prod")).To(Succeed())
+
+			contents, err := os.ReadFile(path)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(contents)).To(Equal(`---
+# foundations
+current-context: "prod"
+contexts:
+  dev:
+    target: https://dev.example.com
+    username: admin
+    password: dev-password
+  prod:
+    target: https://prod.example.com
+    client-id: automation
+    client-secret: {env: OM_TEST_PROD_SECRET}
+    decryption-passphrase: {command: "echo prod-passphrase"}
+    skip-ssl-validation: true
+connect-timeout: 20
+`))
+
+			err = newEnvFileContexts(path, "").Use("staging")
+			Expect(err).To(MatchError(`the env file has no context "staging": om context list shows its contexts`))
+		})
+
+		It("adds the current context to an env file without one", func() {
+			Expect(os.WriteFile(path, []byte("contexts:\n  dev:\n    target: https://dev.example.com\n"), 0600)).To(Succeed())
+
+			Expect(newEnvFileContexts(path, "").Use("dev")).To(Succeed())
+
+			contents, err := os.ReadFile(path)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(contents)).To(Equal("current-context: \"dev\"\ncontexts:\n  dev:\n    target: https://dev.example.com\n"))
+		})
+
+		It("shows a context without its inline secrets", func() {
+			contents, err := newEnvFileContexts(path, "").Show("")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(contents)).To(MatchYAML(`
+target: https://dev.example.com
+username: admin
+password: "[REDACTED]"
+`))
+
+			contents, err = newEnvFileContexts(path, "").Show("prod")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(contents)).To(MatchYAML(`
+target: https://prod.example.com
+client-id: automation
+client-secret: {env: OM_TEST_PROD_SECRET}
+decryption-passphrase: {command: "echo prod-passphrase"}
+skip-ssl-validation: true
+`))
+		})
+
+		It("requires the env file", func() {
+			_, _, err := newEnvFileContexts("", "").List()
+			Expect(err).To(MatchError("--env is required: contexts are kept in the env file"))
+		})
+	})
+})
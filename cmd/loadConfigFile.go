@@ -11,6 +11,7 @@ import (
 // Load the config file, (optionally) load the vars file, vars env as well
 // To use this function, `Config` field must be defined in the command struct being passed in.
 // To load vars, VarsFile and/or VarsEnv must exist in the command struct being passed in.
+// To apply ops files, OpsFile must exist in the command struct being passed in.
 // If VarsEnv is used, envFunc must be defined instead of nil
 func loadConfigFile(args []string, envFunc func() []string) ([]string, error) {
 	if len(args) == 0 {
@@ -20,11 +21,13 @@ func loadConfigFile(args []string, envFunc func() []string) ([]string, error) {
 	for _, cmdConfigBypassList := range []string{
 		"apply-changes",
 		"bosh-env",
+		"bundle",
 		"configure-director",
 		"configure-opsman",
 		"configure-product",
 		"create-vm-extension",
 		"credentials",
+		"download-products",
 		"interpolate",
 		"nom",
 		"replicate-product",
@@ -43,6 +46,7 @@ func loadConfigFile(args []string, envFunc func() []string) ([]string, error) {
 		VarsEnv    []string `long:"vars-env" env:"OM_VARS_ENV"`
 		VarsFile   []string `long:"vars-file"                  short:"l"`
 		Vars       []string `long:"var"                        short:"v"`
+		OpsFile    []string `long:"ops-file"`
 	}
 
 	parser := flags.NewParser(&config, flags.IgnoreUnknown)
@@ -62,7 +66,7 @@ func loadConfigFile(args []string, envFunc func() []string) ([]string, error) {
 		VarsFiles:     config.VarsFile,
 		Vars:          config.Vars,
 		EnvironFunc:   envFunc,
-		OpsFiles:      nil,
+		OpsFiles:      config.OpsFile,
 		ExpectAllKeys: true,
 	})
 	if err != nil {
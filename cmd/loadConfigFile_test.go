@@ -1,6 +1,10 @@
 package cmd
 
 import (
+	"bytes"
+	"os"
+	"path/filepath"
+
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 )
@@ -20,3 +24,40 @@ var _ = Describe("parseOptions", func() {
 
 	})
 })
+
+var _ = Describe("loadConfigFile", func() {
+	It("leaves the interpolation flags to the commands that interpolate their own files", func() {
+		args := []string{"bundle", "create", "--manifest", "m.yml", "--var", "dir=/tmp", "--ops-file", "ops.yml", "-o", "out.tar"}
+
+		loaded, err := loadConfigFile(args, os.Environ)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(loaded).To(Equal(args))
+	})
+
+	It("passes --var and --ops-file to bundle create", func() {
+		dir := GinkgoT().TempDir()
+
+		manifest := filepath.Join(dir, "products.yml")
+		Expect(os.WriteFile(manifest, []byte(`
+output-directory: ((dir))
+products:
+- pivnet-product-slug: cf
+`), 0600)).To(Succeed())
+
+		opsFile := filepath.Join(dir, "ops.yml")
+		Expect(os.WriteFile(opsFile, []byte(`
+- type: remove
+  path: /products
+`), 0600)).To(Succeed())
+
+		stderr := &bytes.Buffer{}
+		err := Main(&bytes.Buffer{}, stderr, "test", "0s", []string{
+			"om", "bundle", "create",
+			"--manifest", manifest,
+			"--var", "dir=" + dir,
+			"--ops-file", opsFile,
+			"-o", filepath.Join(dir, "out.tar"),
+		})
+		Expect(err).To(MatchError(ContainSubstring(manifest + " does not list any products")))
+	})
+})
@@ -30,20 +30,40 @@ type httpClient interface {
 
 type options struct {
 	CACert               string `yaml:"ca-cert" long:"ca-cert" env:"OM_CA_CERT" description:"OpsManager CA certificate path or value"`
+	ClientCert           string `yaml:"client-cert"                      long:"client-cert"           env:"OM_CLIENT_CERT"                         description:"client certificate path or value, presented to Ops Manager and UAA for mutual TLS"`
+	ClientKey            string `yaml:"client-key"                       long:"client-key"            env:"OM_CLIENT_KEY"                          description:"private key path or value of the client certificate"`
+	ClientKeyPassphrase  string `yaml:"client-key-passphrase"            long:"client-key-passphrase" env:"OM_CLIENT_KEY_PASSPHRASE"               description:"passphrase of an encrypted client key"`
 	ClientID             string `yaml:"client-id"             short:"c"  long:"client-id"             env:"OM_CLIENT_ID"                           description:"Client ID for the Ops Manager VM (not required for unauthenticated commands)"`
 	ClientSecret         string `yaml:"client-secret"         short:"s"  long:"client-secret"         env:"OM_CLIENT_SECRET"                       description:"Client Secret for the Ops Manager VM (not required for unauthenticated commands)"`
 	ConnectTimeout       int    `yaml:"connect-timeout"       short:"o"  long:"connect-timeout"       env:"OM_CONNECT_TIMEOUT"     default:"10"    description:"timeout in seconds to make TCP connections"`
+	Context              string `yaml:"-"                                long:"context"               env:"OM_CONTEXT"                             description:"context of the env file to use, instead of its current-context"`
+	CredentialProcess    string `yaml:"credential-process"               long:"credential-process"    env:"OM_CREDENTIAL_PROCESS"                  description:"command that prints the username and password, or client-id and client-secret, as JSON, run when om first needs a token"`
 	DecryptionPassphrase string `yaml:"decryption-passphrase" short:"d"  long:"decryption-passphrase" env:"OM_DECRYPTION_PASSPHRASE"               description:"Passphrase to decrypt the installation if the Ops Manager VM has been rebooted (optional for most commands)"`
 	Env                  string `                             short:"e"  long:"env"                                                                description:"env file with login credentials"`
+	MaxRetries           *int   `yaml:"max-retries"                      long:"max-retries"           env:"OM_MAX_RETRIES"                         description:"times to retry idempotent requests when Ops Manager is unreachable or responds with 502, 503, 504 or 429 (default: 0)"`
 	Password             string `yaml:"password"              short:"p"  long:"password"              env:"OM_PASSWORD"                            description:"admin password for the Ops Manager VM (not required for unauthenticated commands)"`
+	ProxyURL             string `yaml:"proxy-url"                        long:"proxy-url"             env:"OM_PROXY_URL"                           description:"proxy for the connections to Ops Manager and UAA, instead of the HTTPS_PROXY environment variable"`
+	ProxyUsername        string `yaml:"proxy-username"                   long:"proxy-username"        env:"OM_PROXY_USERNAME"                      description:"username to authenticate with the proxy"`
+	ProxyPassword        string `yaml:"proxy-password"                   long:"proxy-password"        env:"OM_PROXY_PASSWORD"                      description:"password to authenticate with the proxy"`
+	ProxyAuthType        string `yaml:"proxy-auth-type"                  long:"proxy-auth-type"       env:"OM_PROXY_AUTH_TYPE"                     description:"type of proxy authentication (basic, spnego), basic when a proxy username or password is set"`
+	ProxyKrb5Config      string `yaml:"proxy-krb5-config"                long:"proxy-krb5-config"     env:"OM_PROXY_KRB5_CONFIG"                   description:"path to Kerberos config file (krb5.conf) for SPNEGO authentication"`
+	Record               string `yaml:"record"                           long:"record"                env:"OM_RECORD"                              description:"records the Ops Manager API requests and responses, with credentials redacted, to a cassette file for --replay"`
+	Replay               string `yaml:"replay"                           long:"replay"                env:"OM_REPLAY"                              description:"serves the Ops Manager API responses from a cassette file written by --record, failing any request it does not contain"`
 	RequestTimeout       int    `yaml:"request-timeout"       short:"r"  long:"request-timeout"       env:"OM_REQUEST_TIMEOUT"     default:"1800"  description:"timeout in seconds for HTTP requests to Ops Manager"`
 	SkipSSLValidation    bool   `yaml:"skip-ssl-validation"   short:"k"  long:"skip-ssl-validation"   env:"OM_SKIP_SSL_VALIDATION"                 description:"skip ssl certificate validation during http requests"`
 	Target               string `yaml:"target"                short:"t"  long:"target"                env:"OM_TARGET"                              description:"location of the Ops Manager VM"`
 	UAATarget            string `yaml:"uaa-target"                       long:"uaa-target"            env:"OM_UAA_TARGET"                          description:"optional location of the Ops Manager UAA"`
 	Trace                bool   `yaml:"trace"                            long:"trace"                 env:"OM_TRACE"                               description:"prints HTTP requests and response payloads"`
+	TraceFile            string `yaml:"trace-file"                       long:"trace-file"            env:"OM_TRACE_FILE"                          description:"writes HTTP requests and responses to a HAR file, which browser devtools can open"`
+	TraceUnredacted      bool   `yaml:"trace-unredacted"                 long:"trace-unredacted"      env:"OM_TRACE_UNREDACTED"                    description:"includes passwords, secrets, tokens and private keys in --trace and --trace-file output"`
+	TokenCache           bool   `yaml:"token-cache"                      long:"token-cache"           env:"OM_TOKEN_CACHE"                         description:"reuse UAA tokens between om invocations by caching them under $XDG_CACHE_HOME/om (see om logout)"`
 	Username             string `yaml:"username"              short:"u"  long:"username"              env:"OM_USERNAME"                            description:"admin username for the Ops Manager VM (not required for unauthenticated commands)"`
 	VarsEnv              string `                                        long:"vars-env"              env:"OM_VARS_ENV"                            description:"load vars from environment variables by specifying a prefix (e.g.: 'MY' to load MY_var=value)"`
 	Version              bool   `                             short:"v"  long:"version"                                                            description:"prints the om release version"`
+
+	// resolveDecryptionPassphrase gets the decryption passphrase of the
+	// context when it is given by an env var or a command
+	resolveDecryptionPassphrase func() (string, error)
 }
 
 func Main(sout io.Writer, serr io.Writer, version string, applySleepDurationString string, args []string) error {
@@ -66,39 +86,128 @@ func Main(sout io.Writer, serr io.Writer, version string, applySleepDurationStri
 		args[0] = "--help"
 	}
 
-	err := setEnvFileProperties(&global)
-	if err != nil {
-		return err
+	// om context reads the env file itself, so that it can switch away from
+	// a context that cannot be used
+	if len(args) == 0 || args[0] != "context" {
+		err := setEnvFileProperties(&global)
+		if err != nil {
+			return err
+		}
+	}
+
+	// import-installation sends the decryption passphrase with the installation
+	if len(args) > 0 && args[0] == "import-installation" && global.resolveDecryptionPassphrase != nil {
+		var err error
+		global.DecryptionPassphrase, err = global.resolveDecryptionPassphrase()
+		if err != nil {
+			return err
+		}
 	}
 
 	requestTimeout := time.Duration(global.RequestTimeout) * time.Second
 	connectTimeout := time.Duration(global.ConnectTimeout) * time.Second
 
+	clientCertificate, err := network.LoadClientCertificate(global.ClientCert, global.ClientKey, global.ClientKeyPassphrase)
+	if err != nil {
+		return err
+	}
+
+	proxy := network.ProxyConfig{
+		URL:        global.ProxyURL,
+		Username:   global.ProxyUsername,
+		Password:   global.ProxyPassword,
+		AuthType:   global.ProxyAuthType,
+		Krb5Config: global.ProxyKrb5Config,
+	}
+
 	var unauthenticatedClient, authedClient, unauthenticatedProgressClient, authedProgressClient httpClient
-	unauthenticatedClient, err = network.NewUnauthenticatedClient(global.Target, global.SkipSSLValidation, global.CACert, connectTimeout, requestTimeout)
+	unauthenticatedClient, err = network.NewUnauthenticatedClient(global.Target, global.SkipSSLValidation, global.CACert, clientCertificate, proxy, connectTimeout, requestTimeout)
 	if err != nil {
 		return err
 	}
 
-	authedClient, err = network.NewOAuthClient(global.UAATarget, global.Target, global.Username, global.Password, global.ClientID, global.ClientSecret, global.SkipSSLValidation, global.CACert, connectTimeout, requestTimeout)
+	oauthClient, err := network.NewOAuthClient(global.UAATarget, global.Target, global.Username, global.Password, global.ClientID, global.ClientSecret, global.SkipSSLValidation, global.CACert, clientCertificate, proxy, connectTimeout, requestTimeout)
 	if err != nil {
 		return err
 	}
 
+	tokenCachePath, tokenCachePathErr := network.DefaultTokenCachePath()
+	tokenCache := network.NewTokenCache(tokenCachePath)
+	if global.TokenCache && tokenCachePathErr != nil {
+		return tokenCachePathErr
+	}
+	// without credentials, om gets them from the credential process, or
+	// can only authenticate with the session of om login --sso, which is
+	// kept in the token cache
+	noCredentials := global.ClientID == "" && (global.Username == "" || global.Password == "")
+	credentialProcess := ""
+	if noCredentials {
+		credentialProcess = global.CredentialProcess
+	}
+	if credentialProcess != "" {
+		oauthClient.SetCredentialProcess(network.NewCredentialProcess(credentialProcess))
+	}
+	ssoSession := noCredentials && credentialProcess == ""
+	if (global.TokenCache || ssoSession) && tokenCachePathErr == nil {
+		oauthClient.SetTokenCache(tokenCache)
+	}
+	authedClient = oauthClient
+
+	if global.Record != "" && global.Replay != "" {
+		return errors.New("--record and --replay cannot be used together")
+	}
+
+	if global.Replay != "" {
+		cassette, err := network.LoadCassette(global.Replay)
+		if err != nil {
+			return err
+		}
+
+		unauthenticatedClient = network.NewReplayClient(cassette)
+		authedClient = unauthenticatedClient
+	}
+
+	if global.Record != "" {
+		cassette := network.NewCassette(global.Record)
+		unauthenticatedClient = network.NewRecordClient(unauthenticatedClient, cassette)
+		authedClient = network.NewRecordClient(authedClient, cassette)
+	}
+
 	if global.DecryptionPassphrase != "" {
 		authedClient = network.NewDecryptClient(authedClient, unauthenticatedClient, global.DecryptionPassphrase, os.Stderr)
+	} else if global.resolveDecryptionPassphrase != nil {
+		authedClient = newContextDecryptClient(authedClient, unauthenticatedClient, global.resolveDecryptionPassphrase)
 	}
 
 	unauthenticatedProgressClient = network.NewProgressClient(unauthenticatedClient, os.Stderr)
 	authedProgressClient = network.NewProgressClient(authedClient, os.Stderr)
 
 	if global.Trace {
-		unauthenticatedClient = network.NewTraceClient(unauthenticatedClient, os.Stderr)
-		unauthenticatedProgressClient = network.NewTraceClient(unauthenticatedProgressClient, os.Stderr)
-		authedClient = network.NewTraceClient(authedClient, os.Stderr)
-		authedProgressClient = network.NewTraceClient(authedProgressClient, os.Stderr)
+		unauthenticatedClient = network.NewTraceClient(unauthenticatedClient, os.Stderr, !global.TraceUnredacted)
+		unauthenticatedProgressClient = network.NewTraceClient(unauthenticatedProgressClient, os.Stderr, !global.TraceUnredacted)
+		authedClient = network.NewTraceClient(authedClient, os.Stderr, !global.TraceUnredacted)
+		authedProgressClient = network.NewTraceClient(authedProgressClient, os.Stderr, !global.TraceUnredacted)
+	}
+
+	if global.TraceFile != "" {
+		harFile := network.NewHARFile(global.TraceFile, version)
+		unauthenticatedClient = network.NewHARClient(unauthenticatedClient, harFile, !global.TraceUnredacted)
+		unauthenticatedProgressClient = network.NewHARClient(unauthenticatedProgressClient, harFile, !global.TraceUnredacted)
+		authedClient = network.NewHARClient(authedClient, harFile, !global.TraceUnredacted)
+		authedProgressClient = network.NewHARClient(authedProgressClient, harFile, !global.TraceUnredacted)
 	}
 
+	// retries are opt-in, so that a command does not wait on an Ops Manager
+	// that is down unless asked to
+	var maxRetries int
+	if global.MaxRetries != nil {
+		maxRetries = *global.MaxRetries
+	}
+	unauthenticatedClient = network.NewRetryClient(unauthenticatedClient, maxRetries, time.Second, os.Stderr)
+	unauthenticatedProgressClient = network.NewRetryClient(unauthenticatedProgressClient, maxRetries, time.Second, os.Stderr)
+	authedClient = network.NewRetryClient(authedClient, maxRetries, time.Second, os.Stderr)
+	authedProgressClient = network.NewRetryClient(authedProgressClient, maxRetries, time.Second, os.Stderr)
+
 	api := api.New(api.ApiInput{
 		Client:                 authedClient,
 		UnauthedClient:         unauthenticatedClient,
@@ -203,6 +312,18 @@ func Main(sout io.Writer, serr io.Writer, version string, applySleepDurationStri
 	if err != nil {
 		return err
 	}
+	_, err = parser.AddCommand(
+		"bundle",
+		"moves products and stemcells into an air-gapped environment",
+		"bundle create downloads the products and stemcells of a download-products manifest into a single tarball, with their checksums and optionally their config templates. bundle upload verifies those checksums on the air-gapped side, then uploads every product and stemcell that the Ops Manager targeted does not already have.",
+		commands.NewBundle(
+			commands.NewBundleCreate(os.Environ, stdout, stderr, os.Stderr, api),
+			commands.NewBundleUpload(api, stdout, commands.UploadRetryBackoff),
+		),
+	)
+	if err != nil {
+		return err
+	}
 	_, err = parser.AddCommand(
 		"certificate-authorities",
 		"lists certificates managed by Ops Manager",
@@ -221,6 +342,15 @@ func Main(sout io.Writer, serr io.Writer, version string, applySleepDurationStri
 	if err != nil {
 		return err
 	}
+	_, err = parser.AddCommand(
+		"check-compatibility",
+		"checks a product file can be staged",
+		"This authenticated command checks that the minimum Ops Manager version and the product dependencies of a product file are satisfied by the staged products, and reports the dependencies that would block staging it.",
+		commands.NewCheckCompatibility(api, metadataExtractor, os.Stdout),
+	)
+	if err != nil {
+		return err
+	}
 	_, err = parser.AddCommand(
 		"config-template",
 		"generates a config template from a Pivnet product",
@@ -428,6 +558,15 @@ func Main(sout io.Writer, serr io.Writer, version string, applySleepDurationStri
 	if err != nil {
 		return err
 	}
+	_, err = parser.AddCommand(
+		"download-products",
+		"downloads the products and stemcells listed in a manifest",
+		"This command downloads every product listed in a manifest, along with their stemcells, using the same options as download-product. Shared stemcells are only downloaded once, and a single download-file.json describes all of the downloaded files.",
+		commands.NewDownloadProducts(os.Environ, stdout, stderr, os.Stderr, api),
+	)
+	if err != nil {
+		return err
+	}
 	_, err = parser.AddCommand(
 		"errands",
 		"list errands for a product",
@@ -500,6 +639,15 @@ func Main(sout io.Writer, serr io.Writer, version string, applySleepDurationStri
 	if err != nil {
 		return err
 	}
+	_, err = parser.AddCommand(
+		"inspect-product",
+		"lists what a product file bundles and requires",
+		"This command lists the releases, stemcell criteria, product dependencies, runtime configs, job types and errands of a product file, either on disk or served over http(s).",
+		commands.NewInspectProduct(metadataExtractor, os.Stdout),
+	)
+	if err != nil {
+		return err
+	}
 	_, err = parser.AddCommand(
 		"installation-log",
 		"output installation logs",
@@ -536,6 +684,47 @@ func Main(sout io.Writer, serr io.Writer, version string, applySleepDurationStri
 	if err != nil {
 		return err
 	}
+	_, err = parser.AddCommand(
+		"login",
+		"logs in to Ops Manager with single sign-on",
+		"This command logs in to an Ops Manager that authenticates users with SAML, using a one-time passcode from UAA. Commands run without credentials then use this session, until it expires or om logout.",
+		commands.NewLogin(oauthClient, os.Stdin, stdout),
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = parser.AddCommand(
+		"context",
+		"manages the contexts of the env file",
+		"A context names the target, credentials and CA of one Ops Manager in the env file. context list lists them, context use sets the current-context of the env file, and context show prints the settings of a context without its inline secrets. Any command uses another context with --context.",
+		commands.NewContext(newEnvFileContexts(global.Env, global.Context), stdout),
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = parser.AddCommand(
+		"logout",
+		"removes cached UAA tokens",
+		"This command removes the UAA token cached by --token-cache or om login --sso for the current target and user, or with --all every cached token.",
+		commands.NewLogout(tokenCache, func() (string, error) {
+			principal, _ := network.TokenCachePrincipal(global.Username, global.Password, global.ClientID, global.ClientSecret, credentialProcess)
+			return network.TokenCacheKey(global.Target, global.UAATarget, principal)
+		}, stdout),
+	)
+	if err != nil {
+		return err
+	}
+	_, err = parser.AddCommand(
+		"mirror-product",
+		"copies a product and its stemcell from Pivotal Network into a blobstore",
+		"This command downloads a product file, and optionally its stemcell, from Pivotal Network and uploads them to a blobstore with the [slug,version] prefix and SHA256 sidecar expected by download-product. Files that already exist in the blobstore are skipped.",
+		commands.NewMirrorProduct(os.Environ, stdout, stderr, os.Stderr),
+	)
+	if err != nil {
+		return err
+	}
 	_, err = parser.AddCommand(
 		"pending-changes",
 		"checks for pending changes",
@@ -662,20 +851,33 @@ func Main(sout io.Writer, serr io.Writer, version string, applySleepDurationStri
 	if err != nil {
 		return err
 	}
+	uploadProduct := commands.NewUploadProduct(form, metadataExtractor, api, stdout)
+	uploadProduct.SetRetryBackoff(commands.UploadRetryBackoff)
 	_, err = parser.AddCommand(
 		"upload-product",
 		"uploads a given product to the Ops Manager targeted",
 		"This command attempts to upload a product to the Ops Manager",
-		commands.NewUploadProduct(form, metadataExtractor, api, stdout),
+		uploadProduct,
 	)
 	if err != nil {
 		return err
 	}
+	uploadStemcell := commands.NewUploadStemcell(form, api, stdout)
+	uploadStemcell.SetRetryBackoff(commands.UploadRetryBackoff)
 	_, err = parser.AddCommand(
 		"upload-stemcell",
 		"uploads a given stemcell to the Ops Manager targeted",
 		"This command will upload a stemcell to the target Ops Manager. Unless the force flag is used, if the stemcell already exists that upload will be skipped",
-		commands.NewUploadStemcell(form, api, stdout),
+		uploadStemcell,
+	)
+	if err != nil {
+		return err
+	}
+	_, err = parser.AddCommand(
+		"verify-product",
+		"verifies the contents of a product file",
+		"This command reads every entry of a product file to check it against the zip central directory, checks that the releases listed in its metadata are bundled with the SHA1s the metadata records, and optionally verifies a detached signature of the file.",
+		commands.NewVerifyProduct(stdout),
 	)
 	if err != nil {
 		return err
@@ -720,7 +922,7 @@ func setEnvFileProperties(global *options) error {
 		return nil
 	}
 
-	var opts options
+	var file envFile
 	_, err := os.Open(global.Env)
 	if err != nil {
 		return fmt.Errorf("env file does not exist: %s", err)
@@ -740,11 +942,18 @@ func setEnvFileProperties(global *options) error {
 		return err
 	}
 
-	err = yaml.UnmarshalStrict(contents, &opts)
+	err = yaml.UnmarshalStrict(contents, &file)
 	if err != nil {
 		return fmt.Errorf("could not parse env file: %s", err)
 	}
 
+	err = setContextProperties(global, file)
+	if err != nil {
+		return err
+	}
+
+	opts := file.options
+
 	if global.ClientID == "" {
 		global.ClientID = opts.ClientID
 	}
@@ -754,9 +963,21 @@ func setEnvFileProperties(global *options) error {
 	if global.Password == "" {
 		global.Password = opts.Password
 	}
+	if global.CredentialProcess == "" {
+		global.CredentialProcess = opts.CredentialProcess
+	}
 	if global.ConnectTimeout == 10 && opts.ConnectTimeout != 0 {
 		global.ConnectTimeout = opts.ConnectTimeout
 	}
+	if global.MaxRetries == nil {
+		global.MaxRetries = opts.MaxRetries
+	}
+	if global.Record == "" {
+		global.Record = opts.Record
+	}
+	if global.Replay == "" {
+		global.Replay = opts.Replay
+	}
 	if global.RequestTimeout == 1800 && opts.RequestTimeout != 0 {
 		global.RequestTimeout = opts.RequestTimeout
 	}
@@ -769,15 +990,48 @@ func setEnvFileProperties(global *options) error {
 	if !global.Trace {
 		global.Trace = opts.Trace
 	}
+	if global.TraceFile == "" {
+		global.TraceFile = opts.TraceFile
+	}
+	if !global.TraceUnredacted {
+		global.TraceUnredacted = opts.TraceUnredacted
+	}
+	if !global.TokenCache {
+		global.TokenCache = opts.TokenCache
+	}
 	if global.Username == "" {
 		global.Username = opts.Username
 	}
-	if global.DecryptionPassphrase == "" {
+	if global.DecryptionPassphrase == "" && global.resolveDecryptionPassphrase == nil {
 		global.DecryptionPassphrase = opts.DecryptionPassphrase
 	}
 	if global.CACert == "" {
 		global.CACert = opts.CACert
 	}
+	if global.ClientCert == "" {
+		global.ClientCert = opts.ClientCert
+	}
+	if global.ClientKey == "" {
+		global.ClientKey = opts.ClientKey
+	}
+	if global.ClientKeyPassphrase == "" {
+		global.ClientKeyPassphrase = opts.ClientKeyPassphrase
+	}
+	if global.ProxyURL == "" {
+		global.ProxyURL = opts.ProxyURL
+	}
+	if global.ProxyUsername == "" {
+		global.ProxyUsername = opts.ProxyUsername
+	}
+	if global.ProxyPassword == "" {
+		global.ProxyPassword = opts.ProxyPassword
+	}
+	if global.ProxyAuthType == "" {
+		global.ProxyAuthType = opts.ProxyAuthType
+	}
+	if global.ProxyKrb5Config == "" {
+		global.ProxyKrb5Config = opts.ProxyKrb5Config
+	}
 
 	err = checkForVars(global)
 	if err != nil {
@@ -0,0 +1,34 @@
+package commands
+
+const bundleManifestName = "bundle.json"
+
+type Bundle struct {
+	Create BundleCreate `command:"create" description:"Download the products and stemcells of a download-products manifest into a bundle"`
+	Upload BundleUpload `command:"upload" description:"Verify a bundle and upload its products and stemcells to the Ops Manager targeted"`
+}
+
+// bundleManifest is written as bundle.json at the root of a bundle. Paths
+// are relative to the root of the bundle.
+type bundleManifest struct {
+	Products  []bundledArtifact `json:"products"`
+	Stemcells []bundledArtifact `json:"stemcells"`
+}
+
+type bundledArtifact struct {
+	Path    string `json:"path"`
+	Slug    string `json:"slug,omitempty"`
+	OS      string `json:"os,omitempty"`
+	Version string `json:"version"`
+	SHA256  string `json:"sha256"`
+}
+
+func NewBundle(create BundleCreate, upload BundleUpload) *Bundle {
+	return &Bundle{
+		Create: create,
+		Upload: upload,
+	}
+}
+
+func (*Bundle) Execute(args []string) error {
+	return nil
+}
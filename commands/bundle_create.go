@@ -0,0 +1,245 @@
+package commands
+
+import (
+	"archive/tar"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/pivotal-cf/om/configtemplate/generator"
+	"github.com/pivotal-cf/om/configtemplate/metadata"
+)
+
+type BundleCreate struct {
+	environFunc    func() []string
+	progressWriter io.Writer
+	stderr         *log.Logger
+	stdout         *log.Logger
+	service        downloadProductService
+	Options        struct {
+		Manifest        string   `long:"manifest"         short:"m" required:"true" description:"path to a download-products manifest listing the products to bundle (see docs/download-products/README.md for format)"`
+		Output          string   `long:"output"           short:"o" required:"true" description:"path of the bundle tarball to create"`
+		ConfigTemplates bool     `long:"config-templates"                           description:"include the config templates of each product, as generated by config-template"`
+		Concurrency     int      `long:"concurrency"                                description:"number of products downloaded at the same time" default:"2"`
+		VarsFile        []string `long:"vars-file"        short:"l"                 description:"load variables from a YAML file"`
+		Vars            []string `long:"var"              short:"v"                 description:"load variable from the command line. Format: VAR=VAL"`
+		VarsEnv         []string `long:"vars-env"         env:"OM_VARS_ENV"         description:"load variables from environment variables (e.g.: 'MY' to load MY_var=value)"`
+		OpsFile         []string `long:"ops-file"                                   description:"YAML operations file"`
+	}
+}
+
+func NewBundleCreate(environFunc func() []string, stdout *log.Logger, stderr *log.Logger, progressWriter io.Writer, downloadProductService downloadProductService) BundleCreate {
+	return BundleCreate{
+		environFunc:    environFunc,
+		stdout:         stdout,
+		stderr:         stderr,
+		progressWriter: progressWriter,
+		service:        downloadProductService,
+	}
+}
+
+func (bc BundleCreate) Execute(_ []string) error {
+	downloads := NewDownloadProducts(bc.environFunc, bc.stdout, bc.stderr, bc.progressWriter, bc.service)
+	downloads.Options.Manifest = bc.Options.Manifest
+	downloads.Options.Concurrency = bc.Options.Concurrency
+	downloads.Options.VarsFile = bc.Options.VarsFile
+	downloads.Options.Vars = bc.Options.Vars
+	downloads.Options.VarsEnv = bc.Options.VarsEnv
+	downloads.Options.OpsFile = bc.Options.OpsFile
+
+	err := downloads.Execute(nil)
+	if err != nil {
+		return err
+	}
+
+	manifest, err := downloads.loadManifest()
+	if err != nil {
+		return err
+	}
+
+	downloadFilePath := filepath.Join(manifest.Options["output-directory"].(string), "download-file.json")
+	contents, err := os.ReadFile(downloadFilePath)
+	if err != nil {
+		return fmt.Errorf("could not read %s: %w", downloadFilePath, err)
+	}
+
+	var downloaded struct {
+		Products  []downloadedProductOutput `json:"products"`
+		Stemcells []struct {
+			StemcellPath    string `json:"stemcell_path"`
+			StemcellVersion string `json:"stemcell_version"`
+		} `json:"stemcells"`
+	}
+	err = json.Unmarshal(contents, &downloaded)
+	if err != nil {
+		return fmt.Errorf("could not parse %s: %w", downloadFilePath, err)
+	}
+
+	output, err := os.Create(bc.Options.Output)
+	if err != nil {
+		return fmt.Errorf("could not create the bundle: %w", err)
+	}
+	defer output.Close()
+
+	tarWriter := tar.NewWriter(output)
+	bundle := bundleManifest{
+		Products:  []bundledArtifact{},
+		Stemcells: []bundledArtifact{},
+	}
+
+	for _, product := range downloaded.Products {
+		artifact := bundledArtifact{
+			Path:    path.Join("products", filepath.Base(product.ProductPath)),
+			Slug:    product.ProductSlug,
+			Version: product.ProductVersion,
+		}
+
+		bc.stderr.Printf("adding %s to the bundle", product.ProductPath)
+		artifact.SHA256, err = addFileToTar(tarWriter, product.ProductPath, artifact.Path)
+		if err != nil {
+			return err
+		}
+		bundle.Products = append(bundle.Products, artifact)
+
+		if bc.Options.ConfigTemplates {
+			err = addConfigTemplatesToTar(tarWriter, product.ProductPath)
+			if err != nil {
+				return fmt.Errorf("could not generate the config templates of %s: %w", product.ProductPath, err)
+			}
+		}
+	}
+
+	// only the stemcells downloaded with --all-stemcells record their OS
+	stemcellOS := map[string]string{}
+	for _, product := range downloaded.Products {
+		for _, stemcell := range product.Stemcells {
+			stemcellOS[stemcell.StemcellPath] = stemcell.OS
+		}
+	}
+
+	for _, stemcell := range downloaded.Stemcells {
+		artifact := bundledArtifact{
+			Path:    path.Join("stemcells", filepath.Base(stemcell.StemcellPath)),
+			OS:      stemcellOS[stemcell.StemcellPath],
+			Version: stemcell.StemcellVersion,
+		}
+
+		bc.stderr.Printf("adding %s to the bundle", stemcell.StemcellPath)
+		artifact.SHA256, err = addFileToTar(tarWriter, stemcell.StemcellPath, artifact.Path)
+		if err != nil {
+			return err
+		}
+		bundle.Stemcells = append(bundle.Stemcells, artifact)
+	}
+
+	_, err = addFileToTar(tarWriter, downloadFilePath, "download-file.json")
+	if err != nil {
+		return err
+	}
+
+	contents, err = json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	err = addBytesToTar(tarWriter, contents, bundleManifestName)
+	if err != nil {
+		return err
+	}
+
+	err = tarWriter.Close()
+	if err != nil {
+		return fmt.Errorf("could not write the bundle: %w", err)
+	}
+
+	bc.stderr.Printf("bundled %d products and %d stemcells into %s", len(bundle.Products), len(bundle.Stemcells), bc.Options.Output)
+
+	return nil
+}
+
+func addFileToTar(tarWriter *tar.Writer, filePath, name string) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", fmt.Errorf("could not add %s to the bundle: %w", filePath, err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return "", fmt.Errorf("could not add %s to the bundle: %w", filePath, err)
+	}
+
+	err = tarWriter.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0644,
+		Size:    info.Size(),
+		ModTime: info.ModTime(),
+	})
+	if err != nil {
+		return "", fmt.Errorf("could not add %s to the bundle: %w", filePath, err)
+	}
+
+	digest := sha256.New()
+	_, err = io.Copy(io.MultiWriter(tarWriter, digest), file)
+	if err != nil {
+		return "", fmt.Errorf("could not add %s to the bundle: %w", filePath, err)
+	}
+
+	return fmt.Sprintf("%x", digest.Sum(nil)), nil
+}
+
+func addBytesToTar(tarWriter *tar.Writer, contents []byte, name string) error {
+	err := tarWriter.WriteHeader(&tar.Header{
+		Name: name,
+		Mode: 0644,
+		Size: int64(len(contents)),
+	})
+	if err != nil {
+		return fmt.Errorf("could not add %s to the bundle: %w", name, err)
+	}
+
+	_, err = tarWriter.Write(contents)
+	if err != nil {
+		return fmt.Errorf("could not add %s to the bundle: %w", name, err)
+	}
+
+	return nil
+}
+
+func addConfigTemplatesToTar(tarWriter *tar.Writer, productPath string) error {
+	metadataBytes, err := metadata.NewFileProvider(productPath).MetadataBytes()
+	if err != nil {
+		return err
+	}
+
+	templatesDir, err := os.MkdirTemp("", "om-config-templates-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(templatesDir)
+
+	err = generator.NewExecutor(metadataBytes, templatesDir, false, true, 10, false).Generate()
+	if err != nil {
+		return err
+	}
+
+	return filepath.WalkDir(templatesDir, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+
+		relativePath, err := filepath.Rel(templatesDir, filePath)
+		if err != nil {
+			return err
+		}
+
+		_, err = addFileToTar(tarWriter, filePath, path.Join("config-templates", filepath.ToSlash(relativePath)))
+		return err
+	})
+}
@@ -0,0 +1,353 @@
+package commands_test
+
+import (
+	"archive/tar"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/onsi/gomega/gbytes"
+
+	"github.com/pivotal-cf/om/api"
+	"github.com/pivotal-cf/om/commands"
+	cmdFakes "github.com/pivotal-cf/om/commands/fakes"
+	"github.com/pivotal-cf/om/download_clients"
+	"github.com/pivotal-cf/om/download_clients/fakes"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func readBundle(bundlePath string) map[string]string {
+	file, err := os.Open(bundlePath)
+	Expect(err).ToNot(HaveOccurred())
+	defer file.Close()
+
+	entries := map[string]string{}
+	reader := tar.NewReader(file)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			return entries
+		}
+		Expect(err).ToNot(HaveOccurred())
+
+		contents, err := io.ReadAll(reader)
+		Expect(err).ToNot(HaveOccurred())
+		entries[header.Name] = string(contents)
+	}
+}
+
+func writeBundle(bundlePath string, entries map[string]string) {
+	file, err := os.Create(bundlePath)
+	Expect(err).ToNot(HaveOccurred())
+	defer file.Close()
+
+	writer := tar.NewWriter(file)
+	for name, contents := range entries {
+		Expect(writer.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(contents))})).To(Succeed())
+		_, err = writer.Write([]byte(contents))
+		Expect(err).ToNot(HaveOccurred())
+	}
+	Expect(writer.Close()).To(Succeed())
+}
+
+var _ = Describe("Bundle", func() {
+	var (
+		buffer    *gbytes.Buffer
+		bundleDir string
+	)
+
+	BeforeEach(func() {
+		buffer = gbytes.NewBuffer()
+		bundleDir = GinkgoT().TempDir()
+	})
+
+	Describe("create", func() {
+		var (
+			command               commands.BundleCreate
+			fakeProductDownloader *fakes.ProductDownloader
+			manifestPath          string
+		)
+
+		BeforeEach(func() {
+			fakeProductDownloader = &fakes.ProductDownloader{}
+			fakeProductDownloader.NameReturns("pivnet")
+			fakeProductDownloader.GetAllProductVersionsReturns([]string{"1.2.3"}, nil)
+
+			fa := &fakes.FileArtifacter{}
+			fa.NameReturns("fake-tile-1.2.3.pivotal")
+			fakeProductDownloader.GetLatestProductFileReturns(fa, nil)
+			fakeProductDownloader.DownloadProductToFileStub = func(_ download_clients.FileArtifacter, file *os.File) error {
+				createProductPivotalFile(file)
+				return nil
+			}
+
+			download_clients.NewPivnetClient = func(stdout *log.Logger, stderr *log.Logger, factory download_clients.PivnetFactory, token string, skipSSL bool, pivnetHost string, proxyURL string, proxyUsername string, proxyPassword string, proxyAuthType string, proxyKrb5Config string) (download_clients.ProductDownloader, error) {
+				return fakeProductDownloader, nil
+			}
+
+			manifestPath = filepath.Join(bundleDir, "products.yml")
+			Expect(os.WriteFile(manifestPath, []byte(fmt.Sprintf(`
+pivnet-api-token: token
+output-directory: %s
+products:
+- pivnet-product-slug: fake-tile
+  product-version: 1.2.3
+  file-glob: "*.pivotal"
+`, GinkgoT().TempDir())), 0600)).To(Succeed())
+
+			command = commands.NewBundleCreate(func() []string { return nil }, log.New(buffer, "", 0), log.New(buffer, "", 0), buffer, &cmdFakes.DownloadProductService{})
+		})
+
+		It("bundles the downloaded products with their checksums", func() {
+			bundlePath := filepath.Join(bundleDir, "bundle.tar")
+			err := executeCommand(&command, []string{"--manifest", manifestPath, "--output", bundlePath, "--config-templates"})
+			Expect(err).ToNot(HaveOccurred())
+
+			entries := readBundle(bundlePath)
+			Expect(entries).To(HaveKey("products/fake-tile-1.2.3.pivotal"))
+			Expect(entries).To(HaveKey("download-file.json"))
+			Expect(entries).To(HaveKey("config-templates/fake-tile/1.2.3/product.yml"))
+
+			var manifest struct {
+				Products []struct {
+					Path    string `json:"path"`
+					Slug    string `json:"slug"`
+					Version string `json:"version"`
+					SHA256  string `json:"sha256"`
+				} `json:"products"`
+			}
+			Expect(json.Unmarshal([]byte(entries["bundle.json"]), &manifest)).To(Succeed())
+			Expect(manifest.Products).To(HaveLen(1))
+			Expect(manifest.Products[0].Path).To(Equal("products/fake-tile-1.2.3.pivotal"))
+			Expect(manifest.Products[0].Slug).To(Equal("fake-tile"))
+			Expect(manifest.Products[0].Version).To(Equal("1.2.3"))
+			Expect(manifest.Products[0].SHA256).To(HaveLen(64))
+
+			Expect(buffer).To(gbytes.Say("bundled 1 products and 0 stemcells into " + bundlePath))
+		})
+
+		It("errors when the products cannot be downloaded", func() {
+			fakeProductDownloader.GetLatestProductFileReturns(nil, errors.New("some error"))
+
+			err := executeCommand(&command, []string{"--manifest", manifestPath, "--output", filepath.Join(bundleDir, "bundle.tar")})
+			Expect(err).To(MatchError(ContainSubstring("could not download fake-tile")))
+			Expect(filepath.Join(bundleDir, "bundle.tar")).ToNot(BeAnExistingFile())
+		})
+	})
+
+	Describe("upload", func() {
+		var (
+			command     commands.BundleUpload
+			fakeService *cmdFakes.BundleUploadService
+			logger      *cmdFakes.Logger
+			bundlePath  string
+			productFile string
+		)
+
+		BeforeEach(func() {
+			fakeService = &cmdFakes.BundleUploadService{}
+			fakeService.UploadAvailableProductStub = func(input api.UploadAvailableProductInput) (api.UploadAvailableProductOutput, error) {
+				_, err := io.Copy(io.Discard, input.Product)
+				return api.UploadAvailableProductOutput{}, err
+			}
+			fakeService.UploadStemcellStub = func(input api.StemcellUploadInput) (api.StemcellUploadOutput, error) {
+				_, err := io.Copy(io.Discard, input.Stemcell)
+				return api.StemcellUploadOutput{}, err
+			}
+			fakeService.InfoReturns(api.Info{Version: "3.0.0"}, nil)
+			logger = &cmdFakes.Logger{}
+
+			file, err := os.CreateTemp(bundleDir, "*.pivotal")
+			Expect(err).ToNot(HaveOccurred())
+			createProductPivotalFile(file)
+			contents, err := os.ReadFile(file.Name())
+			Expect(err).ToNot(HaveOccurred())
+			productFile = string(contents)
+
+			bundlePath = filepath.Join(bundleDir, "bundle.tar")
+			writeBundle(bundlePath, map[string]string{
+				"products/fake-tile-1.2.3.pivotal":          productFile,
+				"stemcells/bosh-stemcell-1.100-vsphere.tgz": "some-stemcell",
+				"bundle.json": fmt.Sprintf(`{
+  "products": [{"path": "products/fake-tile-1.2.3.pivotal", "slug": "fake-tile", "version": "1.2.3", "sha256": "%s"}],
+  "stemcells": [{"path": "stemcells/bosh-stemcell-1.100-vsphere.tgz", "version": "1.100", "sha256": "%s"}]
+}`, sha256Of(productFile), sha256Of("some-stemcell")),
+			})
+
+			command = commands.NewBundleUpload(fakeService, logger, 0)
+		})
+
+		It("verifies the bundle and uploads its products and stemcells", func() {
+			err := executeCommand(&command, []string{bundlePath})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fakeService.CheckProductAvailabilityCallCount()).To(Equal(1))
+			name, version := fakeService.CheckProductAvailabilityArgsForCall(0)
+			Expect(name).To(Equal("fake-tile"))
+			Expect(version).To(Equal("1.2.3"))
+			Expect(fakeService.UploadAvailableProductCallCount()).To(Equal(1))
+
+			Expect(fakeService.CheckStemcellAvailabilityArgsForCall(0)).To(HaveSuffix("stemcells/bosh-stemcell-1.100-vsphere.tgz"))
+			Expect(fakeService.UploadStemcellCallCount()).To(Equal(1))
+
+			format, v := logger.PrintfArgsForCall(1)
+			Expect(fmt.Sprintf(format, v...)).To(Equal("verified the checksums of 1 products and 1 stemcells"))
+
+			Expect(fakeService.ListMultiStemcellsCallCount()).To(Equal(0))
+			Expect(fakeService.AssignMultiStemcellCallCount()).To(Equal(0))
+		})
+
+		When("assigning the stemcells", func() {
+			var productStemcell api.ProductMultiStemcell
+
+			BeforeEach(func() {
+				productStemcell = api.ProductMultiStemcell{
+					GUID:        "fake-tile-guid",
+					ProductName: "fake-tile",
+					StagedStemcells: []api.StemcellObject{
+						{OS: "ubuntu-jammy", Version: "1.90"},
+						{OS: "windows2019", Version: "2019.76"},
+					},
+					AvailableVersions: []api.StemcellObject{
+						{OS: "ubuntu-jammy", Version: "1.90"},
+						{OS: "ubuntu-jammy", Version: "1.100"},
+						{OS: "windows2019", Version: "2019.76"},
+					},
+				}
+			})
+
+			It("replaces the stemcell staged for their OS once they are uploaded", func() {
+				fakeService.ListMultiStemcellsReturns(api.ProductMultiStemcells{Products: []api.ProductMultiStemcell{productStemcell}}, nil)
+
+				err := executeCommand(&command, []string{"--assign-stemcells", bundlePath})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(fakeService.UploadStemcellCallCount()).To(Equal(1))
+				Expect(fakeService.AssignMultiStemcellCallCount()).To(Equal(1))
+				Expect(fakeService.AssignMultiStemcellArgsForCall(0)).To(Equal(api.ProductMultiStemcells{
+					Products: []api.ProductMultiStemcell{{
+						GUID: "fake-tile-guid",
+						StagedStemcells: []api.StemcellObject{
+							{OS: "ubuntu-jammy", Version: "1.100"},
+							{OS: "windows2019", Version: "2019.76"},
+						},
+					}},
+				}))
+			})
+
+			It("only assigns the stemcells available for the OS the bundle records", func() {
+				writeBundle(bundlePath, map[string]string{
+					"products/fake-tile-1.2.3.pivotal":          productFile,
+					"stemcells/bosh-stemcell-1.100-vsphere.tgz": "some-stemcell",
+					"bundle.json": fmt.Sprintf(`{
+  "products": [{"path": "products/fake-tile-1.2.3.pivotal", "slug": "fake-tile", "version": "1.2.3", "sha256": "%s"}],
+  "stemcells": [{"path": "stemcells/bosh-stemcell-1.100-vsphere.tgz", "os": "windows2019", "version": "1.100", "sha256": "%s"}]
+}`, sha256Of(productFile), sha256Of("some-stemcell")),
+				})
+				fakeService.ListMultiStemcellsReturns(api.ProductMultiStemcells{Products: []api.ProductMultiStemcell{productStemcell}}, nil)
+
+				err := executeCommand(&command, []string{"--assign-stemcells", bundlePath})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(fakeService.AssignMultiStemcellCallCount()).To(Equal(0))
+			})
+
+			It("does not assign stemcells to products that are not staged", func() {
+				productStemcell.ProductName = "other-tile"
+				fakeService.ListMultiStemcellsReturns(api.ProductMultiStemcells{Products: []api.ProductMultiStemcell{productStemcell}}, nil)
+
+				err := executeCommand(&command, []string{"--assign-stemcells", bundlePath})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(fakeService.AssignMultiStemcellCallCount()).To(Equal(0))
+				format, v := logger.PrintfArgsForCall(logger.PrintfCallCount() - 1)
+				Expect(fmt.Sprintf(format, v...)).To(Equal("not assigning stemcells to fake-tile, as it is not staged"))
+			})
+
+			It("does not assign stemcells to products staged for deletion", func() {
+				productStemcell.StagedForDeletion = true
+				fakeService.ListMultiStemcellsReturns(api.ProductMultiStemcells{Products: []api.ProductMultiStemcell{productStemcell}}, nil)
+
+				err := executeCommand(&command, []string{"--assign-stemcells", bundlePath})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(fakeService.AssignMultiStemcellCallCount()).To(Equal(0))
+			})
+
+			It("errors when the stemcells cannot be assigned", func() {
+				fakeService.ListMultiStemcellsReturns(api.ProductMultiStemcells{Products: []api.ProductMultiStemcell{productStemcell}}, nil)
+				fakeService.AssignMultiStemcellReturns(errors.New("some error"))
+
+				err := executeCommand(&command, []string{"--assign-stemcells", bundlePath})
+				Expect(err).To(MatchError("could not assign stemcells to fake-tile: some error"))
+			})
+
+			It("requires Ops Manager 2.6+", func() {
+				fakeService.InfoReturns(api.Info{Version: "2.5.0"}, nil)
+
+				err := executeCommand(&command, []string{"--assign-stemcells", bundlePath})
+				Expect(err).To(MatchError("--assign-stemcells can only be used with OpsManager 2.6+"))
+			})
+		})
+
+		It("skips the products and stemcells that are already present", func() {
+			fakeService.CheckProductAvailabilityReturns(true, nil)
+			fakeService.CheckStemcellAvailabilityReturns(true, nil)
+
+			err := executeCommand(&command, []string{bundlePath})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fakeService.UploadAvailableProductCallCount()).To(Equal(0))
+			Expect(fakeService.UploadStemcellCallCount()).To(Equal(0))
+		})
+
+		It("extracts the bundle into the work directory", func() {
+			workDirectory := GinkgoT().TempDir()
+
+			err := executeCommand(&command, []string{"--work-directory", workDirectory, bundlePath})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(filepath.Join(workDirectory, "products", "fake-tile-1.2.3.pivotal")).To(BeAnExistingFile())
+		})
+
+		It("uploads nothing when a checksum does not match", func() {
+			writeBundle(bundlePath, map[string]string{
+				"products/fake-tile-1.2.3.pivotal": productFile,
+				"bundle.json": `{
+  "products": [{"path": "products/fake-tile-1.2.3.pivotal", "version": "1.2.3", "sha256": "abc"}],
+  "stemcells": [{"path": "stemcells/bosh-stemcell-1.100-vsphere.tgz", "version": "1.100", "sha256": "def"}]
+}`,
+			})
+
+			err := executeCommand(&command, []string{bundlePath})
+			Expect(err).To(MatchError(fmt.Sprintf(`the bundle failed verification: products/fake-tile-1.2.3.pivotal has SHA256 %s, but the bundle expects abc
+stemcells/bosh-stemcell-1.100-vsphere.tgz is missing from the bundle`, sha256Of(productFile))))
+			Expect(fakeService.UploadAvailableProductCallCount()).To(Equal(0))
+			Expect(fakeService.UploadStemcellCallCount()).To(Equal(0))
+		})
+
+		It("refuses entries outside of the bundle", func() {
+			writeBundle(bundlePath, map[string]string{"../outside": "outside"})
+
+			err := executeCommand(&command, []string{bundlePath})
+			Expect(err).To(MatchError("the bundle entry ../outside points outside of the bundle"))
+		})
+
+		It("errors when the file is not a bundle", func() {
+			writeBundle(bundlePath, map[string]string{"some-file": "contents"})
+
+			err := executeCommand(&command, []string{bundlePath})
+			Expect(err).To(MatchError(ContainSubstring(bundlePath + " is not a bundle")))
+		})
+	})
+})
+
+func sha256Of(contents string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(contents)))
+}
@@ -0,0 +1,297 @@
+package commands
+
+import (
+	"archive/tar"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jessevdk/go-flags"
+	"github.com/pivotal-cf/om/api"
+	"github.com/pivotal-cf/om/extractor"
+	"github.com/pivotal-cf/om/formcontent"
+)
+
+//counterfeiter:generate -o ./fakes/bundle_upload_service.go --fake-name BundleUploadService . bundleUploadService
+type bundleUploadService interface {
+	UploadAvailableProduct(api.UploadAvailableProductInput) (api.UploadAvailableProductOutput, error)
+	CheckProductAvailability(string, string) (bool, error)
+	UploadStemcell(api.StemcellUploadInput) (api.StemcellUploadOutput, error)
+	CheckStemcellAvailability(string) (bool, error)
+	GetDiagnosticReport() (api.DiagnosticReport, error)
+	ListMultiStemcells() (api.ProductMultiStemcells, error)
+	AssignMultiStemcell(api.ProductMultiStemcells) error
+	Info() (api.Info, error)
+}
+
+type BundleUpload struct {
+	service      bundleUploadService
+	logger       logger
+	retryBackoff time.Duration
+	Options      struct {
+		WorkDirectory   string `long:"work-directory"   short:"w" description:"directory to extract the bundle into (default: a temporary directory, removed afterwards)"`
+		PollingInterval int    `long:"polling-interval" short:"i" description:"interval (in seconds) at which to print status" default:"1"`
+		AssignStemcells bool   `long:"assign-stemcells"           description:"once uploaded, assign the stemcells of the bundle to each of its products that is staged"`
+		Args            struct {
+			Bundle string `positional-arg-name:"BUNDLE" description:"path to a bundle created by bundle create"`
+		} `positional-args:"yes" required:"yes"`
+	}
+}
+
+func NewBundleUpload(service bundleUploadService, logger logger, retryBackoff time.Duration) BundleUpload {
+	return BundleUpload{
+		service:      service,
+		logger:       logger,
+		retryBackoff: retryBackoff,
+	}
+}
+
+func (bu BundleUpload) Execute(_ []string) error {
+	workDirectory := bu.Options.WorkDirectory
+	if workDirectory == "" {
+		var err error
+		workDirectory, err = os.MkdirTemp("", "om-bundle-")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(workDirectory)
+	}
+
+	bu.logger.Printf("extracting %s into %s", bu.Options.Args.Bundle, workDirectory)
+	checksums, err := extractBundle(bu.Options.Args.Bundle, workDirectory)
+	if err != nil {
+		return err
+	}
+
+	contents, err := os.ReadFile(filepath.Join(workDirectory, bundleManifestName))
+	if err != nil {
+		return fmt.Errorf("%s is not a bundle: %w", bu.Options.Args.Bundle, err)
+	}
+
+	var manifest bundleManifest
+	err = json.Unmarshal(contents, &manifest)
+	if err != nil {
+		return fmt.Errorf("could not parse the %s of the bundle: %w", bundleManifestName, err)
+	}
+
+	var problems []error
+	for _, artifact := range append(manifest.Products, manifest.Stemcells...) {
+		checksum, ok := checksums[artifact.Path]
+		switch {
+		case !ok:
+			problems = append(problems, fmt.Errorf("%s is missing from the bundle", artifact.Path))
+		case checksum != artifact.SHA256:
+			problems = append(problems, fmt.Errorf("%s has SHA256 %s, but the bundle expects %s", artifact.Path, checksum, artifact.SHA256))
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("the bundle failed verification: %w", errors.Join(problems...))
+	}
+	bu.logger.Printf("verified the checksums of %d products and %d stemcells", len(manifest.Products), len(manifest.Stemcells))
+
+	for _, product := range manifest.Products {
+		command := NewUploadProduct(formcontent.NewForm(), extractor.NewMetadataExtractor(), bu.service, bu.logger)
+		command.SetRetryBackoff(bu.retryBackoff)
+		_, err = flags.NewParser(&command.Options, flags.None).ParseArgs([]string{
+			"--product", filepath.Join(workDirectory, filepath.FromSlash(product.Path)),
+			"--polling-interval", fmt.Sprint(bu.Options.PollingInterval),
+		})
+		if err != nil {
+			return err
+		}
+
+		err = command.Execute(nil)
+		if err != nil {
+			return fmt.Errorf("could not upload %s: %w", product.Path, err)
+		}
+	}
+
+	for _, stemcell := range manifest.Stemcells {
+		command := NewUploadStemcell(formcontent.NewForm(), bu.service, bu.logger)
+		command.SetRetryBackoff(bu.retryBackoff)
+		_, err = flags.NewParser(&command.Options, flags.None).ParseArgs([]string{
+			"--stemcell", filepath.Join(workDirectory, filepath.FromSlash(stemcell.Path)),
+		})
+		if err != nil {
+			return err
+		}
+
+		err = command.Execute(nil)
+		if err != nil {
+			return fmt.Errorf("could not upload %s: %w", stemcell.Path, err)
+		}
+	}
+
+	if bu.Options.AssignStemcells {
+		return bu.assignStemcells(workDirectory, manifest)
+	}
+
+	return nil
+}
+
+// assignStemcells assigns the stemcells of the bundle to each product of the
+// bundle that is staged, as Ops Manager cannot assign stemcells to the
+// others. A stemcell replaces the one staged for its OS, while the stemcells
+// staged for other operating systems remain assigned.
+func (bu BundleUpload) assignStemcells(workDirectory string, manifest bundleManifest) error {
+	info, err := bu.service.Info()
+	if err != nil {
+		return errors.New("cannot retrieve version of Ops Manager")
+	}
+
+	validVersion, err := info.VersionAtLeast(2, 6)
+	if err != nil {
+		return fmt.Errorf("could not determine version was 2.6+ compatible: %s", err)
+	}
+
+	if !validVersion {
+		return errors.New("--assign-stemcells can only be used with OpsManager 2.6+")
+	}
+
+	productStemcells, err := bu.service.ListMultiStemcells()
+	if err != nil {
+		return fmt.Errorf("could not list the stemcells of the staged products: %w", err)
+	}
+
+	for _, product := range manifest.Products {
+		metadata, err := extractor.NewMetadataExtractor().ExtractFromFile(filepath.Join(workDirectory, filepath.FromSlash(product.Path)))
+		if err != nil {
+			return fmt.Errorf("could not extract the metadata of %s: %w", product.Path, err)
+		}
+
+		productStemcell, found := findProductMultiStemcell(productStemcells, metadata.Name)
+		if !found || productStemcell.StagedForDeletion {
+			bu.logger.Printf("not assigning stemcells to %s, as it is not staged", metadata.Name)
+			continue
+		}
+
+		var assigned []api.StemcellObject
+		for _, stemcell := range manifest.Stemcells {
+			available, found := availableBundledStemcell(productStemcell.AvailableVersions, stemcell)
+			if found {
+				assigned = append(assigned, available)
+			}
+		}
+
+		if len(assigned) == 0 {
+			bu.logger.Printf("not assigning stemcells to %s, as none of the stemcells of the bundle are available to it", metadata.Name)
+			continue
+		}
+
+		stagedStemcells := assigned
+		for _, staged := range productStemcell.StagedStemcells {
+			if !slices.ContainsFunc(assigned, func(stemcell api.StemcellObject) bool { return stemcell.OS == staged.OS }) {
+				stagedStemcells = append(stagedStemcells, staged)
+			}
+		}
+
+		bu.logger.Printf("assigning stemcells: \"%s\" to product \"%s\"", strings.Join(getAllStemcells(stagedStemcells), ", "), metadata.Name)
+		err = bu.service.AssignMultiStemcell(api.ProductMultiStemcells{
+			Products: []api.ProductMultiStemcell{
+				{
+					GUID:            productStemcell.GUID,
+					StagedStemcells: stagedStemcells,
+				},
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("could not assign stemcells to %s: %w", metadata.Name, err)
+		}
+	}
+
+	return nil
+}
+
+func findProductMultiStemcell(productStemcells api.ProductMultiStemcells, productName string) (api.ProductMultiStemcell, bool) {
+	for _, productStemcell := range productStemcells.Products {
+		if productStemcell.ProductName == productName {
+			return productStemcell, true
+		}
+	}
+
+	return api.ProductMultiStemcell{}, false
+}
+
+// availableBundledStemcell finds the stemcell of the bundle among those
+// available to a product. The bundle only records the OS of the stemcells
+// downloaded with --all-stemcells, so the others are found by their version,
+// as long as a single OS has it.
+func availableBundledStemcell(availableVersions []api.StemcellObject, stemcell bundledArtifact) (api.StemcellObject, bool) {
+	var matches []api.StemcellObject
+	for _, available := range availableVersions {
+		if available.Version == stemcell.Version && (stemcell.OS == "" || available.OS == stemcell.OS) {
+			matches = append(matches, available)
+		}
+	}
+
+	if len(matches) != 1 {
+		return api.StemcellObject{}, false
+	}
+
+	return matches[0], true
+}
+
+// extractBundle extracts a bundle into a directory and returns the SHA256 of
+// each file it contains, keyed by its path in the bundle.
+func extractBundle(bundlePath, directory string) (map[string]string, error) {
+	file, err := os.Open(bundlePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	checksums := map[string]string{}
+	tarReader := tar.NewReader(file)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return checksums, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not read the bundle %s: %w", bundlePath, err)
+		}
+
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		name := path.Clean(header.Name)
+		if path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+			return nil, fmt.Errorf("the bundle entry %s points outside of the bundle", header.Name)
+		}
+
+		checksums[name], err = extractBundleEntry(tarReader, filepath.Join(directory, filepath.FromSlash(name)))
+		if err != nil {
+			return nil, fmt.Errorf("could not extract %s from the bundle: %w", name, err)
+		}
+	}
+}
+
+func extractBundleEntry(reader io.Reader, filePath string) (string, error) {
+	err := os.MkdirAll(filepath.Dir(filePath), 0755)
+	if err != nil {
+		return "", err
+	}
+
+	file, err := os.Create(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	digest := sha256.New()
+	_, err = io.Copy(io.MultiWriter(file, digest), reader)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%x", digest.Sum(nil)), nil
+}
@@ -0,0 +1,218 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/hashicorp/go-version"
+	"github.com/olekukonko/tablewriter"
+	"github.com/pivotal-cf/om/api"
+	"github.com/pivotal-cf/om/extractor"
+)
+
+//counterfeiter:generate -o ./fakes/check_compatibility_service.go --fake-name CheckCompatibilityService . checkCompatibilityService
+type checkCompatibilityService interface {
+	Info() (api.Info, error)
+	ListDeployedProducts() ([]api.DeployedProductOutput, error)
+	ListStagedProducts() (api.StagedProductsOutput, error)
+}
+
+type CheckCompatibility struct {
+	service   checkCompatibilityService
+	inspector productInspector
+	stdout    io.Writer
+	Options   struct {
+		ProductPath string `long:"product-path" short:"p" required:"true" description:"path or http(s) URL of the product file to check"`
+	}
+}
+
+func NewCheckCompatibility(service checkCompatibilityService, inspector productInspector, stdout io.Writer) *CheckCompatibility {
+	return &CheckCompatibility{
+		service:   service,
+		inspector: inspector,
+		stdout:    stdout,
+	}
+}
+
+func (cc CheckCompatibility) Execute(_ []string) error {
+	inspection, err := inspectProductPath(cc.inspector, cc.Options.ProductPath, false)
+	if err != nil {
+		return err
+	}
+
+	report, err := checkProductCompatibility(cc.service, inspection)
+	if err != nil {
+		return err
+	}
+
+	table := tablewriter.NewWriter(cc.stdout)
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.SetAutoWrapText(false)
+	table.SetHeader([]string{"Requirement", "Constraint", "Staged", "Deployed", "Result"})
+	for _, check := range report.checks {
+		result := "ok"
+		if !check.satisfied {
+			result = "blocks staging"
+		}
+		table.Append([]string{check.requirement, check.constraint, check.staged, check.deployed, result})
+	}
+	table.Render()
+
+	return report.err()
+}
+
+type compatibilityCheck struct {
+	requirement string
+	constraint  string
+	staged      string
+	deployed    string
+	satisfied   bool
+	reason      string
+}
+
+type compatibilityReport struct {
+	product string
+	checks  []compatibilityCheck
+}
+
+// err describes every requirement of the product that is not satisfied by
+// the Ops Manager, or returns nil when the product can be staged.
+func (r compatibilityReport) err() error {
+	var reasons []string
+	for _, check := range r.checks {
+		if !check.satisfied {
+			reasons = append(reasons, check.reason)
+		}
+	}
+
+	if len(reasons) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("%s cannot be staged:\n- %s", r.product, strings.Join(reasons, "\n- "))
+}
+
+func inspectProductPath(inspector productInspector, productPath string, releaseSHA1s bool) (*extractor.Inspection, error) {
+	var (
+		inspection *extractor.Inspection
+		err        error
+	)
+
+	if strings.HasPrefix(productPath, "http://") || strings.HasPrefix(productPath, "https://") {
+		inspection, err = inspector.InspectURL(productPath, releaseSHA1s)
+	} else {
+		inspection, err = inspector.InspectFile(productPath, releaseSHA1s)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect product %s: %w", productPath, err)
+	}
+
+	return inspection, nil
+}
+
+// checkProductCompatibility compares the minimum Ops Manager version and the
+// requires_product_versions of a product with what is staged and deployed.
+// A dependency is checked against the staged version of the product it
+// requires, as that is what is deployed alongside it on the next apply.
+func checkProductCompatibility(service checkCompatibilityService, inspection *extractor.Inspection) (compatibilityReport, error) {
+	report := compatibilityReport{
+		product: fmt.Sprintf("%s %s", inspection.Name, inspection.Version),
+	}
+
+	info, err := service.Info()
+	if err != nil {
+		return report, fmt.Errorf("failed to get Ops Manager version: %w", err)
+	}
+
+	if inspection.MinimumOpsManagerVersion != "" {
+		report.checks = append(report.checks, checkOpsManagerVersion(info.Version, inspection.MinimumOpsManagerVersion))
+	}
+
+	deployedProducts, err := service.ListDeployedProducts()
+	if err != nil {
+		return report, fmt.Errorf("failed to list deployed products: %w", err)
+	}
+
+	stagedProducts, err := service.ListStagedProducts()
+	if err != nil {
+		return report, fmt.Errorf("failed to list staged products: %w", err)
+	}
+
+	for _, required := range inspection.RequiredProducts {
+		check := compatibilityCheck{
+			requirement: required.Name,
+			constraint:  required.Version,
+		}
+
+		for _, product := range deployedProducts {
+			if productTemplateName(product.ProductTemplateName, product.Type) == required.Name {
+				check.deployed = product.ProductVersion
+			}
+		}
+		for _, product := range stagedProducts.Products {
+			if productTemplateName(product.ProductTemplateName, product.Type) == required.Name {
+				check.staged = product.ProductVersion
+			}
+		}
+
+		switch {
+		case check.staged == "":
+			check.reason = fmt.Sprintf("requires %s %s, which is not staged", required.Name, required.Version)
+		default:
+			check.satisfied, err = versionSatisfies(check.staged, required.Version)
+			if err != nil {
+				return report, fmt.Errorf("could not check the dependency on %s %s: %w", required.Name, required.Version, err)
+			}
+			check.reason = fmt.Sprintf("requires %s %s, but %s %s is staged", required.Name, required.Version, required.Name, check.staged)
+		}
+
+		report.checks = append(report.checks, check)
+	}
+
+	return report, nil
+}
+
+func checkOpsManagerVersion(opsManagerVersion, minimumVersion string) compatibilityCheck {
+	check := compatibilityCheck{
+		requirement: "Ops Manager",
+		constraint:  ">= " + minimumVersion,
+		staged:      opsManagerVersion,
+		deployed:    opsManagerVersion,
+		reason:      fmt.Sprintf("requires Ops Manager %s or newer, but the Ops Manager is %s", minimumVersion, opsManagerVersion),
+	}
+
+	satisfied, err := versionSatisfies(opsManagerVersion, check.constraint)
+	if err != nil {
+		check.reason = fmt.Sprintf("requires Ops Manager %s or newer: %s", minimumVersion, err)
+		return check
+	}
+
+	check.satisfied = satisfied
+	return check
+}
+
+// versionSatisfies ignores the build suffix of the installed version, such as
+// the -build.123 of 2.13.5-build.123, which would otherwise be treated as a
+// pre-release that no constraint matches.
+func versionSatisfies(installedVersion, constraint string) (bool, error) {
+	installed, err := version.NewVersion(installedVersion)
+	if err != nil {
+		return false, fmt.Errorf("invalid version %q: %w", installedVersion, err)
+	}
+
+	constraints, err := version.NewConstraint(constraint)
+	if err != nil {
+		return false, fmt.Errorf("invalid version constraint %q: %w", constraint, err)
+	}
+
+	return constraints.Check(installed.Core()), nil
+}
+
+func productTemplateName(templateName, productType string) string {
+	if templateName != "" {
+		return templateName
+	}
+
+	return productType
+}
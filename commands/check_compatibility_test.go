@@ -0,0 +1,112 @@
+package commands_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/pivotal-cf/om/api"
+	"github.com/pivotal-cf/om/commands"
+	"github.com/pivotal-cf/om/commands/fakes"
+	"github.com/pivotal-cf/om/extractor"
+)
+
+const compatibilityMetadata = `---
+name: some-product
+product_version: 1.2.3
+metadata_version: "3.0"
+requires_product_versions:
+- name: cf
+  version: ~> 2.13
+- name: p-isolation-segment
+  version: ">= 2.13.0"
+`
+
+func createTileWithMetadata(metadata string) string {
+	productPath := filepath.Join(GinkgoT().TempDir(), "product.pivotal")
+	file, err := os.Create(productPath)
+	Expect(err).ToNot(HaveOccurred())
+	defer file.Close()
+
+	z := zip.NewWriter(file)
+	f, err := z.Create("metadata/some-product.yml")
+	Expect(err).ToNot(HaveOccurred())
+	_, err = f.Write([]byte(metadata))
+	Expect(err).ToNot(HaveOccurred())
+	Expect(z.Close()).To(Succeed())
+
+	return productPath
+}
+
+var _ = Describe("CheckCompatibility", func() {
+	var (
+		fakeService *fakes.CheckCompatibilityService
+		stdout      *bytes.Buffer
+		command     *commands.CheckCompatibility
+		productPath string
+	)
+
+	BeforeEach(func() {
+		fakeService = &fakes.CheckCompatibilityService{}
+		fakeService.InfoReturns(api.Info{Version: "3.0.12-build.5"}, nil)
+		fakeService.ListDeployedProductsReturns([]api.DeployedProductOutput{
+			{Type: "cf", ProductVersion: "2.13.1"},
+		}, nil)
+		fakeService.ListStagedProductsReturns(api.StagedProductsOutput{Products: []api.StagedProduct{
+			{Type: "cf", ProductVersion: "2.13.5-build.3"},
+			{Type: "p-isolation-segment-blue", ProductTemplateName: "p-isolation-segment", ProductVersion: "2.13.2"},
+		}}, nil)
+
+		productPath = createTileWithMetadata(compatibilityMetadata)
+		stdout = &bytes.Buffer{}
+		command = commands.NewCheckCompatibility(fakeService, extractor.NewMetadataExtractor(), stdout)
+	})
+
+	It("reports every requirement as satisfied", func() {
+		err := executeCommand(command, []string{"--product-path", productPath})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(stdout.String()).To(MatchRegexp(`Ops Manager\s+\|\s+>= 3\.0\s+\|\s+3\.0\.12-build\.5\s+\|\s+3\.0\.12-build\.5\s+\|\s+ok`))
+		Expect(stdout.String()).To(MatchRegexp(`cf\s+\|\s+~> 2\.13\s+\|\s+2\.13\.5-build\.3\s+\|\s+2\.13\.1\s+\|\s+ok`))
+		Expect(stdout.String()).To(MatchRegexp(`p-isolation-segment\s+\|\s+>= 2\.13\.0\s+\|\s+2\.13\.2\s+\|\s+\|\s+ok`))
+	})
+
+	It("reports exactly which requirements block staging", func() {
+		fakeService.InfoReturns(api.Info{Version: "2.10.70"}, nil)
+		fakeService.ListStagedProductsReturns(api.StagedProductsOutput{Products: []api.StagedProduct{
+			{Type: "cf", ProductVersion: "3.0.1"},
+		}}, nil)
+
+		err := executeCommand(command, []string{"--product-path", productPath})
+		Expect(err).To(MatchError(`some-product 1.2.3 cannot be staged:
+- requires Ops Manager 3.0 or newer, but the Ops Manager is 2.10.70
+- requires cf ~> 2.13, but cf 3.0.1 is staged
+- requires p-isolation-segment >= 2.13.0, which is not staged`))
+
+		Expect(stdout.String()).To(MatchRegexp(`cf\s+\|\s+~> 2\.13\s+\|\s+3\.0\.1\s+\|\s+2\.13\.1\s+\|\s+blocks staging`))
+	})
+
+	It("errors on an invalid version constraint", func() {
+		productPath = createTileWithMetadata(`{name: some-product, product_version: 1.2.3, requires_product_versions: [{name: cf, version: "not a constraint"}]}`)
+
+		err := executeCommand(command, []string{"--product-path", productPath})
+		Expect(err).To(MatchError(ContainSubstring(`could not check the dependency on cf not a constraint: invalid version constraint "not a constraint"`)))
+	})
+
+	It("errors when the Ops Manager cannot be queried", func() {
+		fakeService.ListStagedProductsReturns(api.StagedProductsOutput{}, errors.New("some error"))
+
+		err := executeCommand(command, []string{"--product-path", productPath})
+		Expect(err).To(MatchError("failed to list staged products: some error"))
+	})
+
+	It("errors when the product cannot be inspected", func() {
+		err := executeCommand(command, []string{"--product-path", "/does/not/exist.pivotal"})
+		Expect(err).To(MatchError(ContainSubstring("failed to inspect product /does/not/exist.pivotal")))
+	})
+})
@@ -0,0 +1,96 @@
+package commands
+
+import (
+	"errors"
+)
+
+//counterfeiter:generate -o ./fakes/env_contexts.go --fake-name EnvContexts . envContexts
+type envContexts interface {
+	List() (names []string, current string, err error)
+	Use(name string) error
+	Show(name string) ([]byte, error)
+}
+
+type Context struct {
+	List ContextList `command:"list" description:"List the contexts of the env file, marking the current context"`
+	Use  ContextUse  `command:"use"  description:"Set the current context of the env file"`
+	Show ContextShow `command:"show" description:"Print the settings of a context, without its inline secrets"`
+}
+
+func NewContext(contexts envContexts, logger logger) *Context {
+	return &Context{
+		List: ContextList{contexts: contexts, logger: logger},
+		Use:  ContextUse{contexts: contexts, logger: logger},
+		Show: ContextShow{contexts: contexts, logger: logger},
+	}
+}
+
+func (*Context) Execute(args []string) error {
+	return nil
+}
+
+type ContextList struct {
+	contexts envContexts
+	logger   logger
+}
+
+func (c ContextList) Execute(_ []string) error {
+	names, current, err := c.contexts.List()
+	if err != nil {
+		return err
+	}
+
+	if len(names) == 0 {
+		return errors.New("the env file has no contexts")
+	}
+
+	for _, name := range names {
+		if name == current {
+			c.logger.Printf("* %s", name)
+		} else {
+			c.logger.Printf("  %s", name)
+		}
+	}
+
+	return nil
+}
+
+type ContextUse struct {
+	contexts envContexts
+	logger   logger
+	Options  struct {
+		Args struct {
+			Name string `positional-arg-name:"NAME" description:"name of the context"`
+		} `positional-args:"yes" required:"yes"`
+	}
+}
+
+func (c ContextUse) Execute(_ []string) error {
+	err := c.contexts.Use(c.Options.Args.Name)
+	if err != nil {
+		return err
+	}
+
+	c.logger.Printf("switched to context %q", c.Options.Args.Name)
+	return nil
+}
+
+type ContextShow struct {
+	contexts envContexts
+	logger   logger
+	Options  struct {
+		Args struct {
+			Name string `positional-arg-name:"NAME" description:"name of the context (default: the current context)"`
+		} `positional-args:"yes"`
+	}
+}
+
+func (c ContextShow) Execute(_ []string) error {
+	contents, err := c.contexts.Show(c.Options.Args.Name)
+	if err != nil {
+		return err
+	}
+
+	c.logger.Print(string(contents))
+	return nil
+}
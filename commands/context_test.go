@@ -0,0 +1,100 @@
+package commands_test
+
+import (
+	"errors"
+
+	"github.com/pivotal-cf/om/commands"
+	"github.com/pivotal-cf/om/commands/fakes"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Context", func() {
+	var (
+		contexts *fakes.EnvContexts
+		logger   *fakes.Logger
+		command  *commands.Context
+	)
+
+	BeforeEach(func() {
+		contexts = &fakes.EnvContexts{}
+		logger = &fakes.Logger{}
+		command = commands.NewContext(contexts, logger)
+	})
+
+	Describe("list", func() {
+		It("lists the contexts, marking the current context", func() {
+			contexts.ListReturns([]string{"dev", "prod"}, "prod", nil)
+
+			err := executeCommand(&command.List, []string{})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(logger.PrintfCallCount()).To(Equal(2))
+			format, args := logger.PrintfArgsForCall(0)
+			Expect(format).To(Equal("  %s"))
+			Expect(args).To(Equal([]interface{}{"dev"}))
+			format, args = logger.PrintfArgsForCall(1)
+			Expect(format).To(Equal("* %s"))
+			Expect(args).To(Equal([]interface{}{"prod"}))
+		})
+
+		When("the env file has no contexts", func() {
+			It("returns an error", func() {
+				err := executeCommand(&command.List, []string{})
+				Expect(err).To(MatchError("the env file has no contexts"))
+			})
+		})
+
+		When("the contexts cannot be read", func() {
+			It("returns the error", func() {
+				contexts.ListReturns(nil, "", errors.New("could not parse env file"))
+
+				err := executeCommand(&command.List, []string{})
+				Expect(err).To(MatchError("could not parse env file"))
+			})
+		})
+	})
+
+	Describe("use", func() {
+		It("sets the current context", func() {
+			err := executeCommand(&command.Use, []string{"prod"})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(contexts.UseCallCount()).To(Equal(1))
+			Expect(contexts.UseArgsForCall(0)).To(Equal("prod"))
+
+			format, args := logger.PrintfArgsForCall(0)
+			Expect(format).To(Equal("switched to context %q"))
+			Expect(args).To(Equal([]interface{}{"prod"}))
+		})
+
+		When("the context does not exist", func() {
+			It("returns the error", func() {
+				contexts.UseReturns(errors.New(`the env file has no context "staging"`))
+
+				err := executeCommand(&command.Use, []string{"staging"})
+				Expect(err).To(MatchError(`the env file has no context "staging"`))
+			})
+		})
+	})
+
+	Describe("show", func() {
+		It("prints the settings of the context", func() {
+			contexts.ShowReturns([]byte("target: https://opsman.example.com\npassword: '[REDACTED]'\n"), nil)
+
+			err := executeCommand(&command.Show, []string{"prod"})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(contexts.ShowArgsForCall(0)).To(Equal("prod"))
+			Expect(logger.PrintArgsForCall(0)).To(Equal([]interface{}{"target: https://opsman.example.com\npassword: '[REDACTED]'\n"}))
+		})
+
+		It("shows the current context when no name is provided", func() {
+			err := executeCommand(&command.Show, []string{})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(contexts.ShowArgsForCall(0)).To(Equal(""))
+		})
+	})
+})
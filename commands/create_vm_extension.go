@@ -22,7 +22,7 @@ type CreateVMExtension struct {
 	Options     struct {
 		Name string `long:"name"               short:"n"   description:"VM extension name"`
 
-		interpolateConfigFileOptions
+		interpolateOptions
 		OpsFile         []string `long:"ops-file"           short:"o"   description:"YAML operations file"`
 		CloudProperties string   `long:"cloud-properties"               description:"cloud properties in JSON format"`
 	}
@@ -16,18 +16,20 @@ import (
 )
 
 type PivnetOptions struct {
-	PivnetProductSlug   string `long:"pivnet-product-slug"   short:"p"                          description:"path to product" required:"true" json:"pivnet_product_slug,omitempty"`
-	PivnetDisableSSL    bool   `long:"pivnet-disable-ssl"                                       description:"whether to disable ssl validation when contacting the Pivotal Network" json:"pivnet_disable_ssl,omitempty"`
-	PivnetToken         string `long:"pivnet-api-token"      short:"t"                          description:"API token to use when interacting with Pivnet. Can be retrieved from your profile page in Pivnet." json:"pivnet_token,omitempty"`
-	PivnetHost          string `long:"pivnet-host" description:"the API endpoint for Pivotal Network" default:"https://network.pivotal.io" json:"pivnet_host,omitempty"`
-	FileGlob            string `long:"file-glob"             short:"f"  description:"glob to match files within Pivotal Network product to be downloaded." json:"file_glob,omitempty"`
-	ProductVersion      string `long:"product-version"                                          description:"version of the product-slug to download files from. Incompatible with --product-version-regex flag." json:"product_version,omitempty"`
-	ProductVersionRegex string `long:"product-version-regex" short:"r"                          description:"regex pattern matching versions of the product-slug to download files from. Highest-versioned match will be used. Incompatible with --product-version flag." json:"product_version_regex,omitempty"`
-	ProxyURL            string `long:"proxy-url"                                                description:"HTTP/HTTPS proxy server URL to use when connecting to Pivnet" json:"proxy_url,omitempty"`
-	ProxyUsername       string `long:"proxy-username"                                           description:"username for proxy authentication" json:"proxy_username,omitempty"`
-	ProxyPassword       string `long:"proxy-password"                                           description:"password for proxy authentication" json:"proxy_password,omitempty"`
-	ProxyAuthType       string `long:"proxy-auth-type"                                          description:"type of proxy authentication (basic, spnego)" json:"proxy_auth_type,omitempty"`
-	ProxyKrb5Config     string `long:"proxy-krb5-config"                                        description:"path to Kerberos config file (krb5.conf) for SPNEGO authentication" json:"proxy_krb_5_config,omitempty"`
+	PivnetProductSlug        string `long:"pivnet-product-slug"   short:"p"                          description:"path to product" required:"true" json:"pivnet_product_slug,omitempty"`
+	PivnetDisableSSL         bool   `long:"pivnet-disable-ssl"                                       description:"whether to disable ssl validation when contacting the Pivotal Network" json:"pivnet_disable_ssl,omitempty"`
+	PivnetToken              string `long:"pivnet-api-token"      short:"t"                          description:"API token to use when interacting with Pivnet. Can be retrieved from your profile page in Pivnet." json:"pivnet_token,omitempty"`
+	PivnetHost               string `long:"pivnet-host" description:"the API endpoint for Pivotal Network" default:"https://network.pivotal.io" json:"pivnet_host,omitempty"`
+	FileGlob                 string `long:"file-glob"             short:"f"  description:"glob to match files within Pivotal Network product to be downloaded." json:"file_glob,omitempty"`
+	ProductVersion           string `long:"product-version"                                          description:"version of the product-slug to download files from. Incompatible with --product-version-regex flag." json:"product_version,omitempty"`
+	ProductVersionRegex      string `long:"product-version-regex" short:"r"                          description:"regex pattern matching versions of the product-slug to download files from. Highest-versioned match will be used. Incompatible with --product-version flag." json:"product_version_regex,omitempty"`
+	ProductVersionConstraint string `long:"product-version-constraint"                               description:"semantic version constraint, such as '~> 2.10.0' or '>= 4.0, < 5', the versions of the product-slug to download files from must satisfy. Highest-versioned match will be used. Constraints without a pre-release never match pre-release versions. Incompatible with --product-version and --product-version-regex flags." json:"product_version_constraint,omitempty"`
+	ExcludePrerelease        bool   `long:"exclude-prerelease"                                       description:"ignore versions with a pre-release or build metadata segment, such as 2.10.0-rc.1 or 2.10.0+build.5, when using --product-version-regex or --product-version-constraint" json:"exclude_prerelease,omitempty"`
+	ProxyURL                 string `long:"proxy-url"                                                description:"HTTP/HTTPS proxy server URL to use when connecting to Pivnet" json:"proxy_url,omitempty"`
+	ProxyUsername            string `long:"proxy-username"                                           description:"username for proxy authentication" json:"proxy_username,omitempty"`
+	ProxyPassword            string `long:"proxy-password"                                           description:"password for proxy authentication" json:"proxy_password,omitempty"`
+	ProxyAuthType            string `long:"proxy-auth-type"                                          description:"type of proxy authentication (basic, spnego)" json:"proxy_auth_type,omitempty"`
+	ProxyKrb5Config          string `long:"proxy-krb5-config"                                        description:"path to Kerberos config file (krb5.conf) for SPNEGO authentication" json:"proxy_krb_5_config,omitempty"`
 
 	PivnetFileGlobSupport string `long:"pivnet-file-glob" hidden:"true" json:"pivnet_file_glob_support,omitempty"`
 }
@@ -55,6 +57,28 @@ type AzureOptions struct {
 	AzureKey            string `long:"azure-storage-key"     description:"the access key for the storage account"`
 }
 
+type LocalOptions struct {
+	LocalDirectory string `long:"local-directory" description:"the directory where the product and stemcell artifacts reside when the source is local"`
+}
+
+type HTTPOptions struct {
+	HTTPURL        string `long:"http-url"         description:"the base URL the product and stemcell artifacts are served from when the source is http"`
+	HTTPIndex      string `long:"http-index"       description:"path, relative to http-url, of a file listing one artifact path per line. If not provided, the product and stemcell paths are browsed as directory listings"`
+	HTTPUsername   string `long:"http-username"    description:"username for basic authentication with the http source"`
+	HTTPPassword   string `long:"http-password"    description:"password for basic authentication with the http source"`
+	HTTPToken      string `long:"http-token"       description:"token for bearer authentication with the http source. Incompatible with --http-username"`
+	HTTPDisableSSL bool   `long:"http-disable-ssl" description:"whether to disable ssl validation when contacting the http source"`
+}
+
+type OCIOptions struct {
+	OCIRegistry     string `long:"oci-registry"      description:"the host, and optionally port, of the OCI registry holding the product and stemcell artifacts when the source is oci"`
+	OCIUsername     string `long:"oci-username"      description:"username for the oci registry. If not provided, credentials are read from the docker config"`
+	OCIPassword     string `long:"oci-password"      description:"password for the oci registry"`
+	OCIDockerConfig string `long:"oci-docker-config" description:"path to the docker config.json holding the oci registry credentials. Defaults to $DOCKER_CONFIG/config.json or ~/.docker/config.json"`
+	OCIInsecure     bool   `long:"oci-insecure"      description:"whether to contact the oci registry over plain http"`
+	OCIDisableSSL   bool   `long:"oci-disable-ssl"   description:"whether to disable ssl validation when contacting the oci registry"`
+}
+
 type StemcellOptions struct {
 	StemcellIaas    string `long:"stemcell-iaas"     description:"download the latest available stemcell for the product for the specified iaas. for example 'vsphere' or 'vcloud' or 'openstack' or 'google' or 'azure' or 'aws'. Can contain globbing patterns to match specific files in a stemcell release on Pivnet"`
 	StemcellVersion string `long:"stemcell-version" description:"the version number of the stemcell to download (ie 458.61)"`
@@ -63,15 +87,20 @@ type StemcellOptions struct {
 }
 
 type DownloadProductOptions struct {
-	Source            string `long:"source"                     short:"s" description:"enables download from external sources when set to [s3|gcs|azure|pivnet]" default:"pivnet"`
+	Source            string `long:"source"                     short:"s" description:"enables download from external sources when set to [s3|gcs|azure|local|http|oci|pivnet]" default:"pivnet"`
 	OutputDir         string `long:"output-directory"           short:"o" description:"directory path to which the file will be outputted. File Name will be preserved from Pivotal Network" required:"true"`
 	StemcellOutputDir string `long:"stemcell-output-directory" short:"d" description:"directory path to which the stemcell file will be outputted. If not provided, output-directory will be used."`
 
 	Bucket               string `long:"blobstore-bucket" description:"bucket name where the product resides in the s3|gcs|azure compatible blobstore"`
-	ProductPath          string `long:"blobstore-product-path"   description:"specify the lookup path where the s3|gcs|azure product artifacts are stored"`
-	StemcellPath         string `long:"blobstore-stemcell-path" description:"specify the lookup path where the s3|gcs|azure stemcell artifacts are stored"`
+	ProductPath          string `long:"blobstore-product-path"   description:"specify the lookup path where the s3|gcs|azure|local|http product artifacts are stored, or the namespace of the oci repositories named after the product slugs"`
+	StemcellPath         string `long:"blobstore-stemcell-path" description:"specify the lookup path where the s3|gcs|azure|local|http stemcell artifacts are stored, or the namespace of the oci repositories named after the stemcell slugs"`
 	CacheCleanup         string `long:"cache-cleanup" env:"CACHE_CLEANUP" description:"Delete everything except the latest artifact in output-dir and stemcell-output-dir, set to 'I acknowledge this will delete files in the output directories' to accept these terms"`
 	CheckAlreadyUploaded bool   `long:"check-already-uploaded" description:"Check if product is already uploaded on Ops Manager before downloading. This command is authenticated."`
+	DownloadChunks       int    `long:"download-chunks" description:"download files with this many parallel ranged requests. Progress is kept next to the partially downloaded file, so rerunning an interrupted download resumes it. Supported by the pivnet, http, oci, s3 and azure sources, other sources download sequentially"`
+	Retention            string `long:"retention" description:"delete the least recently used artifacts download-product put in the output directories that the policy does not keep. Comma separated rules: versions=N keeps the N latest used versions of each slug, newer-than=DURATION (such as 72h or 30d) keeps artifacts used within the duration, max-size=GB evicts artifacts until the rest fit. Incompatible with --cache-cleanup"`
+	RetentionDryRun      bool   `long:"retention-dry-run" description:"list the files the --retention policy would delete without deleting them"`
+	RequireChecksum      bool   `long:"require-checksum" description:"fail when no SHA256 checksum is available to verify a downloaded file. The s3, gcs and azure sources read it from a .sha256 file next to the artifact or from its sha256 metadata"`
+	AllStemcells         bool   `long:"all-stemcells" description:"download a stemcell for the specified iaas for each of the stemcell_criteria and additional_stemcells_criteria of the product, such as Windows and Ubuntu stemcells, and write an assign-multi-stemcell.yml assigning them all. Requires --stemcell-iaas"`
 
 	S3BucketSupport          string `long:"s3-bucket" hidden:"true"`
 	GCSBucketSupport         string `long:"gcs-bucket" hidden:"true"`
@@ -85,7 +114,10 @@ type DownloadProductOptions struct {
 
 	AzureOptions
 	GCSOptions
+	HTTPOptions
 	InterpolateOptions interpolateConfigFileOptions `group:"config file interpolation"`
+	LocalOptions
+	OCIOptions
 	PivnetOptions
 	S3Options
 	StemcellOptions
@@ -98,6 +130,7 @@ type DownloadProduct struct {
 	stdout         *log.Logger
 	service        downloadProductService
 	downloadClient download_clients.ProductDownloader
+	lockPath       func(path string) (unlock func())
 	Options        DownloadProductOptions
 }
 
@@ -128,11 +161,62 @@ func (c *DownloadProduct) Execute(args []string) error {
 		return err
 	}
 
-	productVersion, err := c.determineProductVersion()
+	downloaded, err := c.download()
 	if err != nil {
 		return err
 	}
 
+	err = c.writeDownloadProductOutput(downloaded)
+	if err != nil {
+		return err
+	}
+
+	if len(downloaded.stemcells) > 0 {
+		err = c.writeAssignMultiStemcellInput(downloaded.productName, downloaded.stemcells)
+		if err != nil {
+			return err
+		}
+	} else if downloaded.stemcellVersion != "" {
+		err = c.writeAssignStemcellInput(downloaded.productPath, downloaded.productFileArtifact, downloaded.stemcellVersion)
+		if err != nil {
+			return err
+		}
+	}
+
+	if c.Options.Retention == "" {
+		return nil
+	}
+
+	return c.applyRetention(downloaded)
+}
+
+type downloadedProduct struct {
+	productPath         string
+	productVersion      string
+	productFileArtifact download_clients.FileArtifacter
+	stemcellPath        string
+	stemcellVersion     string
+
+	// productName and stemcells are only set with --all-stemcells,
+	// the first stemcell being the one in stemcellPath and stemcellVersion.
+	productName string
+	stemcells   []downloadedStemcell
+}
+
+type downloadedStemcell struct {
+	OS              string `json:"os"`
+	StemcellPath    string `json:"stemcell_path"`
+	StemcellVersion string `json:"stemcell_version"`
+}
+
+// download fetches the product, and its stemcell when requested, with the
+// already validated options and client.
+func (c *DownloadProduct) download() (downloadedProduct, error) {
+	productVersion, err := c.determineProductVersion()
+	if err != nil {
+		return downloadedProduct{}, err
+	}
+
 	productFileName, productFileArtifact, err := c.downloadProductFile(
 		c.Options.PivnetProductSlug,
 		productVersion,
@@ -141,29 +225,90 @@ func (c *DownloadProduct) Execute(args []string) error {
 		c.Options.OutputDir,
 	)
 	if err != nil {
-		return fmt.Errorf("could not download product: %s", err)
+		return downloadedProduct{}, fmt.Errorf("could not download product: %s", err)
+	}
+
+	downloaded := downloadedProduct{
+		productPath:         productFileName,
+		productVersion:      productVersion,
+		productFileArtifact: productFileArtifact,
 	}
 
 	if c.Options.StemcellIaas == "" {
-		return c.writeDownloadProductOutput(productFileName, productVersion, "", "")
+		return downloaded, nil
 	}
 
 	if filepath.Ext(productFileName) != ".pivotal" {
 		c.stderr.Printf("the downloaded file is not a .pivotal file. Not determining and fetching required stemcell.")
-		return c.writeDownloadProductOutput(productFileName, productVersion, "", "")
+		return downloaded, nil
 	}
 
-	stemcellVersion, stemcellFileName, err := c.downloadStemcell(productFileName, productVersion, productFileArtifact, c.Options.StemcellSlug)
+	if c.Options.AllStemcells {
+		downloaded.productName, downloaded.stemcells, err = c.downloadAllStemcells(productFileName, productVersion, productFileArtifact)
+		if err != nil {
+			return downloadedProduct{}, err
+		}
+
+		downloaded.stemcellPath = downloaded.stemcells[0].StemcellPath
+		downloaded.stemcellVersion = downloaded.stemcells[0].StemcellVersion
+		return downloaded, nil
+	}
+
+	downloaded.stemcellVersion, downloaded.stemcellPath, err = c.downloadStemcell(productFileName, productVersion, productFileArtifact, c.Options.StemcellSlug)
 	if err != nil {
-		return err
+		return downloadedProduct{}, err
 	}
 
-	err = c.writeDownloadProductOutput(productFileName, productVersion, stemcellFileName, stemcellVersion)
+	return downloaded, nil
+}
+
+// downloadAllStemcells downloads a stemcell for each of the stemcell criteria
+// of the product, for tiles running on several operating systems.
+func (c *DownloadProduct) downloadAllStemcells(productFileName string, productVersion string, productFileArtifact download_clients.FileArtifacter) (string, []downloadedStemcell, error) {
+	var (
+		metadata *extractor.Metadata
+		err      error
+	)
+	if c.Options.CheckAlreadyUploaded {
+		metadata, err = productFileArtifact.ProductMetadata()
+	} else {
+		metadata, err = extractor.NewMetadataExtractor().ExtractFromFile(productFileName)
+	}
 	if err != nil {
-		return err
+		return "", nil, fmt.Errorf("could not determine the stemcell criteria of the product: %s", err)
 	}
 
-	return c.writeAssignStemcellInput(productFileName, productFileArtifact, stemcellVersion)
+	criteria := metadata.AllStemcellCriteria()
+	if len(criteria) == 0 {
+		return "", nil, fmt.Errorf("the product %s does not define any stemcell criteria", metadata.Name)
+	}
+
+	var stemcells []downloadedStemcell
+	downloadedSlugs := map[string]bool{}
+	for _, criterion := range criteria {
+		slug := download_clients.StemcellSlugForOS(criterion.OS)
+		if slug == "" {
+			return "", nil, fmt.Errorf("could not determine the stemcell slug for the %q stemcell criteria of the product", criterion.OS)
+		}
+
+		if downloadedSlugs[slug] {
+			continue
+		}
+		downloadedSlugs[slug] = true
+
+		stemcellVersion, stemcellPath, err := c.downloadStemcell(productFileName, productVersion, productFileArtifact, slug)
+		if err != nil {
+			return "", nil, err
+		}
+
+		stemcells = append(stemcells, downloadedStemcell{
+			OS:              criterion.OS,
+			StemcellPath:    stemcellPath,
+			StemcellVersion: stemcellVersion,
+		})
+	}
+
+	return metadata.Name, stemcells, nil
 }
 
 func (c *DownloadProduct) downloadStemcell(productFileName string, productVersion string, productFileArtifact download_clients.FileArtifacter, stemCellSlug string) (string, string, error) {
@@ -174,16 +319,7 @@ func (c *DownloadProduct) downloadStemcell(productFileName string, productVersio
 		return "", "", fmt.Errorf("could not get information about stemcell: %s", err)
 	}
 
-	stemcellGlobs := []string{
-		fmt.Sprintf("light*bosh*%s*", c.Options.StemcellIaas),
-		fmt.Sprintf("bosh*%s*", c.Options.StemcellIaas),
-	}
-
-	if c.Options.StemcellHeavy {
-		stemcellGlobs = []string{
-			fmt.Sprintf("bosh*%s*", c.Options.StemcellIaas),
-		}
-	}
+	stemcellGlobs := c.stemcellGlobs()
 
 	stemcellVersion := stemcell.Version()
 	if c.Options.StemcellVersion != "" {
@@ -214,20 +350,41 @@ func (c *DownloadProduct) downloadStemcell(productFileName string, productVersio
 	}
 
 	if err != nil {
-		isHeavy := ""
-		if c.Options.StemcellHeavy {
-			isHeavy = "heavy "
-		}
-		return "", "", fmt.Errorf("could not download stemcell: %s\nNo %sstemcell identified for IaaS \"%s\" on Pivotal Network. Correct the `stemcell-iaas` option to match the IaaS portion of the stemcell filename, or remove the option.", err, isHeavy, c.Options.StemcellIaas)
+		return "", "", c.stemcellNotFoundError("could not download stemcell", err)
 	}
 	return stemcellVersion, stemcellFileName, nil
 }
 
+// stemcellGlobs returns the globs matching the stemcell for the IaaS, light
+// stemcells being preferred unless a heavy stemcell is requested.
+func (c *DownloadProduct) stemcellGlobs() []string {
+	if c.Options.StemcellHeavy {
+		return []string{
+			fmt.Sprintf("bosh*%s*", c.Options.StemcellIaas),
+		}
+	}
+
+	return []string{
+		fmt.Sprintf("light*bosh*%s*", c.Options.StemcellIaas),
+		fmt.Sprintf("bosh*%s*", c.Options.StemcellIaas),
+	}
+}
+
+func (c *DownloadProduct) stemcellNotFoundError(message string, err error) error {
+	isHeavy := ""
+	if c.Options.StemcellHeavy {
+		isHeavy = "heavy "
+	}
+	return fmt.Errorf("%s: %s\nNo %sstemcell identified for IaaS \"%s\" on Pivotal Network. Correct the `stemcell-iaas` option to match the IaaS portion of the stemcell filename, or remove the option.", message, err, isHeavy, c.Options.StemcellIaas)
+}
+
 func (c *DownloadProduct) determineProductVersion() (string, error) {
 	return download_clients.DetermineProductVersion(
 		c.Options.PivnetProductSlug,
 		c.Options.ProductVersion,
 		c.Options.ProductVersionRegex,
+		c.Options.ProductVersionConstraint,
+		c.Options.ExcludePrerelease,
 		c.downloadClient,
 		c.stderr,
 	)
@@ -254,8 +411,12 @@ func (c *DownloadProduct) validate() error {
 		return errors.New("cannot use both --product-version and --product-version-regex; please choose one or the other")
 	}
 
-	if c.Options.ProductVersionRegex == "" && c.Options.ProductVersion == "" {
-		return errors.New("no version information provided; please provide either --product-version or --product-version-regex")
+	if c.Options.ProductVersionConstraint != "" && (c.Options.ProductVersion != "" || c.Options.ProductVersionRegex != "") {
+		return errors.New("cannot use --product-version-constraint with --product-version or --product-version-regex; please choose one")
+	}
+
+	if c.Options.ProductVersionRegex == "" && c.Options.ProductVersion == "" && c.Options.ProductVersionConstraint == "" {
+		return errors.New("no version information provided; please provide either --product-version or --product-version-regex or --product-version-constraint")
 	}
 
 	if c.Options.PivnetToken == "" && c.Options.Source == "pivnet" {
@@ -268,6 +429,28 @@ func (c *DownloadProduct) validate() error {
 	if c.Options.StemcellVersion != "" && c.Options.StemcellIaas == "" {
 		return errors.New("--stemcell-version requires --stemcell-iaas to be defined")
 	}
+	if c.Options.AllStemcells && c.Options.StemcellIaas == "" {
+		return errors.New("--all-stemcells requires --stemcell-iaas to be defined")
+	}
+	if c.Options.AllStemcells && (c.Options.StemcellSlug != "" || c.Options.StemcellVersion != "") {
+		return errors.New("cannot use --all-stemcells with --stemcell-slug or --stemcell-version; please choose one")
+	}
+	if c.Options.DownloadChunks < 0 {
+		return errors.New("--download-chunks must be a positive number")
+	}
+	if c.Options.Retention != "" {
+		if c.Options.CacheCleanup != "" {
+			return errors.New("cannot use both --retention and --cache-cleanup; please choose one or the other")
+		}
+
+		_, err := parseRetentionPolicy(c.Options.Retention)
+		if err != nil {
+			return err
+		}
+	}
+	if c.Options.RetentionDryRun && c.Options.Retention == "" {
+		return errors.New("--retention-dry-run requires --retention to be defined")
+	}
 
 	file, err := os.Open(c.Options.OutputDir)
 	if err != nil {
@@ -332,21 +515,23 @@ func (c *DownloadProduct) handleAliases() {
 	}
 }
 
-func (c DownloadProduct) writeDownloadProductOutput(productFileName string, productVersion string, stemcellFileName string, stemcellVersion string) error {
+func (c DownloadProduct) writeDownloadProductOutput(downloaded downloadedProduct) error {
 	downloadProductFilename := "download-file.json"
 	c.stderr.Printf("Writing a list of downloaded artifact to %s", downloadProductFilename)
 	downloadProductPayload := struct {
-		ProductPath     string `json:"product_path,omitempty"`
-		ProductSlug     string `json:"product_slug,omitempty"`
-		ProductVersion  string `json:"product_version,omitempty"`
-		StemcellPath    string `json:"stemcell_path,omitempty"`
-		StemcellVersion string `json:"stemcell_version,omitempty"`
+		ProductPath     string               `json:"product_path,omitempty"`
+		ProductSlug     string               `json:"product_slug,omitempty"`
+		ProductVersion  string               `json:"product_version,omitempty"`
+		StemcellPath    string               `json:"stemcell_path,omitempty"`
+		StemcellVersion string               `json:"stemcell_version,omitempty"`
+		Stemcells       []downloadedStemcell `json:"stemcells,omitempty"`
 	}{
-		ProductPath:     productFileName,
-		StemcellPath:    stemcellFileName,
+		ProductPath:     downloaded.productPath,
+		StemcellPath:    downloaded.stemcellPath,
 		ProductSlug:     c.Options.PivnetProductSlug,
-		ProductVersion:  productVersion,
-		StemcellVersion: stemcellVersion,
+		ProductVersion:  downloaded.productVersion,
+		StemcellVersion: downloaded.stemcellVersion,
+		Stemcells:       downloaded.stemcells,
 	}
 
 	outputFile, err := os.Create(filepath.Join(c.Options.OutputDir, downloadProductFilename))
@@ -412,12 +597,47 @@ func (c DownloadProduct) writeAssignStemcellInput(productFile string, fileArtifa
 	return nil
 }
 
+// writeAssignMultiStemcellInput writes the config file of assign-multi-stemcell,
+// assigning every stemcell downloaded with --all-stemcells to the product.
+func (c DownloadProduct) writeAssignMultiStemcellInput(productName string, stemcells []downloadedStemcell) error {
+	assignMultiStemcellFileName := "assign-multi-stemcell.yml"
+
+	c.stderr.Printf("Writing a assign multi stemcell artifact to %s", assignMultiStemcellFileName)
+
+	assignMultiStemcellPayload := struct {
+		Product  string   `json:"product"`
+		Stemcell []string `json:"stemcell"`
+	}{
+		Product: productName,
+	}
+	for _, stemcell := range stemcells {
+		assignMultiStemcellPayload.Stemcell = append(assignMultiStemcellPayload.Stemcell, fmt.Sprintf("%s:%s", stemcell.OS, stemcell.StemcellVersion))
+	}
+
+	outputFile, err := os.Create(filepath.Join(c.Options.OutputDir, assignMultiStemcellFileName))
+	if err != nil {
+		return fmt.Errorf("could not create %s: %s", assignMultiStemcellFileName, err)
+	}
+	defer outputFile.Close()
+
+	err = json.NewEncoder(outputFile).Encode(assignMultiStemcellPayload)
+	if err != nil {
+		return fmt.Errorf("could not encode JSON for %s: %s", assignMultiStemcellFileName, err)
+	}
+
+	return nil
+}
+
 func (c *DownloadProduct) downloadProductFile(slug, version, glob, prefixPath string, outputDir string) (string, download_clients.FileArtifacter, error) {
 	fileArtifact, err := c.downloadClient.GetLatestProductFile(slug, version, glob)
 	if err != nil {
 		return "", nil, err
 	}
 
+	return c.downloadFileArtifact(fileArtifact, slug, glob, prefixPath, outputDir)
+}
+
+func (c *DownloadProduct) downloadFileArtifact(fileArtifact download_clients.FileArtifacter, slug, glob, prefixPath string, outputDir string) (string, download_clients.FileArtifacter, error) {
 	var productFilePath string
 	if c.Options.Source != "pivnet" || c.Options.Bucket == "" {
 		productFilePath = filepath.Join(outputDir, filepath.Base(fileArtifact.Name()))
@@ -427,6 +647,10 @@ func (c *DownloadProduct) downloadProductFile(slug, version, glob, prefixPath st
 
 	c.stderr.Printf("attempting to download the file %s from source %s", fileArtifact.Name(), c.downloadClient.Name())
 
+	if c.lockPath != nil {
+		defer c.lockPath(productFilePath)()
+	}
+
 	// check for already downloaded file
 	exist, err := checkFileExists(productFilePath)
 	if err != nil {
@@ -487,15 +711,30 @@ func (c *DownloadProduct) downloadProductFile(slug, version, glob, prefixPath st
 		return "", nil, fmt.Errorf("could not cleanup cache: %w", err)
 	}
 
+	if c.Options.RequireChecksum && fileArtifact.SHA256() == "" {
+		return productFilePath, fileArtifact, fmt.Errorf("could not find a SHA256 checksum for %s from source %s, which --require-checksum requires", fileArtifact.Name(), c.downloadClient.Name())
+	}
+
 	partialProductFilePath := productFilePath + ".partial"
-	// create a new file to download
-	productFile, err := os.Create(partialProductFilePath)
+
+	var productFile *os.File
+	if c.Options.DownloadChunks > 0 {
+		// keep the partial file, the chunked download resumes from it
+		productFile, err = os.OpenFile(partialProductFilePath, os.O_RDWR|os.O_CREATE, 0666)
+	} else {
+		// create a new file to download
+		productFile, err = os.Create(partialProductFilePath)
+	}
 	if err != nil {
 		return "", nil, fmt.Errorf("could not create file %s: %s", productFilePath, err)
 	}
 	defer productFile.Close()
 
-	err = c.downloadClient.DownloadProductToFile(fileArtifact, productFile)
+	if c.Options.DownloadChunks > 0 {
+		err = download_clients.DownloadProductToFileInChunks(c.downloadClient, fileArtifact, productFile, c.Options.DownloadChunks, c.stderr)
+	} else {
+		err = c.downloadClient.DownloadProductToFile(fileArtifact, productFile)
+	}
 	if err != nil {
 		return productFilePath, fileArtifact, err
 	}
@@ -626,6 +865,43 @@ func newDownloadClientFromSource(c DownloadProductOptions,
 			},
 			stderr,
 		)
+	case "local":
+		return download_clients.NewLocalClient(
+			download_clients.LocalConfiguration{
+				Directory:    c.LocalDirectory,
+				ProductPath:  c.ProductPath,
+				StemcellPath: c.StemcellPath,
+			},
+			stderr,
+		)
+	case "http":
+		return download_clients.NewHTTPClient(
+			download_clients.HTTPConfiguration{
+				URL:          c.HTTPURL,
+				IndexPath:    c.HTTPIndex,
+				Username:     c.HTTPUsername,
+				Password:     c.HTTPPassword,
+				Token:        c.HTTPToken,
+				DisableSSL:   c.HTTPDisableSSL,
+				ProductPath:  c.ProductPath,
+				StemcellPath: c.StemcellPath,
+			},
+			stderr,
+		)
+	case "oci":
+		return download_clients.NewOCIClient(
+			download_clients.OCIConfiguration{
+				Registry:     c.OCIRegistry,
+				Username:     c.OCIUsername,
+				Password:     c.OCIPassword,
+				DockerConfig: c.OCIDockerConfig,
+				Insecure:     c.OCIInsecure,
+				DisableSSL:   c.OCIDisableSSL,
+				ProductPath:  c.ProductPath,
+				StemcellPath: c.StemcellPath,
+			},
+			stderr,
+		)
 	case "pivnet", "":
 		return download_clients.NewPivnetClient(
 			stdout,
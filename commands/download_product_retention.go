@@ -0,0 +1,360 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// retentionIndexFilename records the artifacts download-product has put in
+// an output directory, so that a retention policy knows the slug and version
+// of each file, the stemcell downloaded with it and when it was last used.
+const retentionIndexFilename = ".om-retention.json"
+
+type retentionPolicy struct {
+	versions  int
+	newerThan time.Duration
+	maxSize   int64
+}
+
+type retentionIndex struct {
+	Artifacts []retainedArtifact `json:"artifacts"`
+}
+
+type retainedArtifact struct {
+	Slug         string    `json:"slug"`
+	Version      string    `json:"version"`
+	ProductPath  string    `json:"product_path"`
+	StemcellPath string    `json:"stemcell_path,omitempty"`
+	LastUsed     time.Time `json:"last_used"`
+}
+
+// parseRetentionPolicy parses a comma separated list of rules, such as
+// "versions=3,newer-than=30d,max-size=50".
+func parseRetentionPolicy(value string) (retentionPolicy, error) {
+	var policy retentionPolicy
+
+	for _, rule := range strings.Split(value, ",") {
+		key, ruleValue, found := strings.Cut(strings.TrimSpace(rule), "=")
+		if !found {
+			return retentionPolicy{}, fmt.Errorf("invalid --retention rule %q: expected key=value", rule)
+		}
+
+		switch key {
+		case "versions":
+			versions, err := strconv.Atoi(ruleValue)
+			if err != nil || versions < 1 {
+				return retentionPolicy{}, fmt.Errorf("invalid --retention rule %q: versions must be a positive number", rule)
+			}
+			policy.versions = versions
+		case "newer-than":
+			newerThan, err := parseRetentionDuration(ruleValue)
+			if err != nil || newerThan <= 0 {
+				return retentionPolicy{}, fmt.Errorf("invalid --retention rule %q: newer-than must be a positive duration, such as 12h or 30d", rule)
+			}
+			policy.newerThan = newerThan
+		case "max-size":
+			gigabytes, err := strconv.ParseFloat(ruleValue, 64)
+			if err != nil || gigabytes <= 0 {
+				return retentionPolicy{}, fmt.Errorf("invalid --retention rule %q: max-size must be a positive number of GB", rule)
+			}
+			policy.maxSize = int64(gigabytes * 1024 * 1024 * 1024)
+		default:
+			return retentionPolicy{}, fmt.Errorf("invalid --retention rule %q: supported rules are versions, newer-than and max-size", rule)
+		}
+	}
+
+	return policy, nil
+}
+
+func parseRetentionDuration(value string) (time.Duration, error) {
+	if days, found := strings.CutSuffix(value, "d"); found {
+		count, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, err
+		}
+
+		return time.Duration(count) * 24 * time.Hour, nil
+	}
+
+	return time.ParseDuration(value)
+}
+
+// applyRetention records the downloaded product in the index of the output
+// directory and deletes the least recently used artifacts the policy does not
+// keep. A product and the stemcell downloaded with it are evicted together,
+// though a stemcell is kept while any remaining product still uses it. The
+// files of the current download, including download-file.json, are always kept.
+func (c *DownloadProduct) applyRetention(downloaded downloadedProduct) error {
+	policy, err := parseRetentionPolicy(c.Options.Retention)
+	if err != nil {
+		return err
+	}
+
+	outputDir := c.Options.OutputDir
+
+	index, err := loadRetentionIndex(outputDir)
+	if err != nil {
+		return err
+	}
+
+	current := retainedArtifact{
+		Slug:         c.Options.PivnetProductSlug,
+		Version:      downloaded.productVersion,
+		ProductPath:  relativeRetentionPath(outputDir, downloaded.productPath),
+		StemcellPath: relativeRetentionPath(outputDir, downloaded.stemcellPath),
+		LastUsed:     time.Now(),
+	}
+
+	artifacts := []retainedArtifact{current}
+	for _, artifact := range index.Artifacts {
+		if artifact.ProductPath == current.ProductPath || artifact.ProductPath == current.StemcellPath {
+			continue
+		}
+
+		_, err := os.Stat(resolveRetentionPath(outputDir, artifact.ProductPath))
+		if err != nil {
+			continue
+		}
+
+		artifacts = append(artifacts, artifact)
+	}
+
+	sort.SliceStable(artifacts[1:], func(i, j int) bool {
+		return artifacts[i+1].LastUsed.After(artifacts[j+1].LastUsed)
+	})
+
+	kept, evicted := policy.evict(artifacts, outputDir, current.LastUsed)
+
+	inUse := map[string]bool{}
+	for _, artifact := range kept {
+		inUse[artifact.ProductPath] = true
+		inUse[artifact.StemcellPath] = true
+	}
+
+	var deletions []string
+	for _, artifact := range evicted {
+		for _, path := range []string{artifact.ProductPath, artifact.StemcellPath} {
+			if path == "" || inUse[path] {
+				continue
+			}
+
+			inUse[path] = true
+			deletions = append(deletions, path)
+		}
+	}
+
+	for _, path := range deletions {
+		filePath := resolveRetentionPath(outputDir, path)
+
+		if c.Options.RetentionDryRun {
+			c.stderr.Printf("retention policy would delete %s", filePath)
+			continue
+		}
+
+		c.stderr.Printf("retention policy is deleting %s", filePath)
+		err = os.Remove(filePath)
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("could not delete %s: %w", filePath, err)
+		}
+	}
+
+	if c.Options.RetentionDryRun {
+		kept = artifacts
+	}
+
+	return saveRetentionIndex(outputDir, retentionIndex{Artifacts: kept})
+}
+
+// evict splits the artifacts, ordered from most to least recently used with
+// the current download first, into those the policy keeps and evicts.
+// An artifact is kept when any of the versions and newer-than rules keeps it.
+// Then the least recently used artifacts are evicted until the kept files
+// fit in max-size.
+func (p retentionPolicy) evict(artifacts []retainedArtifact, outputDir string, now time.Time) ([]retainedArtifact, []retainedArtifact) {
+	var kept, evicted []retainedArtifact
+
+	versions := map[string]map[string]bool{}
+	for index, artifact := range artifacts {
+		if versions[artifact.Slug] == nil {
+			versions[artifact.Slug] = map[string]bool{}
+		}
+
+		keep := index == 0 || (p.versions == 0 && p.newerThan == 0)
+		if p.versions > 0 && (versions[artifact.Slug][artifact.Version] || len(versions[artifact.Slug]) < p.versions) {
+			keep = true
+		}
+		if p.newerThan > 0 && now.Sub(artifact.LastUsed) < p.newerThan {
+			keep = true
+		}
+
+		if keep {
+			versions[artifact.Slug][artifact.Version] = true
+			kept = append(kept, artifact)
+		} else {
+			evicted = append(evicted, artifact)
+		}
+	}
+
+	if p.maxSize == 0 {
+		return kept, evicted
+	}
+
+	for len(kept) > 1 && retainedSize(kept, outputDir) > p.maxSize {
+		evicted = append(evicted, kept[len(kept)-1])
+		kept = kept[:len(kept)-1]
+	}
+
+	return kept, evicted
+}
+
+// retainedSize adds up the size of the files of the artifacts, counting a
+// stemcell shared by several products once.
+func retainedSize(artifacts []retainedArtifact, outputDir string) int64 {
+	var size int64
+
+	counted := map[string]bool{}
+	for _, artifact := range artifacts {
+		for _, path := range []string{artifact.ProductPath, artifact.StemcellPath} {
+			if path == "" || counted[path] {
+				continue
+			}
+			counted[path] = true
+
+			info, err := os.Stat(resolveRetentionPath(outputDir, path))
+			if err == nil {
+				size += info.Size()
+			}
+		}
+	}
+
+	return size
+}
+
+func loadRetentionIndex(outputDir string) (retentionIndex, error) {
+	var index retentionIndex
+
+	contents, err := os.ReadFile(filepath.Join(outputDir, retentionIndexFilename))
+	if err != nil && !os.IsNotExist(err) {
+		return index, fmt.Errorf("could not read %s: %w", retentionIndexFilename, err)
+	}
+
+	if err == nil {
+		err = json.Unmarshal(contents, &index)
+		if err != nil {
+			return index, fmt.Errorf("could not parse %s: %w", retentionIndexFilename, err)
+		}
+	}
+
+	unindexed, err := unindexedArtifacts(outputDir, index)
+	if err != nil {
+		return index, err
+	}
+
+	index.Artifacts = append(index.Artifacts, unindexed...)
+
+	return index, nil
+}
+
+var retentionFilePrefix = regexp.MustCompile(`^\[([^,\]]+),([^\]]+)\]`)
+
+// unindexedArtifacts finds the artifacts download-product put in the output
+// directory without recording them in the index, such as those downloaded
+// before it had an index or without a retention policy, which it names with
+// a [slug,version] prefix. They were last used when they were last modified.
+// Stemcells cannot be told apart from products by their name, so each file is
+// an artifact of its own.
+func unindexedArtifacts(outputDir string, index retentionIndex) ([]retainedArtifact, error) {
+	indexed := map[string]bool{}
+	for _, artifact := range index.Artifacts {
+		indexed[artifact.ProductPath] = true
+		indexed[artifact.StemcellPath] = true
+	}
+
+	entries, err := os.ReadDir(outputDir)
+	if err != nil {
+		return nil, fmt.Errorf("could not read %s: %w", outputDir, err)
+	}
+
+	var artifacts []retainedArtifact
+	for _, entry := range entries {
+		matches := retentionFilePrefix.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || matches == nil || indexed[entry.Name()] {
+			continue
+		}
+
+		// downloads in progress are not artifacts yet
+		switch filepath.Ext(entry.Name()) {
+		case ".partial", ".progress", ".tmp":
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+
+		artifacts = append(artifacts, retainedArtifact{
+			Slug:        matches[1],
+			Version:     matches[2],
+			ProductPath: entry.Name(),
+			LastUsed:    info.ModTime(),
+		})
+	}
+
+	return artifacts, nil
+}
+
+func saveRetentionIndex(outputDir string, index retentionIndex) error {
+	contents, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(outputDir, retentionIndexFilename)
+	err = os.WriteFile(path+".tmp", contents, 0644)
+	if err != nil {
+		return fmt.Errorf("could not write %s: %w", retentionIndexFilename, err)
+	}
+
+	return os.Rename(path+".tmp", path)
+}
+
+// The paths in the index are relative to the output directory, so that it
+// stays valid when the directory is mounted elsewhere.
+func relativeRetentionPath(outputDir, path string) string {
+	if path == "" {
+		return ""
+	}
+
+	absoluteOutputDir, err := filepath.Abs(outputDir)
+	if err != nil {
+		return path
+	}
+
+	absolutePath, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+
+	relativePath, err := filepath.Rel(absoluteOutputDir, absolutePath)
+	if err != nil {
+		return absolutePath
+	}
+
+	return filepath.ToSlash(relativePath)
+}
+
+func resolveRetentionPath(outputDir, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+
+	return filepath.Join(outputDir, filepath.FromSlash(path))
+}
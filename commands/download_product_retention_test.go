@@ -0,0 +1,263 @@
+package commands_test
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/onsi/gomega/gbytes"
+
+	"github.com/pivotal-cf/om/commands"
+	cmdFakes "github.com/pivotal-cf/om/commands/fakes"
+	"github.com/pivotal-cf/om/download_clients"
+	"github.com/pivotal-cf/om/download_clients/fakes"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("DownloadProduct with a retention policy", func() {
+	var (
+		command               *commands.DownloadProduct
+		fakeProductDownloader *fakes.ProductDownloader
+		buffer                *gbytes.Buffer
+		outputDir             string
+	)
+
+	type artifact struct {
+		Slug         string    `json:"slug"`
+		Version      string    `json:"version"`
+		ProductPath  string    `json:"product_path"`
+		StemcellPath string    `json:"stemcell_path,omitempty"`
+		LastUsed     time.Time `json:"last_used"`
+	}
+
+	writeIndex := func(artifacts ...artifact) {
+		for _, a := range artifacts {
+			for _, path := range []string{a.ProductPath, a.StemcellPath} {
+				if path != "" {
+					Expect(os.WriteFile(filepath.Join(outputDir, path), []byte(strings.Repeat("x", 100)), 0644)).To(Succeed())
+				}
+			}
+		}
+
+		contents, err := json.Marshal(map[string]interface{}{"artifacts": artifacts})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(os.WriteFile(filepath.Join(outputDir, ".om-retention.json"), contents, 0644)).To(Succeed())
+	}
+
+	readIndex := func() []artifact {
+		contents, err := os.ReadFile(filepath.Join(outputDir, ".om-retention.json"))
+		Expect(err).ToNot(HaveOccurred())
+
+		var index struct {
+			Artifacts []artifact `json:"artifacts"`
+		}
+		Expect(json.Unmarshal(contents, &index)).To(Succeed())
+		return index.Artifacts
+	}
+
+	indexedProducts := func() []string {
+		var paths []string
+		for _, a := range readIndex() {
+			paths = append(paths, a.ProductPath)
+		}
+		return paths
+	}
+
+	download := func(args ...string) error {
+		return executeCommand(command, append([]string{
+			"--pivnet-api-token", "token",
+			"--file-glob", "*.pivotal",
+			"--pivnet-product-slug", "cf",
+			"--product-version", "2.0.0",
+			"--output-directory", outputDir,
+		}, args...))
+	}
+
+	BeforeEach(func() {
+		outputDir = GinkgoT().TempDir()
+
+		fakeProductDownloader = &fakes.ProductDownloader{}
+		fakeProductDownloader.NameReturns("pivnet")
+		fakeProductDownloader.GetAllProductVersionsReturns([]string{"2.0.0"}, nil)
+
+		fa := &fakes.FileArtifacter{}
+		fa.NameReturns("cf-2.0.0.pivotal")
+		fakeProductDownloader.GetLatestProductFileReturns(fa, nil)
+		fakeProductDownloader.DownloadProductToFileStub = func(_ download_clients.FileArtifacter, file *os.File) error {
+			_, err := file.WriteString(strings.Repeat("x", 100))
+			return err
+		}
+
+		download_clients.NewPivnetClient = func(stdout *log.Logger, stderr *log.Logger, factory download_clients.PivnetFactory, token string, skipSSL bool, pivnetHost string, proxyURL string, proxyUsername string, proxyPassword string, proxyAuthType string, proxyKrb5Config string) (download_clients.ProductDownloader, error) {
+			return fakeProductDownloader, nil
+		}
+
+		buffer = gbytes.NewBuffer()
+		command = commands.NewDownloadProduct(func() []string { return nil }, log.New(buffer, "", 0), log.New(buffer, "", 0), buffer, &cmdFakes.DownloadProductService{})
+	})
+
+	It("records the download and keeps the latest versions of each slug", func() {
+		now := time.Now()
+		writeIndex(
+			artifact{Slug: "cf", Version: "1.0.0", ProductPath: "cf-1.0.0.pivotal", StemcellPath: "stemcell-1.tgz", LastUsed: now.Add(-2 * time.Hour)},
+			artifact{Slug: "cf", Version: "1.1.0", ProductPath: "cf-1.1.0.pivotal", StemcellPath: "stemcell-2.tgz", LastUsed: now.Add(-time.Hour)},
+			artifact{Slug: "p-healthwatch", Version: "2.0.0", ProductPath: "healthwatch-2.0.0.pivotal", StemcellPath: "stemcell-1.tgz", LastUsed: now.Add(-3 * time.Hour)},
+		)
+
+		err := download("--retention", "versions=2")
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(filepath.Join(outputDir, "cf-2.0.0.pivotal")).To(BeAnExistingFile())
+		Expect(filepath.Join(outputDir, "cf-1.1.0.pivotal")).To(BeAnExistingFile())
+		Expect(filepath.Join(outputDir, "healthwatch-2.0.0.pivotal")).To(BeAnExistingFile())
+		Expect(filepath.Join(outputDir, "cf-1.0.0.pivotal")).ToNot(BeAnExistingFile())
+		Expect(filepath.Join(outputDir, "stemcell-1.tgz")).To(BeAnExistingFile(), "the stemcell is still used by p-healthwatch")
+		Expect(filepath.Join(outputDir, "download-file.json")).To(BeAnExistingFile())
+
+		Expect(indexedProducts()).To(Equal([]string{"cf-2.0.0.pivotal", "cf-1.1.0.pivotal", "healthwatch-2.0.0.pivotal"}))
+		Expect(buffer).To(gbytes.Say("retention policy is deleting " + filepath.Join(outputDir, "cf-1.0.0.pivotal")))
+	})
+
+	It("evicts the product together with its stemcell when nothing else uses it", func() {
+		writeIndex(artifact{Slug: "cf", Version: "1.0.0", ProductPath: "cf-1.0.0.pivotal", StemcellPath: "stemcell-1.tgz", LastUsed: time.Now().Add(-10 * 24 * time.Hour)})
+
+		err := download("--retention", "newer-than=7d")
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(filepath.Join(outputDir, "cf-1.0.0.pivotal")).ToNot(BeAnExistingFile())
+		Expect(filepath.Join(outputDir, "stemcell-1.tgz")).ToNot(BeAnExistingFile())
+		Expect(indexedProducts()).To(Equal([]string{"cf-2.0.0.pivotal"}))
+	})
+
+	It("keeps artifacts that any rule keeps", func() {
+		now := time.Now()
+		writeIndex(
+			artifact{Slug: "cf", Version: "1.0.0", ProductPath: "cf-1.0.0.pivotal", LastUsed: now.Add(-time.Hour)},
+			artifact{Slug: "cf", Version: "0.9.0", ProductPath: "cf-0.9.0.pivotal", LastUsed: now.Add(-48 * time.Hour)},
+		)
+
+		err := download("--retention", "versions=1,newer-than=24h")
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(indexedProducts()).To(Equal([]string{"cf-2.0.0.pivotal", "cf-1.0.0.pivotal"}))
+		Expect(filepath.Join(outputDir, "cf-0.9.0.pivotal")).ToNot(BeAnExistingFile())
+	})
+
+	It("evicts the least recently used artifacts until the rest fit in the maximum size", func() {
+		now := time.Now()
+		writeIndex(
+			artifact{Slug: "cf", Version: "1.0.0", ProductPath: "cf-1.0.0.pivotal", LastUsed: now.Add(-2 * time.Hour)},
+			artifact{Slug: "p-healthwatch", Version: "2.0.0", ProductPath: "healthwatch-2.0.0.pivotal", LastUsed: now.Add(-time.Hour)},
+		)
+
+		// 250 bytes, which fits two of the 100 byte files
+		err := download("--retention", "max-size=0.00000023283064365386963")
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(indexedProducts()).To(Equal([]string{"cf-2.0.0.pivotal", "healthwatch-2.0.0.pivotal"}))
+		Expect(filepath.Join(outputDir, "cf-1.0.0.pivotal")).ToNot(BeAnExistingFile())
+	})
+
+	When("the output directory has artifacts the index does not list", func() {
+		writeArtifact := func(name string, lastUsed time.Time) {
+			path := filepath.Join(outputDir, name)
+			Expect(os.WriteFile(path, []byte(strings.Repeat("x", 100)), 0644)).To(Succeed())
+			Expect(os.Chtimes(path, lastUsed, lastUsed)).To(Succeed())
+		}
+
+		BeforeEach(func() {
+			now := time.Now()
+			writeArtifact("[cf,1.0.0]cf-1.0.0.pivotal", now.Add(-2*time.Hour))
+			writeArtifact("[cf,1.1.0]cf-1.1.0.pivotal", now.Add(-time.Hour))
+			writeArtifact("[p-healthwatch,2.0.0]healthwatch-2.0.0.pivotal", now.Add(-3*time.Hour))
+			writeArtifact("[cf,1.2.0]cf-1.2.0.pivotal.partial", now)
+			writeArtifact("unrelated.txt", now.Add(-4*time.Hour))
+		})
+
+		It("seeds the index from their [slug,version] prefix", func() {
+			err := download("--retention", "versions=2")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(filepath.Join(outputDir, "[cf,1.0.0]cf-1.0.0.pivotal")).ToNot(BeAnExistingFile())
+			Expect(filepath.Join(outputDir, "[cf,1.1.0]cf-1.1.0.pivotal")).To(BeAnExistingFile())
+			Expect(filepath.Join(outputDir, "[cf,1.2.0]cf-1.2.0.pivotal.partial")).To(BeAnExistingFile())
+			Expect(filepath.Join(outputDir, "unrelated.txt")).To(BeAnExistingFile())
+
+			Expect(indexedProducts()).To(Equal([]string{
+				"cf-2.0.0.pivotal",
+				"[cf,1.1.0]cf-1.1.0.pivotal",
+				"[p-healthwatch,2.0.0]healthwatch-2.0.0.pivotal",
+			}))
+		})
+
+		It("evicts them to fit in the maximum size", func() {
+			// 250 bytes, which fits two of the 100 byte files
+			err := download("--retention", "max-size=0.00000023283064365386963")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(indexedProducts()).To(Equal([]string{"cf-2.0.0.pivotal", "[cf,1.1.0]cf-1.1.0.pivotal"}))
+			Expect(filepath.Join(outputDir, "[cf,1.0.0]cf-1.0.0.pivotal")).ToNot(BeAnExistingFile())
+			Expect(filepath.Join(outputDir, "[p-healthwatch,2.0.0]healthwatch-2.0.0.pivotal")).ToNot(BeAnExistingFile())
+		})
+
+		It("adds them to an existing index", func() {
+			writeIndex(artifact{
+				Slug:         "cf",
+				Version:      "1.1.0",
+				ProductPath:  "[cf,1.1.0]cf-1.1.0.pivotal",
+				StemcellPath: "[stemcells-ubuntu-jammy,1.0]stemcell.tgz",
+				LastUsed:     time.Now().Add(-time.Minute),
+			})
+
+			err := download("--retention", "versions=2")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(filepath.Join(outputDir, "[cf,1.0.0]cf-1.0.0.pivotal")).ToNot(BeAnExistingFile())
+			Expect(filepath.Join(outputDir, "[stemcells-ubuntu-jammy,1.0]stemcell.tgz")).To(BeAnExistingFile())
+
+			Expect(readIndex()).To(HaveLen(3))
+			Expect(readIndex()[1].StemcellPath).To(Equal("[stemcells-ubuntu-jammy,1.0]stemcell.tgz"))
+			Expect(indexedProducts()).To(Equal([]string{
+				"cf-2.0.0.pivotal",
+				"[cf,1.1.0]cf-1.1.0.pivotal",
+				"[p-healthwatch,2.0.0]healthwatch-2.0.0.pivotal",
+			}))
+		})
+	})
+
+	It("lists what would be deleted on a dry run", func() {
+		writeIndex(artifact{Slug: "cf", Version: "1.0.0", ProductPath: "cf-1.0.0.pivotal", StemcellPath: "stemcell-1.tgz", LastUsed: time.Now().Add(-time.Hour)})
+
+		err := download("--retention", "versions=1", "--retention-dry-run")
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(buffer).To(gbytes.Say("retention policy would delete " + filepath.Join(outputDir, "cf-1.0.0.pivotal")))
+		Expect(buffer).To(gbytes.Say("retention policy would delete " + filepath.Join(outputDir, "stemcell-1.tgz")))
+		Expect(filepath.Join(outputDir, "cf-1.0.0.pivotal")).To(BeAnExistingFile())
+		Expect(filepath.Join(outputDir, "stemcell-1.tgz")).To(BeAnExistingFile())
+		Expect(indexedProducts()).To(Equal([]string{"cf-2.0.0.pivotal", "cf-1.0.0.pivotal"}))
+	})
+
+	When("the options are invalid", func() {
+		It("errors on an unknown rule", func() {
+			err := download("--retention", "count=2")
+			Expect(err).To(MatchError(`invalid --retention rule "count=2": supported rules are versions, newer-than and max-size`))
+			Expect(fakeProductDownloader.DownloadProductToFileCallCount()).To(Equal(0))
+		})
+
+		It("cannot be combined with --cache-cleanup", func() {
+			err := download("--retention", "versions=2", "--cache-cleanup", "I acknowledge this will delete files in the output directories")
+			Expect(err).To(MatchError("cannot use both --retention and --cache-cleanup; please choose one or the other"))
+		})
+
+		It("requires --retention for a dry run", func() {
+			err := download("--retention-dry-run")
+			Expect(err).To(MatchError("--retention-dry-run requires --retention to be defined"))
+		})
+	})
+})
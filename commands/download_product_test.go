@@ -244,6 +244,33 @@ var _ = Describe("DownloadProduct", func() {
 			})
 		})
 
+		When("--require-checksum is set and the downloader has no SHA sum for the file", func() {
+			BeforeEach(func() {
+				fa := &fakes.FileArtifacter{}
+				fa.NameReturns("[elastic-runtime,2.0.0]cf-2.0-build.1.pivotal")
+				fakeProductDownloader.GetLatestProductFileReturnsOnCall(0, fa, nil)
+				fakeProductDownloader.NameReturns("s3")
+			})
+
+			It("errors without downloading the file", func() {
+				tempDir, err := os.MkdirTemp("", "om-tests-")
+				Expect(err).ToNot(HaveOccurred())
+
+				commandArgs := []string{
+					"--pivnet-api-token", "token",
+					"--file-glob", "*.pivotal",
+					"--pivnet-product-slug", "elastic-runtime",
+					"--product-version", "2.0.0",
+					"--output-directory", tempDir,
+					"--require-checksum",
+				}
+
+				err = executeCommand(command, commandArgs)
+				Expect(err).To(MatchError(ContainSubstring("could not find a SHA256 checksum for [elastic-runtime,2.0.0]cf-2.0-build.1.pivotal from source s3, which --require-checksum requires")))
+				Expect(fakeProductDownloader.DownloadProductToFileCallCount()).To(Equal(0))
+			})
+		})
+
 		When("the stemcell-iaas flag is set", func() {
 			When("the product has an associated stemcell", func() {
 				BeforeEach(func() {
@@ -1133,6 +1160,59 @@ var _ = Describe("DownloadProduct", func() {
 		})
 	})
 
+	When("--download-chunks is negative", func() {
+		It("returns an error", func() {
+			tempDir, err := os.MkdirTemp("", "om-tests-")
+			Expect(err).ToNot(HaveOccurred())
+
+			err = executeCommand(command, []string{
+				"--pivnet-api-token", "token",
+				"--file-glob", "*.pivotal",
+				"--pivnet-product-slug", "elastic-runtime",
+				"--product-version", "2.0.0",
+				"--output-directory", tempDir,
+				"--download-chunks", "-1",
+			})
+			Expect(err).To(MatchError(ContainSubstring("--download-chunks must be a positive number")))
+		})
+	})
+
+	When("--download-chunks is provided and the source does not support ranged downloads", func() {
+		BeforeEach(func() {
+			fa := &fakes.FileArtifacter{}
+			fa.NameReturns("/some-account/some-bucket/cf-2.0-build.1.pivotal")
+			fakeProductDownloader.GetLatestProductFileReturns(fa, nil)
+			fakeProductDownloader.NameReturns("pivnet")
+		})
+
+		It("discards a previous partial download and downloads sequentially", func() {
+			tempDir, err := os.MkdirTemp("", "om-tests-")
+			Expect(err).ToNot(HaveOccurred())
+
+			partialFile := filepath.Join(tempDir, "cf-2.0-build.1.pivotal.partial")
+			Expect(os.WriteFile(partialFile, []byte("stale"), 0644)).To(Succeed())
+
+			fakeProductDownloader.DownloadProductToFileStub = func(_ download_clients.FileArtifacter, file *os.File) error {
+				_, err := file.WriteString("contents")
+				return err
+			}
+
+			err = executeCommand(command, []string{
+				"--pivnet-api-token", "token",
+				"--file-glob", "*.pivotal",
+				"--pivnet-product-slug", "elastic-runtime",
+				"--product-version", "2.0.0",
+				"--output-directory", tempDir,
+				"--download-chunks", "4",
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(buffer).To(gbytes.Say("source pivnet does not support ranged downloads, downloading sequentially"))
+
+			Expect(fakeProductDownloader.DownloadProductToFileCallCount()).To(Equal(1))
+			Expect(os.ReadFile(filepath.Join(tempDir, "cf-2.0-build.1.pivotal"))).To(Equal([]byte("contents")))
+		})
+	})
+
 	When("directory flags are provided pointing to directories that don't exist", func() {
 		var (
 			nonexistingDir string
@@ -1242,6 +1322,20 @@ var _ = Describe("DownloadProduct", func() {
 		})
 	})
 
+	When("the source is oci and the registry is missing", func() {
+		It("returns an error", func() {
+			err := executeCommand(command, []string{
+				"--source", "oci",
+				"--file-glob", "*.pivotal",
+				"--pivnet-product-slug", "mayhem-crew",
+				"--product-version", `2.0.0`,
+				"--output-directory", GinkgoT().TempDir(),
+			})
+			Expect(err).To(MatchError(ContainSubstring("could not find valid source for 'oci'")))
+			Expect(err).To(MatchError(ContainSubstring("Field validation for 'Registry' failed on the 'required' tag")))
+		})
+	})
+
 	When("both product-version and product-version-regex are set", func() {
 		It("fails with an error saying that the user must pick one or the other", func() {
 			tempDir, err := os.MkdirTemp("", "om-tests-")
@@ -1259,6 +1353,49 @@ var _ = Describe("DownloadProduct", func() {
 		})
 	})
 
+	When("product-version-constraint is set with product-version-regex", func() {
+		It("fails with an error saying that the user must pick one", func() {
+			tempDir, err := os.MkdirTemp("", "om-tests-")
+			Expect(err).ToNot(HaveOccurred())
+
+			err = executeCommand(command, []string{
+				"--pivnet-api-token", "token",
+				"--file-glob", "*.pivotal",
+				"--pivnet-product-slug", "elastic-runtime",
+				"--product-version-constraint", "~> 2.0",
+				"--product-version-regex", ".*",
+				"--output-directory", tempDir,
+			})
+			Expect(err).To(MatchError(ContainSubstring("cannot use --product-version-constraint with --product-version or --product-version-regex; please choose one")))
+		})
+	})
+
+	When("product-version-constraint is set", func() {
+		BeforeEach(func() {
+			fakeProductDownloader.GetAllProductVersionsReturns([]string{"2.10.1", "2.10.12", "2.100.0", "2.11.0"}, nil)
+			fa := &fakes.FileArtifacter{}
+			fa.NameReturns("/some-account/some-bucket/cf-2.10.12.pivotal")
+			fakeProductDownloader.GetLatestProductFileReturns(fa, nil)
+		})
+
+		It("downloads the highest version satisfying the constraint", func() {
+			tempDir, err := os.MkdirTemp("", "om-tests-")
+			Expect(err).ToNot(HaveOccurred())
+
+			err = executeCommand(command, []string{
+				"--pivnet-api-token", "token",
+				"--file-glob", "*.pivotal",
+				"--pivnet-product-slug", "elastic-runtime",
+				"--product-version-constraint", "~> 2.10.0",
+				"--output-directory", tempDir,
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			_, version, _ := fakeProductDownloader.GetLatestProductFileArgsForCall(0)
+			Expect(version).To(Equal("2.10.12"))
+		})
+	})
+
 	When("neither product-version nor product-version-regex are set", func() {
 		It("fails with an error saying that the user must provide one or the other", func() {
 			tempDir, err := os.MkdirTemp("", "om-tests-")
@@ -1295,6 +1432,115 @@ var _ = Describe("DownloadProduct", func() {
 	})
 })
 
+var _ = Describe("DownloadProduct with --all-stemcells", func() {
+	var (
+		command               *commands.DownloadProduct
+		fakeProductDownloader *fakes.ProductDownloader
+		buffer                *gbytes.Buffer
+		outputDir             string
+	)
+
+	download := func(args ...string) error {
+		return executeCommand(command, append([]string{
+			"--pivnet-api-token", "token",
+			"--file-glob", "*.pivotal",
+			"--pivnet-product-slug", "pas-windows",
+			"--product-version", "2.0.0",
+			"--output-directory", outputDir,
+		}, args...))
+	}
+
+	BeforeEach(func() {
+		outputDir = GinkgoT().TempDir()
+
+		fakeProductDownloader = &fakes.ProductDownloader{}
+		fakeProductDownloader.NameReturns("pivnet")
+		fakeProductDownloader.GetAllProductVersionsReturns([]string{"2.0.0"}, nil)
+		fakeProductDownloader.GetLatestProductFileStub = func(slug, version, glob string) (download_clients.FileArtifacter, error) {
+			fa := &fakes.FileArtifacter{}
+			if slug == "pas-windows" {
+				fa.NameReturns("pas-windows-2.0.0.pivotal")
+			} else {
+				fa.NameReturns(fmt.Sprintf("light-bosh-stemcell-%s-google-%s.tgz", version, slug))
+			}
+			return fa, nil
+		}
+		fakeProductDownloader.DownloadProductToFileStub = func(fa download_clients.FileArtifacter, file *os.File) error {
+			if filepath.Ext(fa.Name()) != ".pivotal" {
+				return nil
+			}
+
+			defer file.Close()
+			z := zip.NewWriter(file)
+			f, err := z.Create("metadata/pas-windows.yml")
+			Expect(err).ToNot(HaveOccurred())
+			_, err = f.Write([]byte(`{name: pas-windows, product_version: 2.0.0, stemcell_criteria: {os: ubuntu-jammy, version: "1.90"}, additional_stemcells_criteria: [{os: windows2019, version: "2019.41"}]}`))
+			Expect(err).ToNot(HaveOccurred())
+			return z.Close()
+		}
+		fakeProductDownloader.GetLatestStemcellForProductStub = func(_ download_clients.FileArtifacter, _ string, slug string) (download_clients.StemcellArtifacter, error) {
+			sa := &fakes.StemcellArtifacter{}
+			sa.SlugReturns(slug)
+			if slug == "stemcells-windows-server" {
+				sa.VersionReturns("2019.76")
+			} else {
+				sa.VersionReturns("1.95")
+			}
+			return sa, nil
+		}
+
+		download_clients.NewPivnetClient = func(stdout *log.Logger, stderr *log.Logger, factory download_clients.PivnetFactory, token string, skipSSL bool, pivnetHost string, proxyURL string, proxyUsername string, proxyPassword string, proxyAuthType string, proxyKrb5Config string) (download_clients.ProductDownloader, error) {
+			return fakeProductDownloader, nil
+		}
+
+		buffer = gbytes.NewBuffer()
+		command = commands.NewDownloadProduct(func() []string { return nil }, log.New(buffer, "", 0), log.New(buffer, "", 0), buffer, &cmdFakes.DownloadProductService{})
+	})
+
+	It("downloads a stemcell for each stemcell criteria and records them all", func() {
+		err := download("--stemcell-iaas", "google", "--all-stemcells")
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(fakeProductDownloader.GetLatestStemcellForProductCallCount()).To(Equal(2))
+		_, _, slug := fakeProductDownloader.GetLatestStemcellForProductArgsForCall(0)
+		Expect(slug).To(Equal("stemcells-ubuntu-jammy"))
+		_, _, slug = fakeProductDownloader.GetLatestStemcellForProductArgsForCall(1)
+		Expect(slug).To(Equal("stemcells-windows-server"))
+
+		jammyPath := filepath.Join(outputDir, "light-bosh-stemcell-1.95-google-stemcells-ubuntu-jammy.tgz")
+		windowsPath := filepath.Join(outputDir, "light-bosh-stemcell-2019.76-google-stemcells-windows-server.tgz")
+
+		downloadFile, err := os.ReadFile(filepath.Join(outputDir, "download-file.json"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(downloadFile).To(MatchJSON(fmt.Sprintf(`{
+			"product_path": %q,
+			"product_slug": "pas-windows",
+			"product_version": "2.0.0",
+			"stemcell_path": %q,
+			"stemcell_version": "1.95",
+			"stemcells": [
+				{"os": "ubuntu-jammy", "stemcell_path": %q, "stemcell_version": "1.95"},
+				{"os": "windows2019", "stemcell_path": %q, "stemcell_version": "2019.76"}
+			]
+		}`, filepath.Join(outputDir, "pas-windows-2.0.0.pivotal"), jammyPath, jammyPath, windowsPath)))
+
+		assignMultiStemcell, err := os.ReadFile(filepath.Join(outputDir, "assign-multi-stemcell.yml"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(assignMultiStemcell).To(MatchJSON(`{"product": "pas-windows", "stemcell": ["ubuntu-jammy:1.95", "windows2019:2019.76"]}`))
+		Expect(filepath.Join(outputDir, "assign-stemcell.yml")).ToNot(BeAnExistingFile())
+	})
+
+	It("requires --stemcell-iaas", func() {
+		err := download("--all-stemcells")
+		Expect(err).To(MatchError("--all-stemcells requires --stemcell-iaas to be defined"))
+	})
+
+	It("cannot be combined with --stemcell-slug", func() {
+		err := download("--stemcell-iaas", "google", "--all-stemcells", "--stemcell-slug", "stemcells-ubuntu-jammy")
+		Expect(err).To(MatchError("cannot use --all-stemcells with --stemcell-slug or --stemcell-version; please choose one"))
+	})
+})
+
 func createProductPivotalFile(file *os.File) {
 	var err error
 	defer file.Close()
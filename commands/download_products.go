@@ -0,0 +1,314 @@
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/jessevdk/go-flags"
+	"github.com/pivotal-cf/om/interpolate"
+	"gopkg.in/yaml.v2"
+)
+
+type DownloadProducts struct {
+	environFunc    func() []string
+	progressWriter io.Writer
+	stderr         *log.Logger
+	stdout         *log.Logger
+	service        downloadProductService
+	Options        struct {
+		Manifest    string   `long:"manifest"    short:"m" required:"true" description:"path to yml file listing the products to download (see docs/download-products/README.md for format)"`
+		Concurrency int      `long:"concurrency"                           description:"number of products downloaded at the same time" default:"2"`
+		VarsFile    []string `long:"vars-file"   short:"l"                 description:"load variables from a YAML file"`
+		Vars        []string `long:"var"         short:"v"                 description:"load variable from the command line. Format: VAR=VAL"`
+		VarsEnv     []string `long:"vars-env"    env:"OM_VARS_ENV"         description:"load variables from environment variables (e.g.: 'MY' to load MY_var=value)"`
+		OpsFile     []string `long:"ops-file"    short:"o"                 description:"YAML operations file"`
+	}
+}
+
+type downloadProductsManifest struct {
+	Options  map[string]interface{}   `yaml:",inline"`
+	Products []map[string]interface{} `yaml:"products"`
+}
+
+type downloadedProductOutput struct {
+	ProductPath     string `json:"product_path,omitempty"`
+	ProductSlug     string `json:"product_slug,omitempty"`
+	ProductVersion  string `json:"product_version,omitempty"`
+	StemcellPath    string `json:"stemcell_path,omitempty"`
+	StemcellVersion string `json:"stemcell_version,omitempty"`
+
+	Stemcells []downloadedStemcell `json:"stemcells,omitempty"`
+}
+
+func NewDownloadProducts(environFunc func() []string, stdout *log.Logger, stderr *log.Logger, progressWriter io.Writer, downloadProductService downloadProductService) *DownloadProducts {
+	return &DownloadProducts{
+		environFunc:    environFunc,
+		stderr:         stderr,
+		stdout:         stdout,
+		progressWriter: progressWriter,
+		service:        downloadProductService,
+	}
+}
+
+func (c *DownloadProducts) Execute(args []string) error {
+	if c.Options.Concurrency < 1 {
+		return errors.New("--concurrency must be at least 1")
+	}
+
+	manifest, err := c.loadManifest()
+	if err != nil {
+		return err
+	}
+
+	outputDir, ok := manifest.Options["output-directory"].(string)
+	if !ok || outputDir == "" {
+		return fmt.Errorf("%s must set output-directory for all products", c.Options.Manifest)
+	}
+
+	if len(manifest.Products) == 0 {
+		return fmt.Errorf("%s does not list any products", c.Options.Manifest)
+	}
+
+	var commands []*DownloadProduct
+	slugs := map[string]int{}
+	for index, product := range manifest.Products {
+		command, err := c.productCommand(manifest.Options, product)
+		if err != nil {
+			return fmt.Errorf("invalid product at index %d in %s: %w", index, c.Options.Manifest, err)
+		}
+
+		slug := command.Options.PivnetProductSlug
+		if previous, ok := slugs[slug]; ok {
+			return fmt.Errorf("invalid product at index %d in %s: %q is already listed at index %d", index, c.Options.Manifest, slug, previous)
+		}
+		slugs[slug] = index
+
+		commands = append(commands, command)
+	}
+
+	locks := &pathLocks{locks: map[string]*sync.Mutex{}}
+	outputs := make([]downloadedProductOutput, len(commands))
+	failures := make([]error, len(commands))
+
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, c.Options.Concurrency)
+	for index, command := range commands {
+		wg.Add(1)
+		slots <- struct{}{}
+
+		go func(index int, command *DownloadProduct) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			command.lockPath = locks.lock
+			outputs[index], failures[index] = c.downloadProduct(command)
+		}(index, command)
+	}
+	wg.Wait()
+
+	err = errors.Join(failures...)
+	if err != nil {
+		return err
+	}
+
+	return c.writeDownloadProductsOutput(outputDir, outputs)
+}
+
+func (c *DownloadProducts) loadManifest() (downloadProductsManifest, error) {
+	contents, err := interpolate.Execute(interpolate.Options{
+		TemplateFile:  c.Options.Manifest,
+		VarsFiles:     c.Options.VarsFile,
+		Vars:          c.Options.Vars,
+		EnvironFunc:   c.environFunc,
+		VarsEnvs:      c.Options.VarsEnv,
+		OpsFiles:      c.Options.OpsFile,
+		ExpectAllKeys: true,
+	})
+	if err != nil {
+		return downloadProductsManifest{}, fmt.Errorf("could not load the manifest: %w", err)
+	}
+
+	var manifest downloadProductsManifest
+	err = yaml.Unmarshal(contents, &manifest)
+	if err != nil {
+		return downloadProductsManifest{}, fmt.Errorf("%s could not be parsed as a valid manifest: %w", c.Options.Manifest, err)
+	}
+
+	return manifest, nil
+}
+
+// productCommand builds the download-product command for a single product.
+// The keys of the product, and of the shared options it overrides, are the
+// flags of download-product.
+func (c *DownloadProducts) productCommand(shared, product map[string]interface{}) (*DownloadProduct, error) {
+	options := map[string]interface{}{}
+	for key, value := range shared {
+		options[key] = value
+	}
+	for key, value := range product {
+		options[key] = value
+	}
+
+	var args []string
+	for key, value := range options {
+		switch convertedValue := value.(type) {
+		case []interface{}:
+			for _, v := range convertedValue {
+				args = append(args, fmt.Sprintf("--%s=%v", key, v))
+			}
+		case bool:
+			if convertedValue {
+				args = append(args, fmt.Sprintf("--%s", key))
+			}
+		default:
+			args = append(args, fmt.Sprintf("--%s=%v", key, convertedValue))
+		}
+	}
+	sort.Strings(args)
+
+	command := NewDownloadProduct(c.environFunc, c.stdout, c.stderr, c.progressWriter, c.service)
+
+	_, err := flags.NewParser(&command.Options, flags.None).ParseArgs(args)
+	if err != nil {
+		return nil, err
+	}
+
+	if command.Options.CacheCleanup != "" {
+		return nil, errors.New("cache-cleanup is not supported when downloading multiple products")
+	}
+
+	if command.Options.Retention != "" {
+		return nil, errors.New("retention is not supported when downloading multiple products")
+	}
+
+	return command, nil
+}
+
+func (c *DownloadProducts) downloadProduct(command *DownloadProduct) (downloadedProductOutput, error) {
+	slug := command.Options.PivnetProductSlug
+
+	err := command.validate()
+	if err != nil {
+		return downloadedProductOutput{}, fmt.Errorf("could not download %s: %w", slug, err)
+	}
+
+	err = command.createClient()
+	if err != nil {
+		return downloadedProductOutput{}, fmt.Errorf("could not download %s: %w", slug, err)
+	}
+
+	downloaded, err := command.download()
+	if err != nil {
+		return downloadedProductOutput{}, fmt.Errorf("could not download %s: %w", slug, err)
+	}
+
+	return downloadedProductOutput{
+		ProductPath:     downloaded.productPath,
+		ProductSlug:     slug,
+		ProductVersion:  downloaded.productVersion,
+		StemcellPath:    downloaded.stemcellPath,
+		StemcellVersion: downloaded.stemcellVersion,
+		Stemcells:       downloaded.stemcells,
+	}, nil
+}
+
+func (c *DownloadProducts) writeDownloadProductsOutput(outputDir string, outputs []downloadedProductOutput) error {
+	downloadProductsFilename := "download-file.json"
+	c.stderr.Printf("Writing a list of downloaded artifacts to %s", downloadProductsFilename)
+
+	type stemcellOutput struct {
+		StemcellPath    string `json:"stemcell_path"`
+		StemcellVersion string `json:"stemcell_version"`
+	}
+
+	payload := struct {
+		Products  []downloadedProductOutput `json:"products"`
+		Stemcells []stemcellOutput          `json:"stemcells"`
+	}{
+		Products:  outputs,
+		Stemcells: []stemcellOutput{},
+	}
+
+	seen := map[string]bool{}
+	for _, output := range outputs {
+		stemcells := []stemcellOutput{{StemcellPath: output.StemcellPath, StemcellVersion: output.StemcellVersion}}
+		for _, stemcell := range output.Stemcells {
+			stemcells = append(stemcells, stemcellOutput{StemcellPath: stemcell.StemcellPath, StemcellVersion: stemcell.StemcellVersion})
+		}
+
+		for _, stemcell := range stemcells {
+			if stemcell.StemcellPath == "" || seen[stemcell.StemcellPath] {
+				continue
+			}
+
+			seen[stemcell.StemcellPath] = true
+			payload.Stemcells = append(payload.Stemcells, stemcell)
+		}
+	}
+
+	outputFile, err := os.Create(filepath.Join(outputDir, downloadProductsFilename))
+	if err != nil {
+		return fmt.Errorf("could not create %s: %s", downloadProductsFilename, err)
+	}
+	defer outputFile.Close()
+
+	err = json.NewEncoder(outputFile).Encode(payload)
+	if err != nil {
+		return fmt.Errorf("could not encode JSON for %s: %s", downloadProductsFilename, err)
+	}
+
+	for _, output := range outputs {
+		err = c.writeDownloadProductOutput(outputDir, output)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeDownloadProductOutput writes the download-file.json download-product
+// would have written for the product, named after its slug.
+func (c *DownloadProducts) writeDownloadProductOutput(outputDir string, output downloadedProductOutput) error {
+	downloadProductFilename := fmt.Sprintf("download-file-%s.json", output.ProductSlug)
+
+	outputFile, err := os.Create(filepath.Join(outputDir, downloadProductFilename))
+	if err != nil {
+		return fmt.Errorf("could not create %s: %s", downloadProductFilename, err)
+	}
+	defer outputFile.Close()
+
+	err = json.NewEncoder(outputFile).Encode(output)
+	if err != nil {
+		return fmt.Errorf("could not encode JSON for %s: %s", downloadProductFilename, err)
+	}
+
+	return nil
+}
+
+// pathLocks serializes downloads to the same path, so that a stemcell shared
+// by several products is downloaded once and then found to already exist.
+type pathLocks struct {
+	mutex sync.Mutex
+	locks map[string]*sync.Mutex
+}
+
+func (p *pathLocks) lock(path string) func() {
+	p.mutex.Lock()
+	lock, ok := p.locks[path]
+	if !ok {
+		lock = &sync.Mutex{}
+		p.locks[path] = lock
+	}
+	p.mutex.Unlock()
+
+	lock.Lock()
+	return lock.Unlock
+}
@@ -0,0 +1,242 @@
+package commands_test
+
+import (
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/onsi/gomega/gbytes"
+
+	"github.com/pivotal-cf/om/commands"
+	cmdFakes "github.com/pivotal-cf/om/commands/fakes"
+	"github.com/pivotal-cf/om/download_clients"
+	"github.com/pivotal-cf/om/download_clients/fakes"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("DownloadProducts", func() {
+	var (
+		command               *commands.DownloadProducts
+		fakeProductDownloader *fakes.ProductDownloader
+		outputDir             string
+		manifestFile          string
+	)
+
+	writeManifest := func(contents string) {
+		Expect(os.WriteFile(manifestFile, []byte(contents), 0600)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		outputDir = GinkgoT().TempDir()
+		manifestFile = filepath.Join(GinkgoT().TempDir(), "products.yml")
+
+		fakeProductDownloader = &fakes.ProductDownloader{}
+		fakeProductDownloader.NameReturns("pivnet")
+		fakeProductDownloader.GetAllProductVersionsStub = func(slug string) ([]string, error) {
+			return []string{"1.0.0", "2.0.0", "2.1.0"}, nil
+		}
+		fakeProductDownloader.GetLatestProductFileStub = func(slug, version, glob string) (download_clients.FileArtifacter, error) {
+			if version == "9.9.9" {
+				return nil, errors.New("no such release")
+			}
+
+			fa := &fakes.FileArtifacter{}
+			if strings.Contains(glob, "bosh") {
+				fa.NameReturns("light-bosh-stemcell-97.190-google.tgz")
+			} else {
+				fa.NameReturns(slug + "-" + version + ".pivotal")
+			}
+			return fa, nil
+		}
+		fakeProductDownloader.DownloadProductToFileStub = func(fa download_clients.FileArtifacter, file *os.File) error {
+			if filepath.Ext(fa.Name()) == ".pivotal" {
+				createProductPivotalFile(file)
+			}
+			return nil
+		}
+
+		sa := &fakes.StemcellArtifacter{}
+		sa.SlugReturns("stemcells-ubuntu-xenial")
+		sa.VersionReturns("97.190")
+		fakeProductDownloader.GetLatestStemcellForProductReturns(sa, nil)
+
+		download_clients.NewPivnetClient = func(stdout *log.Logger, stderr *log.Logger, factory download_clients.PivnetFactory, token string, skipSSL bool, pivnetHost string, proxyURL string, proxyUsername string, proxyPassword string, proxyAuthType string, proxyKrb5Config string) (download_clients.ProductDownloader, error) {
+			return fakeProductDownloader, nil
+		}
+
+		buffer := gbytes.NewBuffer()
+		command = commands.NewDownloadProducts(func() []string { return nil }, log.New(buffer, "", 0), log.New(buffer, "", 0), buffer, &cmdFakes.DownloadProductService{})
+	})
+
+	It("downloads every product with the shared options and writes a combined download-file.json", func() {
+		writeManifest(`
+pivnet-api-token: ((token))
+output-directory: ` + outputDir + `
+stemcell-iaas: google
+products:
+- pivnet-product-slug: cf
+  product-version: 2.0.0
+  file-glob: "*.pivotal"
+- pivnet-product-slug: p-healthwatch
+  product-version-regex: ^2\..*$
+  file-glob: "*.pivotal"
+- pivnet-product-slug: p-compliance
+  product-version: 1.0.0
+  file-glob: "*.pivotal"
+  stemcell-iaas: ""
+`)
+
+		err := executeCommand(command, []string{
+			"--manifest", manifestFile,
+			"--var", "token=some-token",
+			"--concurrency", "3",
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		var stemcellDownloads int
+		for i := 0; i < fakeProductDownloader.DownloadProductToFileCallCount(); i++ {
+			fa, _ := fakeProductDownloader.DownloadProductToFileArgsForCall(i)
+			if fa.Name() == "light-bosh-stemcell-97.190-google.tgz" {
+				stemcellDownloads++
+			}
+		}
+		Expect(stemcellDownloads).To(Equal(1))
+		Expect(fakeProductDownloader.DownloadProductToFileCallCount()).To(Equal(4))
+
+		stemcellPath := filepath.Join(outputDir, "light-bosh-stemcell-97.190-google.tgz")
+		contents, err := os.ReadFile(filepath.Join(outputDir, "download-file.json"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(contents)).To(MatchJSON(`{
+			"products": [{
+				"product_path": "` + filepath.Join(outputDir, "cf-2.0.0.pivotal") + `",
+				"product_slug": "cf",
+				"product_version": "2.0.0",
+				"stemcell_path": "` + stemcellPath + `",
+				"stemcell_version": "97.190"
+			}, {
+				"product_path": "` + filepath.Join(outputDir, "p-healthwatch-2.1.0.pivotal") + `",
+				"product_slug": "p-healthwatch",
+				"product_version": "2.1.0",
+				"stemcell_path": "` + stemcellPath + `",
+				"stemcell_version": "97.190"
+			}, {
+				"product_path": "` + filepath.Join(outputDir, "p-compliance-1.0.0.pivotal") + `",
+				"product_slug": "p-compliance",
+				"product_version": "1.0.0"
+			}],
+			"stemcells": [{
+				"stemcell_path": "` + stemcellPath + `",
+				"stemcell_version": "97.190"
+			}]
+		}`))
+
+		contents, err = os.ReadFile(filepath.Join(outputDir, "download-file-cf.json"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(contents)).To(MatchJSON(`{
+			"product_path": "` + filepath.Join(outputDir, "cf-2.0.0.pivotal") + `",
+			"product_slug": "cf",
+			"product_version": "2.0.0",
+			"stemcell_path": "` + stemcellPath + `",
+			"stemcell_version": "97.190"
+		}`))
+
+		contents, err = os.ReadFile(filepath.Join(outputDir, "download-file-p-compliance.json"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(contents)).To(MatchJSON(`{
+			"product_path": "` + filepath.Join(outputDir, "p-compliance-1.0.0.pivotal") + `",
+			"product_slug": "p-compliance",
+			"product_version": "1.0.0"
+		}`))
+		Expect(filepath.Join(outputDir, "download-file-p-healthwatch.json")).To(BeAnExistingFile())
+	})
+
+	When("a product fails to download", func() {
+		It("returns the error for that product and does not write the output", func() {
+			writeManifest(`
+pivnet-api-token: token
+output-directory: ` + outputDir + `
+products:
+- pivnet-product-slug: cf
+  product-version: 2.0.0
+  file-glob: "*.pivotal"
+- pivnet-product-slug: p-healthwatch
+  product-version: 9.9.9
+  file-glob: "*.pivotal"
+`)
+
+			err := executeCommand(command, []string{"--manifest", manifestFile})
+			Expect(err).To(MatchError(ContainSubstring("could not download p-healthwatch")))
+			Expect(filepath.Join(outputDir, "download-file.json")).ToNot(BeAnExistingFile())
+		})
+	})
+
+	When("the manifest is invalid", func() {
+		It("requires a shared output-directory", func() {
+			writeManifest(`
+products:
+- pivnet-product-slug: cf
+`)
+
+			err := executeCommand(command, []string{"--manifest", manifestFile})
+			Expect(err).To(MatchError(ContainSubstring("must set output-directory for all products")))
+		})
+
+		It("requires products", func() {
+			writeManifest(`output-directory: ` + outputDir)
+
+			err := executeCommand(command, []string{"--manifest", manifestFile})
+			Expect(err).To(MatchError(ContainSubstring("does not list any products")))
+		})
+
+		It("reports products with unknown or missing options", func() {
+			writeManifest(`
+output-directory: ` + outputDir + `
+products:
+- product-version: 2.0.0
+`)
+
+			err := executeCommand(command, []string{"--manifest", manifestFile})
+			Expect(err).To(MatchError(ContainSubstring("invalid product at index 0")))
+			Expect(err).To(MatchError(ContainSubstring("pivnet-product-slug")))
+		})
+
+		It("rejects products listed more than once", func() {
+			writeManifest(`
+output-directory: ` + outputDir + `
+products:
+- pivnet-product-slug: cf
+  product-version: 2.0.0
+- pivnet-product-slug: p-compliance
+- pivnet-product-slug: cf
+  product-version: 2.1.0
+`)
+
+			err := executeCommand(command, []string{"--manifest", manifestFile})
+			Expect(err).To(MatchError(ContainSubstring(`invalid product at index 2`)))
+			Expect(err).To(MatchError(ContainSubstring(`"cf" is already listed at index 0`)))
+		})
+
+		It("does not support cache-cleanup", func() {
+			writeManifest(`
+output-directory: ` + outputDir + `
+cache-cleanup: I acknowledge this will delete files in the output directories
+products:
+- pivnet-product-slug: cf
+`)
+
+			err := executeCommand(command, []string{"--manifest", manifestFile})
+			Expect(err).To(MatchError(ContainSubstring("cache-cleanup is not supported when downloading multiple products")))
+		})
+	})
+
+	It("requires a positive concurrency", func() {
+		writeManifest(`output-directory: ` + outputDir)
+
+		err := executeCommand(command, []string{"--manifest", manifestFile, "--concurrency", "0"})
+		Expect(err).To(MatchError("--concurrency must be at least 1"))
+	})
+})
@@ -0,0 +1,621 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package fakes
+
+import (
+	"sync"
+
+	"github.com/pivotal-cf/om/api"
+)
+
+type BundleUploadService struct {
+	AssignMultiStemcellStub        func(api.ProductMultiStemcells) error
+	assignMultiStemcellMutex       sync.RWMutex
+	assignMultiStemcellArgsForCall []struct {
+		arg1 api.ProductMultiStemcells
+	}
+	assignMultiStemcellReturns struct {
+		result1 error
+	}
+	assignMultiStemcellReturnsOnCall map[int]struct {
+		result1 error
+	}
+	CheckProductAvailabilityStub        func(string, string) (bool, error)
+	checkProductAvailabilityMutex       sync.RWMutex
+	checkProductAvailabilityArgsForCall []struct {
+		arg1 string
+		arg2 string
+	}
+	checkProductAvailabilityReturns struct {
+		result1 bool
+		result2 error
+	}
+	checkProductAvailabilityReturnsOnCall map[int]struct {
+		result1 bool
+		result2 error
+	}
+	CheckStemcellAvailabilityStub        func(string) (bool, error)
+	checkStemcellAvailabilityMutex       sync.RWMutex
+	checkStemcellAvailabilityArgsForCall []struct {
+		arg1 string
+	}
+	checkStemcellAvailabilityReturns struct {
+		result1 bool
+		result2 error
+	}
+	checkStemcellAvailabilityReturnsOnCall map[int]struct {
+		result1 bool
+		result2 error
+	}
+	GetDiagnosticReportStub        func() (api.DiagnosticReport, error)
+	getDiagnosticReportMutex       sync.RWMutex
+	getDiagnosticReportArgsForCall []struct {
+	}
+	getDiagnosticReportReturns struct {
+		result1 api.DiagnosticReport
+		result2 error
+	}
+	getDiagnosticReportReturnsOnCall map[int]struct {
+		result1 api.DiagnosticReport
+		result2 error
+	}
+	InfoStub        func() (api.Info, error)
+	infoMutex       sync.RWMutex
+	infoArgsForCall []struct {
+	}
+	infoReturns struct {
+		result1 api.Info
+		result2 error
+	}
+	infoReturnsOnCall map[int]struct {
+		result1 api.Info
+		result2 error
+	}
+	ListMultiStemcellsStub        func() (api.ProductMultiStemcells, error)
+	listMultiStemcellsMutex       sync.RWMutex
+	listMultiStemcellsArgsForCall []struct {
+	}
+	listMultiStemcellsReturns struct {
+		result1 api.ProductMultiStemcells
+		result2 error
+	}
+	listMultiStemcellsReturnsOnCall map[int]struct {
+		result1 api.ProductMultiStemcells
+		result2 error
+	}
+	UploadAvailableProductStub        func(api.UploadAvailableProductInput) (api.UploadAvailableProductOutput, error)
+	uploadAvailableProductMutex       sync.RWMutex
+	uploadAvailableProductArgsForCall []struct {
+		arg1 api.UploadAvailableProductInput
+	}
+	uploadAvailableProductReturns struct {
+		result1 api.UploadAvailableProductOutput
+		result2 error
+	}
+	uploadAvailableProductReturnsOnCall map[int]struct {
+		result1 api.UploadAvailableProductOutput
+		result2 error
+	}
+	UploadStemcellStub        func(api.StemcellUploadInput) (api.StemcellUploadOutput, error)
+	uploadStemcellMutex       sync.RWMutex
+	uploadStemcellArgsForCall []struct {
+		arg1 api.StemcellUploadInput
+	}
+	uploadStemcellReturns struct {
+		result1 api.StemcellUploadOutput
+		result2 error
+	}
+	uploadStemcellReturnsOnCall map[int]struct {
+		result1 api.StemcellUploadOutput
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *BundleUploadService) AssignMultiStemcell(arg1 api.ProductMultiStemcells) error {
+	fake.assignMultiStemcellMutex.Lock()
+	ret, specificReturn := fake.assignMultiStemcellReturnsOnCall[len(fake.assignMultiStemcellArgsForCall)]
+	fake.assignMultiStemcellArgsForCall = append(fake.assignMultiStemcellArgsForCall, struct {
+		arg1 api.ProductMultiStemcells
+	}{arg1})
+	stub := fake.AssignMultiStemcellStub
+	fakeReturns := fake.assignMultiStemcellReturns
+	fake.recordInvocation("AssignMultiStemcell", []interface{}{arg1})
+	fake.assignMultiStemcellMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *BundleUploadService) AssignMultiStemcellCallCount() int {
+	fake.assignMultiStemcellMutex.RLock()
+	defer fake.assignMultiStemcellMutex.RUnlock()
+	return len(fake.assignMultiStemcellArgsForCall)
+}
+
+func (fake *BundleUploadService) AssignMultiStemcellCalls(stub func(api.ProductMultiStemcells) error) {
+	fake.assignMultiStemcellMutex.Lock()
+	defer fake.assignMultiStemcellMutex.Unlock()
+	fake.AssignMultiStemcellStub = stub
+}
+
+func (fake *BundleUploadService) AssignMultiStemcellArgsForCall(i int) api.ProductMultiStemcells {
+	fake.assignMultiStemcellMutex.RLock()
+	defer fake.assignMultiStemcellMutex.RUnlock()
+	argsForCall := fake.assignMultiStemcellArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *BundleUploadService) AssignMultiStemcellReturns(result1 error) {
+	fake.assignMultiStemcellMutex.Lock()
+	defer fake.assignMultiStemcellMutex.Unlock()
+	fake.AssignMultiStemcellStub = nil
+	fake.assignMultiStemcellReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *BundleUploadService) AssignMultiStemcellReturnsOnCall(i int, result1 error) {
+	fake.assignMultiStemcellMutex.Lock()
+	defer fake.assignMultiStemcellMutex.Unlock()
+	fake.AssignMultiStemcellStub = nil
+	if fake.assignMultiStemcellReturnsOnCall == nil {
+		fake.assignMultiStemcellReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.assignMultiStemcellReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *BundleUploadService) CheckProductAvailability(arg1 string, arg2 string) (bool, error) {
+	fake.checkProductAvailabilityMutex.Lock()
+	ret, specificReturn := fake.checkProductAvailabilityReturnsOnCall[len(fake.checkProductAvailabilityArgsForCall)]
+	fake.checkProductAvailabilityArgsForCall = append(fake.checkProductAvailabilityArgsForCall, struct {
+		arg1 string
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.CheckProductAvailabilityStub
+	fakeReturns := fake.checkProductAvailabilityReturns
+	fake.recordInvocation("CheckProductAvailability", []interface{}{arg1, arg2})
+	fake.checkProductAvailabilityMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *BundleUploadService) CheckProductAvailabilityCallCount() int {
+	fake.checkProductAvailabilityMutex.RLock()
+	defer fake.checkProductAvailabilityMutex.RUnlock()
+	return len(fake.checkProductAvailabilityArgsForCall)
+}
+
+func (fake *BundleUploadService) CheckProductAvailabilityCalls(stub func(string, string) (bool, error)) {
+	fake.checkProductAvailabilityMutex.Lock()
+	defer fake.checkProductAvailabilityMutex.Unlock()
+	fake.CheckProductAvailabilityStub = stub
+}
+
+func (fake *BundleUploadService) CheckProductAvailabilityArgsForCall(i int) (string, string) {
+	fake.checkProductAvailabilityMutex.RLock()
+	defer fake.checkProductAvailabilityMutex.RUnlock()
+	argsForCall := fake.checkProductAvailabilityArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *BundleUploadService) CheckProductAvailabilityReturns(result1 bool, result2 error) {
+	fake.checkProductAvailabilityMutex.Lock()
+	defer fake.checkProductAvailabilityMutex.Unlock()
+	fake.CheckProductAvailabilityStub = nil
+	fake.checkProductAvailabilityReturns = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *BundleUploadService) CheckProductAvailabilityReturnsOnCall(i int, result1 bool, result2 error) {
+	fake.checkProductAvailabilityMutex.Lock()
+	defer fake.checkProductAvailabilityMutex.Unlock()
+	fake.CheckProductAvailabilityStub = nil
+	if fake.checkProductAvailabilityReturnsOnCall == nil {
+		fake.checkProductAvailabilityReturnsOnCall = make(map[int]struct {
+			result1 bool
+			result2 error
+		})
+	}
+	fake.checkProductAvailabilityReturnsOnCall[i] = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *BundleUploadService) CheckStemcellAvailability(arg1 string) (bool, error) {
+	fake.checkStemcellAvailabilityMutex.Lock()
+	ret, specificReturn := fake.checkStemcellAvailabilityReturnsOnCall[len(fake.checkStemcellAvailabilityArgsForCall)]
+	fake.checkStemcellAvailabilityArgsForCall = append(fake.checkStemcellAvailabilityArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.CheckStemcellAvailabilityStub
+	fakeReturns := fake.checkStemcellAvailabilityReturns
+	fake.recordInvocation("CheckStemcellAvailability", []interface{}{arg1})
+	fake.checkStemcellAvailabilityMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *BundleUploadService) CheckStemcellAvailabilityCallCount() int {
+	fake.checkStemcellAvailabilityMutex.RLock()
+	defer fake.checkStemcellAvailabilityMutex.RUnlock()
+	return len(fake.checkStemcellAvailabilityArgsForCall)
+}
+
+func (fake *BundleUploadService) CheckStemcellAvailabilityCalls(stub func(string) (bool, error)) {
+	fake.checkStemcellAvailabilityMutex.Lock()
+	defer fake.checkStemcellAvailabilityMutex.Unlock()
+	fake.CheckStemcellAvailabilityStub = stub
+}
+
+func (fake *BundleUploadService) CheckStemcellAvailabilityArgsForCall(i int) string {
+	fake.checkStemcellAvailabilityMutex.RLock()
+	defer fake.checkStemcellAvailabilityMutex.RUnlock()
+	argsForCall := fake.checkStemcellAvailabilityArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *BundleUploadService) CheckStemcellAvailabilityReturns(result1 bool, result2 error) {
+	fake.checkStemcellAvailabilityMutex.Lock()
+	defer fake.checkStemcellAvailabilityMutex.Unlock()
+	fake.CheckStemcellAvailabilityStub = nil
+	fake.checkStemcellAvailabilityReturns = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *BundleUploadService) CheckStemcellAvailabilityReturnsOnCall(i int, result1 bool, result2 error) {
+	fake.checkStemcellAvailabilityMutex.Lock()
+	defer fake.checkStemcellAvailabilityMutex.Unlock()
+	fake.CheckStemcellAvailabilityStub = nil
+	if fake.checkStemcellAvailabilityReturnsOnCall == nil {
+		fake.checkStemcellAvailabilityReturnsOnCall = make(map[int]struct {
+			result1 bool
+			result2 error
+		})
+	}
+	fake.checkStemcellAvailabilityReturnsOnCall[i] = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *BundleUploadService) GetDiagnosticReport() (api.DiagnosticReport, error) {
+	fake.getDiagnosticReportMutex.Lock()
+	ret, specificReturn := fake.getDiagnosticReportReturnsOnCall[len(fake.getDiagnosticReportArgsForCall)]
+	fake.getDiagnosticReportArgsForCall = append(fake.getDiagnosticReportArgsForCall, struct {
+	}{})
+	stub := fake.GetDiagnosticReportStub
+	fakeReturns := fake.getDiagnosticReportReturns
+	fake.recordInvocation("GetDiagnosticReport", []interface{}{})
+	fake.getDiagnosticReportMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *BundleUploadService) GetDiagnosticReportCallCount() int {
+	fake.getDiagnosticReportMutex.RLock()
+	defer fake.getDiagnosticReportMutex.RUnlock()
+	return len(fake.getDiagnosticReportArgsForCall)
+}
+
+func (fake *BundleUploadService) GetDiagnosticReportCalls(stub func() (api.DiagnosticReport, error)) {
+	fake.getDiagnosticReportMutex.Lock()
+	defer fake.getDiagnosticReportMutex.Unlock()
+	fake.GetDiagnosticReportStub = stub
+}
+
+func (fake *BundleUploadService) GetDiagnosticReportReturns(result1 api.DiagnosticReport, result2 error) {
+	fake.getDiagnosticReportMutex.Lock()
+	defer fake.getDiagnosticReportMutex.Unlock()
+	fake.GetDiagnosticReportStub = nil
+	fake.getDiagnosticReportReturns = struct {
+		result1 api.DiagnosticReport
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *BundleUploadService) GetDiagnosticReportReturnsOnCall(i int, result1 api.DiagnosticReport, result2 error) {
+	fake.getDiagnosticReportMutex.Lock()
+	defer fake.getDiagnosticReportMutex.Unlock()
+	fake.GetDiagnosticReportStub = nil
+	if fake.getDiagnosticReportReturnsOnCall == nil {
+		fake.getDiagnosticReportReturnsOnCall = make(map[int]struct {
+			result1 api.DiagnosticReport
+			result2 error
+		})
+	}
+	fake.getDiagnosticReportReturnsOnCall[i] = struct {
+		result1 api.DiagnosticReport
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *BundleUploadService) Info() (api.Info, error) {
+	fake.infoMutex.Lock()
+	ret, specificReturn := fake.infoReturnsOnCall[len(fake.infoArgsForCall)]
+	fake.infoArgsForCall = append(fake.infoArgsForCall, struct {
+	}{})
+	stub := fake.InfoStub
+	fakeReturns := fake.infoReturns
+	fake.recordInvocation("Info", []interface{}{})
+	fake.infoMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *BundleUploadService) InfoCallCount() int {
+	fake.infoMutex.RLock()
+	defer fake.infoMutex.RUnlock()
+	return len(fake.infoArgsForCall)
+}
+
+func (fake *BundleUploadService) InfoCalls(stub func() (api.Info, error)) {
+	fake.infoMutex.Lock()
+	defer fake.infoMutex.Unlock()
+	fake.InfoStub = stub
+}
+
+func (fake *BundleUploadService) InfoReturns(result1 api.Info, result2 error) {
+	fake.infoMutex.Lock()
+	defer fake.infoMutex.Unlock()
+	fake.InfoStub = nil
+	fake.infoReturns = struct {
+		result1 api.Info
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *BundleUploadService) InfoReturnsOnCall(i int, result1 api.Info, result2 error) {
+	fake.infoMutex.Lock()
+	defer fake.infoMutex.Unlock()
+	fake.InfoStub = nil
+	if fake.infoReturnsOnCall == nil {
+		fake.infoReturnsOnCall = make(map[int]struct {
+			result1 api.Info
+			result2 error
+		})
+	}
+	fake.infoReturnsOnCall[i] = struct {
+		result1 api.Info
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *BundleUploadService) ListMultiStemcells() (api.ProductMultiStemcells, error) {
+	fake.listMultiStemcellsMutex.Lock()
+	ret, specificReturn := fake.listMultiStemcellsReturnsOnCall[len(fake.listMultiStemcellsArgsForCall)]
+	fake.listMultiStemcellsArgsForCall = append(fake.listMultiStemcellsArgsForCall, struct {
+	}{})
+	stub := fake.ListMultiStemcellsStub
+	fakeReturns := fake.listMultiStemcellsReturns
+	fake.recordInvocation("ListMultiStemcells", []interface{}{})
+	fake.listMultiStemcellsMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *BundleUploadService) ListMultiStemcellsCallCount() int {
+	fake.listMultiStemcellsMutex.RLock()
+	defer fake.listMultiStemcellsMutex.RUnlock()
+	return len(fake.listMultiStemcellsArgsForCall)
+}
+
+func (fake *BundleUploadService) ListMultiStemcellsCalls(stub func() (api.ProductMultiStemcells, error)) {
+	fake.listMultiStemcellsMutex.Lock()
+	defer fake.listMultiStemcellsMutex.Unlock()
+	fake.ListMultiStemcellsStub = stub
+}
+
+func (fake *BundleUploadService) ListMultiStemcellsReturns(result1 api.ProductMultiStemcells, result2 error) {
+	fake.listMultiStemcellsMutex.Lock()
+	defer fake.listMultiStemcellsMutex.Unlock()
+	fake.ListMultiStemcellsStub = nil
+	fake.listMultiStemcellsReturns = struct {
+		result1 api.ProductMultiStemcells
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *BundleUploadService) ListMultiStemcellsReturnsOnCall(i int, result1 api.ProductMultiStemcells, result2 error) {
+	fake.listMultiStemcellsMutex.Lock()
+	defer fake.listMultiStemcellsMutex.Unlock()
+	fake.ListMultiStemcellsStub = nil
+	if fake.listMultiStemcellsReturnsOnCall == nil {
+		fake.listMultiStemcellsReturnsOnCall = make(map[int]struct {
+			result1 api.ProductMultiStemcells
+			result2 error
+		})
+	}
+	fake.listMultiStemcellsReturnsOnCall[i] = struct {
+		result1 api.ProductMultiStemcells
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *BundleUploadService) UploadAvailableProduct(arg1 api.UploadAvailableProductInput) (api.UploadAvailableProductOutput, error) {
+	fake.uploadAvailableProductMutex.Lock()
+	ret, specificReturn := fake.uploadAvailableProductReturnsOnCall[len(fake.uploadAvailableProductArgsForCall)]
+	fake.uploadAvailableProductArgsForCall = append(fake.uploadAvailableProductArgsForCall, struct {
+		arg1 api.UploadAvailableProductInput
+	}{arg1})
+	stub := fake.UploadAvailableProductStub
+	fakeReturns := fake.uploadAvailableProductReturns
+	fake.recordInvocation("UploadAvailableProduct", []interface{}{arg1})
+	fake.uploadAvailableProductMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *BundleUploadService) UploadAvailableProductCallCount() int {
+	fake.uploadAvailableProductMutex.RLock()
+	defer fake.uploadAvailableProductMutex.RUnlock()
+	return len(fake.uploadAvailableProductArgsForCall)
+}
+
+func (fake *BundleUploadService) UploadAvailableProductCalls(stub func(api.UploadAvailableProductInput) (api.UploadAvailableProductOutput, error)) {
+	fake.uploadAvailableProductMutex.Lock()
+	defer fake.uploadAvailableProductMutex.Unlock()
+	fake.UploadAvailableProductStub = stub
+}
+
+func (fake *BundleUploadService) UploadAvailableProductArgsForCall(i int) api.UploadAvailableProductInput {
+	fake.uploadAvailableProductMutex.RLock()
+	defer fake.uploadAvailableProductMutex.RUnlock()
+	argsForCall := fake.uploadAvailableProductArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *BundleUploadService) UploadAvailableProductReturns(result1 api.UploadAvailableProductOutput, result2 error) {
+	fake.uploadAvailableProductMutex.Lock()
+	defer fake.uploadAvailableProductMutex.Unlock()
+	fake.UploadAvailableProductStub = nil
+	fake.uploadAvailableProductReturns = struct {
+		result1 api.UploadAvailableProductOutput
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *BundleUploadService) UploadAvailableProductReturnsOnCall(i int, result1 api.UploadAvailableProductOutput, result2 error) {
+	fake.uploadAvailableProductMutex.Lock()
+	defer fake.uploadAvailableProductMutex.Unlock()
+	fake.UploadAvailableProductStub = nil
+	if fake.uploadAvailableProductReturnsOnCall == nil {
+		fake.uploadAvailableProductReturnsOnCall = make(map[int]struct {
+			result1 api.UploadAvailableProductOutput
+			result2 error
+		})
+	}
+	fake.uploadAvailableProductReturnsOnCall[i] = struct {
+		result1 api.UploadAvailableProductOutput
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *BundleUploadService) UploadStemcell(arg1 api.StemcellUploadInput) (api.StemcellUploadOutput, error) {
+	fake.uploadStemcellMutex.Lock()
+	ret, specificReturn := fake.uploadStemcellReturnsOnCall[len(fake.uploadStemcellArgsForCall)]
+	fake.uploadStemcellArgsForCall = append(fake.uploadStemcellArgsForCall, struct {
+		arg1 api.StemcellUploadInput
+	}{arg1})
+	stub := fake.UploadStemcellStub
+	fakeReturns := fake.uploadStemcellReturns
+	fake.recordInvocation("UploadStemcell", []interface{}{arg1})
+	fake.uploadStemcellMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *BundleUploadService) UploadStemcellCallCount() int {
+	fake.uploadStemcellMutex.RLock()
+	defer fake.uploadStemcellMutex.RUnlock()
+	return len(fake.uploadStemcellArgsForCall)
+}
+
+func (fake *BundleUploadService) UploadStemcellCalls(stub func(api.StemcellUploadInput) (api.StemcellUploadOutput, error)) {
+	fake.uploadStemcellMutex.Lock()
+	defer fake.uploadStemcellMutex.Unlock()
+	fake.UploadStemcellStub = stub
+}
+
+func (fake *BundleUploadService) UploadStemcellArgsForCall(i int) api.StemcellUploadInput {
+	fake.uploadStemcellMutex.RLock()
+	defer fake.uploadStemcellMutex.RUnlock()
+	argsForCall := fake.uploadStemcellArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *BundleUploadService) UploadStemcellReturns(result1 api.StemcellUploadOutput, result2 error) {
+	fake.uploadStemcellMutex.Lock()
+	defer fake.uploadStemcellMutex.Unlock()
+	fake.UploadStemcellStub = nil
+	fake.uploadStemcellReturns = struct {
+		result1 api.StemcellUploadOutput
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *BundleUploadService) UploadStemcellReturnsOnCall(i int, result1 api.StemcellUploadOutput, result2 error) {
+	fake.uploadStemcellMutex.Lock()
+	defer fake.uploadStemcellMutex.Unlock()
+	fake.UploadStemcellStub = nil
+	if fake.uploadStemcellReturnsOnCall == nil {
+		fake.uploadStemcellReturnsOnCall = make(map[int]struct {
+			result1 api.StemcellUploadOutput
+			result2 error
+		})
+	}
+	fake.uploadStemcellReturnsOnCall[i] = struct {
+		result1 api.StemcellUploadOutput
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *BundleUploadService) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *BundleUploadService) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
@@ -0,0 +1,255 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package fakes
+
+import (
+	"sync"
+)
+
+type EnvContexts struct {
+	ListStub        func() ([]string, string, error)
+	listMutex       sync.RWMutex
+	listArgsForCall []struct {
+	}
+	listReturns struct {
+		result1 []string
+		result2 string
+		result3 error
+	}
+	listReturnsOnCall map[int]struct {
+		result1 []string
+		result2 string
+		result3 error
+	}
+	ShowStub        func(string) ([]byte, error)
+	showMutex       sync.RWMutex
+	showArgsForCall []struct {
+		arg1 string
+	}
+	showReturns struct {
+		result1 []byte
+		result2 error
+	}
+	showReturnsOnCall map[int]struct {
+		result1 []byte
+		result2 error
+	}
+	UseStub        func(string) error
+	useMutex       sync.RWMutex
+	useArgsForCall []struct {
+		arg1 string
+	}
+	useReturns struct {
+		result1 error
+	}
+	useReturnsOnCall map[int]struct {
+		result1 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *EnvContexts) List() ([]string, string, error) {
+	fake.listMutex.Lock()
+	ret, specificReturn := fake.listReturnsOnCall[len(fake.listArgsForCall)]
+	fake.listArgsForCall = append(fake.listArgsForCall, struct {
+	}{})
+	stub := fake.ListStub
+	fakeReturns := fake.listReturns
+	fake.recordInvocation("List", []interface{}{})
+	fake.listMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2, ret.result3
+	}
+	return fakeReturns.result1, fakeReturns.result2, fakeReturns.result3
+}
+
+func (fake *EnvContexts) ListCallCount() int {
+	fake.listMutex.RLock()
+	defer fake.listMutex.RUnlock()
+	return len(fake.listArgsForCall)
+}
+
+func (fake *EnvContexts) ListCalls(stub func() ([]string, string, error)) {
+	fake.listMutex.Lock()
+	defer fake.listMutex.Unlock()
+	fake.ListStub = stub
+}
+
+func (fake *EnvContexts) ListReturns(result1 []string, result2 string, result3 error) {
+	fake.listMutex.Lock()
+	defer fake.listMutex.Unlock()
+	fake.ListStub = nil
+	fake.listReturns = struct {
+		result1 []string
+		result2 string
+		result3 error
+	}{result1, result2, result3}
+}
+
+func (fake *EnvContexts) ListReturnsOnCall(i int, result1 []string, result2 string, result3 error) {
+	fake.listMutex.Lock()
+	defer fake.listMutex.Unlock()
+	fake.ListStub = nil
+	if fake.listReturnsOnCall == nil {
+		fake.listReturnsOnCall = make(map[int]struct {
+			result1 []string
+			result2 string
+			result3 error
+		})
+	}
+	fake.listReturnsOnCall[i] = struct {
+		result1 []string
+		result2 string
+		result3 error
+	}{result1, result2, result3}
+}
+
+func (fake *EnvContexts) Show(arg1 string) ([]byte, error) {
+	fake.showMutex.Lock()
+	ret, specificReturn := fake.showReturnsOnCall[len(fake.showArgsForCall)]
+	fake.showArgsForCall = append(fake.showArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.ShowStub
+	fakeReturns := fake.showReturns
+	fake.recordInvocation("Show", []interface{}{arg1})
+	fake.showMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *EnvContexts) ShowCallCount() int {
+	fake.showMutex.RLock()
+	defer fake.showMutex.RUnlock()
+	return len(fake.showArgsForCall)
+}
+
+func (fake *EnvContexts) ShowCalls(stub func(string) ([]byte, error)) {
+	fake.showMutex.Lock()
+	defer fake.showMutex.Unlock()
+	fake.ShowStub = stub
+}
+
+func (fake *EnvContexts) ShowArgsForCall(i int) string {
+	fake.showMutex.RLock()
+	defer fake.showMutex.RUnlock()
+	argsForCall := fake.showArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *EnvContexts) ShowReturns(result1 []byte, result2 error) {
+	fake.showMutex.Lock()
+	defer fake.showMutex.Unlock()
+	fake.ShowStub = nil
+	fake.showReturns = struct {
+		result1 []byte
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *EnvContexts) ShowReturnsOnCall(i int, result1 []byte, result2 error) {
+	fake.showMutex.Lock()
+	defer fake.showMutex.Unlock()
+	fake.ShowStub = nil
+	if fake.showReturnsOnCall == nil {
+		fake.showReturnsOnCall = make(map[int]struct {
+			result1 []byte
+			result2 error
+		})
+	}
+	fake.showReturnsOnCall[i] = struct {
+		result1 []byte
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *EnvContexts) Use(arg1 string) error {
+	fake.useMutex.Lock()
+	ret, specificReturn := fake.useReturnsOnCall[len(fake.useArgsForCall)]
+	fake.useArgsForCall = append(fake.useArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.UseStub
+	fakeReturns := fake.useReturns
+	fake.recordInvocation("Use", []interface{}{arg1})
+	fake.useMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *EnvContexts) UseCallCount() int {
+	fake.useMutex.RLock()
+	defer fake.useMutex.RUnlock()
+	return len(fake.useArgsForCall)
+}
+
+func (fake *EnvContexts) UseCalls(stub func(string) error) {
+	fake.useMutex.Lock()
+	defer fake.useMutex.Unlock()
+	fake.UseStub = stub
+}
+
+func (fake *EnvContexts) UseArgsForCall(i int) string {
+	fake.useMutex.RLock()
+	defer fake.useMutex.RUnlock()
+	argsForCall := fake.useArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *EnvContexts) UseReturns(result1 error) {
+	fake.useMutex.Lock()
+	defer fake.useMutex.Unlock()
+	fake.UseStub = nil
+	fake.useReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *EnvContexts) UseReturnsOnCall(i int, result1 error) {
+	fake.useMutex.Lock()
+	defer fake.useMutex.Unlock()
+	fake.UseStub = nil
+	if fake.useReturnsOnCall == nil {
+		fake.useReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.useReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *EnvContexts) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *EnvContexts) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
@@ -0,0 +1,173 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package fakes
+
+import (
+	"sync"
+)
+
+type PasscodeAuthenticator struct {
+	LoginWithPasscodeStub        func(string) error
+	loginWithPasscodeMutex       sync.RWMutex
+	loginWithPasscodeArgsForCall []struct {
+		arg1 string
+	}
+	loginWithPasscodeReturns struct {
+		result1 error
+	}
+	loginWithPasscodeReturnsOnCall map[int]struct {
+		result1 error
+	}
+	PasscodeURLStub        func() (string, error)
+	passcodeURLMutex       sync.RWMutex
+	passcodeURLArgsForCall []struct {
+	}
+	passcodeURLReturns struct {
+		result1 string
+		result2 error
+	}
+	passcodeURLReturnsOnCall map[int]struct {
+		result1 string
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *PasscodeAuthenticator) LoginWithPasscode(arg1 string) error {
+	fake.loginWithPasscodeMutex.Lock()
+	ret, specificReturn := fake.loginWithPasscodeReturnsOnCall[len(fake.loginWithPasscodeArgsForCall)]
+	fake.loginWithPasscodeArgsForCall = append(fake.loginWithPasscodeArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.LoginWithPasscodeStub
+	fakeReturns := fake.loginWithPasscodeReturns
+	fake.recordInvocation("LoginWithPasscode", []interface{}{arg1})
+	fake.loginWithPasscodeMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *PasscodeAuthenticator) LoginWithPasscodeCallCount() int {
+	fake.loginWithPasscodeMutex.RLock()
+	defer fake.loginWithPasscodeMutex.RUnlock()
+	return len(fake.loginWithPasscodeArgsForCall)
+}
+
+func (fake *PasscodeAuthenticator) LoginWithPasscodeCalls(stub func(string) error) {
+	fake.loginWithPasscodeMutex.Lock()
+	defer fake.loginWithPasscodeMutex.Unlock()
+	fake.LoginWithPasscodeStub = stub
+}
+
+func (fake *PasscodeAuthenticator) LoginWithPasscodeArgsForCall(i int) string {
+	fake.loginWithPasscodeMutex.RLock()
+	defer fake.loginWithPasscodeMutex.RUnlock()
+	argsForCall := fake.loginWithPasscodeArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *PasscodeAuthenticator) LoginWithPasscodeReturns(result1 error) {
+	fake.loginWithPasscodeMutex.Lock()
+	defer fake.loginWithPasscodeMutex.Unlock()
+	fake.LoginWithPasscodeStub = nil
+	fake.loginWithPasscodeReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *PasscodeAuthenticator) LoginWithPasscodeReturnsOnCall(i int, result1 error) {
+	fake.loginWithPasscodeMutex.Lock()
+	defer fake.loginWithPasscodeMutex.Unlock()
+	fake.LoginWithPasscodeStub = nil
+	if fake.loginWithPasscodeReturnsOnCall == nil {
+		fake.loginWithPasscodeReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.loginWithPasscodeReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *PasscodeAuthenticator) PasscodeURL() (string, error) {
+	fake.passcodeURLMutex.Lock()
+	ret, specificReturn := fake.passcodeURLReturnsOnCall[len(fake.passcodeURLArgsForCall)]
+	fake.passcodeURLArgsForCall = append(fake.passcodeURLArgsForCall, struct {
+	}{})
+	stub := fake.PasscodeURLStub
+	fakeReturns := fake.passcodeURLReturns
+	fake.recordInvocation("PasscodeURL", []interface{}{})
+	fake.passcodeURLMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *PasscodeAuthenticator) PasscodeURLCallCount() int {
+	fake.passcodeURLMutex.RLock()
+	defer fake.passcodeURLMutex.RUnlock()
+	return len(fake.passcodeURLArgsForCall)
+}
+
+func (fake *PasscodeAuthenticator) PasscodeURLCalls(stub func() (string, error)) {
+	fake.passcodeURLMutex.Lock()
+	defer fake.passcodeURLMutex.Unlock()
+	fake.PasscodeURLStub = stub
+}
+
+func (fake *PasscodeAuthenticator) PasscodeURLReturns(result1 string, result2 error) {
+	fake.passcodeURLMutex.Lock()
+	defer fake.passcodeURLMutex.Unlock()
+	fake.PasscodeURLStub = nil
+	fake.passcodeURLReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *PasscodeAuthenticator) PasscodeURLReturnsOnCall(i int, result1 string, result2 error) {
+	fake.passcodeURLMutex.Lock()
+	defer fake.passcodeURLMutex.Unlock()
+	fake.PasscodeURLStub = nil
+	if fake.passcodeURLReturnsOnCall == nil {
+		fake.passcodeURLReturnsOnCall = make(map[int]struct {
+			result1 string
+			result2 error
+		})
+	}
+	fake.passcodeURLReturnsOnCall[i] = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *PasscodeAuthenticator) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *PasscodeAuthenticator) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
@@ -0,0 +1,133 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/pivotal-cf/om/extractor"
+	"gopkg.in/yaml.v2"
+)
+
+type productInspector interface {
+	InspectFile(productPath string, releaseSHA1s bool) (*extractor.Inspection, error)
+	InspectURL(productURL string, releaseSHA1s bool) (*extractor.Inspection, error)
+}
+
+type InspectProduct struct {
+	inspector productInspector
+	stdout    io.Writer
+	Options   struct {
+		ProductPath  string `long:"product-path"  short:"p" required:"true"  description:"path or http(s) URL of the product file"`
+		Format       string `long:"format"        short:"f" default:"table"  description:"Format to print as (options: table,json,yaml)"`
+		ReleaseSHA1s bool   `long:"release-sha1s"                            description:"compute the SHA1 of bundled releases when the metadata does not provide one; this reads the whole product file"`
+	}
+}
+
+func NewInspectProduct(inspector productInspector, stdout io.Writer) *InspectProduct {
+	return &InspectProduct{
+		inspector: inspector,
+		stdout:    stdout,
+	}
+}
+
+func (ip InspectProduct) Execute(_ []string) error {
+	switch ip.Options.Format {
+	case "table", "json", "yaml":
+	default:
+		return fmt.Errorf("unsupported format %q: supported formats are table, json and yaml", ip.Options.Format)
+	}
+
+	inspection, err := inspectProductPath(ip.inspector, ip.Options.ProductPath, ip.Options.ReleaseSHA1s)
+	if err != nil {
+		return err
+	}
+
+	switch ip.Options.Format {
+	case "json":
+		encoder := json.NewEncoder(ip.stdout)
+		encoder.SetIndent("", "  ")
+
+		if err := encoder.Encode(inspection); err != nil {
+			return fmt.Errorf("failed to encode JSON: %w", err)
+		}
+	case "yaml":
+		contents, err := yaml.Marshal(inspection)
+		if err != nil {
+			return fmt.Errorf("failed to encode YAML: %w", err)
+		}
+
+		_, err = ip.stdout.Write(contents)
+		if err != nil {
+			return err
+		}
+	default:
+		ip.renderTable(inspection)
+	}
+
+	return nil
+}
+
+func (ip InspectProduct) renderTable(inspection *extractor.Inspection) {
+	ip.renderSection("Product", []string{"Name", "Version", "Minimum Ops Manager Version"},
+		[][]string{{inspection.Name, inspection.Version, inspection.MinimumOpsManagerVersion}})
+
+	var rows [][]string
+	for _, criterion := range inspection.StemcellCriteria {
+		rows = append(rows, []string{criterion.OS, criterion.Version, strconv.FormatBool(criterion.PatchSecurityUpdates)})
+	}
+	ip.renderSection("Stemcells", []string{"OS", "Version", "Patch Security Updates"}, rows)
+
+	rows = nil
+	for _, release := range inspection.Releases {
+		rows = append(rows, []string{release.Name, release.Version, release.File, release.SHA1})
+	}
+	ip.renderSection("Releases", []string{"Name", "Version", "File", "SHA1"}, rows)
+
+	rows = nil
+	for _, product := range inspection.RequiredProducts {
+		rows = append(rows, []string{product.Name, product.Version})
+	}
+	ip.renderSection("Required Products", []string{"Name", "Version"}, rows)
+
+	rows = nil
+	for _, runtimeConfig := range inspection.RuntimeConfigs {
+		rows = append(rows, []string{runtimeConfig})
+	}
+	ip.renderSection("Runtime Configs", []string{"Name"}, rows)
+
+	rows = nil
+	for _, jobType := range inspection.JobTypes {
+		instances := ""
+		if jobType.DefaultInstances != nil {
+			instances = strconv.Itoa(*jobType.DefaultInstances)
+		}
+		rows = append(rows, []string{jobType.Name, jobType.Label, instances, strconv.FormatBool(jobType.Errand)})
+	}
+	ip.renderSection("Job Types", []string{"Name", "Label", "Default Instances", "Errand"}, rows)
+
+	rows = nil
+	for _, errand := range inspection.Errands {
+		rows = append(rows, []string{errand.Name, errand.Phase})
+	}
+	ip.renderSection("Errands", []string{"Name", "Phase"}, rows)
+}
+
+func (ip InspectProduct) renderSection(title string, header []string, rows [][]string) {
+	fmt.Fprintf(ip.stdout, "%s:\n", title)
+	if len(rows) == 0 {
+		fmt.Fprintln(ip.stdout, "  none")
+		fmt.Fprintln(ip.stdout)
+		return
+	}
+
+	table := tablewriter.NewWriter(ip.stdout)
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.SetAutoWrapText(false)
+	table.SetHeader(header)
+	table.AppendBulk(rows)
+	table.Render()
+	fmt.Fprintln(ip.stdout)
+}
@@ -0,0 +1,123 @@
+package commands_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/pivotal-cf/om/commands"
+	"github.com/pivotal-cf/om/extractor"
+)
+
+var _ = Describe("InspectProduct", func() {
+	var (
+		stdout      *bytes.Buffer
+		command     *commands.InspectProduct
+		productPath string
+	)
+
+	BeforeEach(func() {
+		productPath = filepath.Join(GinkgoT().TempDir(), "product.pivotal")
+		file, err := os.Create(productPath)
+		Expect(err).ToNot(HaveOccurred())
+		defer file.Close()
+
+		z := zip.NewWriter(file)
+		f, err := z.Create("metadata/some-product.yml")
+		Expect(err).ToNot(HaveOccurred())
+		_, err = f.Write([]byte(`---
+name: some-product
+product_version: 1.2.3
+metadata_version: "3.0"
+stemcell_criteria:
+  os: ubuntu-jammy
+  version: "1.100"
+releases:
+- name: some-release
+  version: 4.5.6
+  file: some-release-4.5.6.tgz
+  sha1: abc123
+requires_product_versions:
+- name: cf
+  version: ~> 2.0
+job_types:
+- name: web
+  resource_label: Web Server
+  instance_definition:
+    default: 2
+post_deploy_errands:
+- name: smoke-tests
+`))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(z.Close()).To(Succeed())
+
+		stdout = &bytes.Buffer{}
+		command = commands.NewInspectProduct(extractor.NewMetadataExtractor(), stdout)
+	})
+
+	It("prints a table per section of the product", func() {
+		err := executeCommand(command, []string{"--product-path", productPath})
+		Expect(err).ToNot(HaveOccurred())
+
+		output := stdout.String()
+		Expect(output).To(ContainSubstring("Product:"))
+		Expect(output).To(MatchRegexp(`some-product\s+\|\s+1\.2\.3\s+\|\s+3\.0`))
+		Expect(output).To(MatchRegexp(`ubuntu-jammy\s+\|\s+1\.100`))
+		Expect(output).To(MatchRegexp(`some-release\s+\|\s+4\.5\.6\s+\|\s+some-release-4\.5\.6\.tgz\s+\|\s+abc123`))
+		Expect(output).To(MatchRegexp(`cf\s+\|\s+~> 2\.0`))
+		Expect(output).To(MatchRegexp(`web\s+\|\s+Web Server\s+\|\s+2\s+\|\s+false`))
+		Expect(output).To(MatchRegexp(`smoke-tests\s+\|\s+post-deploy`))
+		Expect(output).To(ContainSubstring("Runtime Configs:\n  none"))
+	})
+
+	It("prints the inspection as JSON", func() {
+		err := executeCommand(command, []string{"--product-path", productPath, "--format", "json"})
+		Expect(err).ToNot(HaveOccurred())
+
+		var inspection extractor.Inspection
+		Expect(json.Unmarshal(stdout.Bytes(), &inspection)).To(Succeed())
+		Expect(inspection.Name).To(Equal("some-product"))
+		Expect(inspection.RequiredProducts).To(Equal([]extractor.RequiredProduct{{Name: "cf", Version: "~> 2.0"}}))
+		Expect(*inspection.JobTypes[0].DefaultInstances).To(Equal(2))
+	})
+
+	It("prints the inspection as YAML", func() {
+		err := executeCommand(command, []string{"--product-path", productPath, "--format", "yaml"})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(stdout.String()).To(ContainSubstring("minimum_ops_manager_version: \"3.0\""))
+		Expect(stdout.String()).To(ContainSubstring("- os: ubuntu-jammy"))
+	})
+
+	It("inspects a product served over http", func() {
+		contents, err := os.ReadFile(productPath)
+		Expect(err).ToNot(HaveOccurred())
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.ServeContent(w, r, "product.pivotal", time.Now(), bytes.NewReader(contents))
+		}))
+		defer server.Close()
+
+		err = executeCommand(command, []string{"--product-path", server.URL + "/product.pivotal", "--format", "json"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(stdout.String()).To(ContainSubstring(`"name": "some-product"`))
+	})
+
+	It("errors on an unsupported format", func() {
+		err := executeCommand(command, []string{"--product-path", productPath, "--format", "csv"})
+		Expect(err).To(MatchError(`unsupported format "csv": supported formats are table, json and yaml`))
+	})
+
+	It("errors when the product cannot be inspected", func() {
+		err := executeCommand(command, []string{"--product-path", "/does/not/exist.pivotal"})
+		Expect(err).To(MatchError(ContainSubstring("failed to inspect product /does/not/exist.pivotal")))
+	})
+})
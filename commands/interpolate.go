@@ -22,6 +22,7 @@ type interpolateConfigFileOptions struct {
 	VarsEnv    []string `long:"vars-env" env:"OM_VARS_ENV"           description:"load variables from environment variables matching the provided prefix (e.g.: 'MY' to load MY_var=value)"`
 	VarsFile   []string `long:"vars-file"                  short:"l" description:"load variables from a YAML file"`
 	Vars       []string `long:"var"                        short:"v" description:"load variable from the command line. Format: VAR=VAL"`
+	OpsFile    []string `long:"ops-file"                             description:"YAML operations files applied to the config file"`
 }
 
 func (*interpolateConfigFileOptions) UnmarshalFlag(value string) error {
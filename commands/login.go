@@ -0,0 +1,65 @@
+package commands
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+//counterfeiter:generate -o ./fakes/passcode_authenticator.go --fake-name PasscodeAuthenticator . passcodeAuthenticator
+type passcodeAuthenticator interface {
+	PasscodeURL() (string, error)
+	LoginWithPasscode(passcode string) error
+}
+
+type Login struct {
+	authenticator passcodeAuthenticator
+	stdin         io.Reader
+	logger        logger
+	Options       struct {
+		SSO      bool   `long:"sso"      description:"log in with a one-time passcode from UAA, for Ops Managers that authenticate users with SAML"`
+		Passcode string `long:"passcode" description:"the one-time passcode, which is prompted for when not provided"`
+	}
+}
+
+func NewLogin(authenticator passcodeAuthenticator, stdin io.Reader, logger logger) *Login {
+	return &Login{
+		authenticator: authenticator,
+		stdin:         stdin,
+		logger:        logger,
+	}
+}
+
+func (l Login) Execute(_ []string) error {
+	if !l.Options.SSO {
+		return errors.New("--sso is required: other commands authenticate with --username and --password, or --client-id and --client-secret")
+	}
+
+	passcode := l.Options.Passcode
+	if passcode == "" {
+		passcodeURL, err := l.authenticator.PasscodeURL()
+		if err != nil {
+			return err
+		}
+
+		l.logger.Printf("Sign in at %s to get a one-time passcode.", passcodeURL)
+		l.logger.Printf("Passcode: ")
+
+		scanner := bufio.NewScanner(l.stdin)
+		scanner.Scan()
+		passcode = strings.TrimSpace(scanner.Text())
+		if passcode == "" {
+			return errors.New("no passcode was provided")
+		}
+	}
+
+	err := l.authenticator.LoginWithPasscode(passcode)
+	if err != nil {
+		return fmt.Errorf("failed to log in: %w", err)
+	}
+
+	l.logger.Printf("Logged in. Commands run without credentials use this session until it expires, or until om logout.")
+	return nil
+}
@@ -0,0 +1,87 @@
+package commands_test
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/pivotal-cf/om/commands"
+	"github.com/pivotal-cf/om/commands/fakes"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Login", func() {
+	var (
+		authenticator *fakes.PasscodeAuthenticator
+		logger        *fakes.Logger
+	)
+
+	BeforeEach(func() {
+		authenticator = &fakes.PasscodeAuthenticator{}
+		authenticator.PasscodeURLReturns("https://opsman.example.com/uaa/passcode", nil)
+		logger = &fakes.Logger{}
+	})
+
+	It("prompts for a passcode and logs in with it", func() {
+		command := commands.NewLogin(authenticator, strings.NewReader("some-passcode\n"), logger)
+
+		err := executeCommand(command, []string{"--sso"})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(authenticator.LoginWithPasscodeCallCount()).To(Equal(1))
+		Expect(authenticator.LoginWithPasscodeArgsForCall(0)).To(Equal("some-passcode"))
+
+		format, args := logger.PrintfArgsForCall(0)
+		Expect(format).To(Equal("Sign in at %s to get a one-time passcode."))
+		Expect(args).To(Equal([]interface{}{"https://opsman.example.com/uaa/passcode"}))
+
+		format, _ = logger.PrintfArgsForCall(1)
+		Expect(format).To(Equal("Passcode: "))
+
+		format, _ = logger.PrintfArgsForCall(2)
+		Expect(format).To(ContainSubstring("Logged in."))
+	})
+
+	When("the passcode is provided", func() {
+		It("does not prompt for it", func() {
+			command := commands.NewLogin(authenticator, strings.NewReader(""), logger)
+
+			err := executeCommand(command, []string{"--sso", "--passcode", "some-passcode"})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(authenticator.PasscodeURLCallCount()).To(Equal(0))
+			Expect(authenticator.LoginWithPasscodeArgsForCall(0)).To(Equal("some-passcode"))
+		})
+	})
+
+	When("--sso is not provided", func() {
+		It("returns an error", func() {
+			command := commands.NewLogin(authenticator, strings.NewReader(""), logger)
+
+			err := executeCommand(command, []string{})
+			Expect(err).To(MatchError(ContainSubstring("--sso is required")))
+			Expect(authenticator.LoginWithPasscodeCallCount()).To(Equal(0))
+		})
+	})
+
+	When("no passcode is entered", func() {
+		It("returns an error", func() {
+			command := commands.NewLogin(authenticator, strings.NewReader("\n"), logger)
+
+			err := executeCommand(command, []string{"--sso"})
+			Expect(err).To(MatchError("no passcode was provided"))
+			Expect(authenticator.LoginWithPasscodeCallCount()).To(Equal(0))
+		})
+	})
+
+	When("the passcode is rejected", func() {
+		It("returns an error", func() {
+			authenticator.LoginWithPasscodeReturns(errors.New("could not log in with the passcode: Invalid passcode"))
+			command := commands.NewLogin(authenticator, strings.NewReader("some-passcode\n"), logger)
+
+			err := executeCommand(command, []string{"--sso"})
+			Expect(err).To(MatchError("failed to log in: could not log in with the passcode: Invalid passcode"))
+		})
+	})
+})
@@ -0,0 +1,53 @@
+package commands
+
+import (
+	"fmt"
+)
+
+//counterfeiter:generate -o ./fakes/token_cache.go --fake-name TokenCache . tokenCache
+type tokenCache interface {
+	Delete(key string) error
+	Clear() error
+}
+
+type Logout struct {
+	cache    tokenCache
+	cacheKey func() (string, error)
+	logger   logger
+	Options  struct {
+		All bool `long:"all" description:"remove the cached tokens of every target and user, rather than only those of the current target and user"`
+	}
+}
+
+func NewLogout(cache tokenCache, cacheKey func() (string, error), logger logger) *Logout {
+	return &Logout{
+		cache:    cache,
+		cacheKey: cacheKey,
+		logger:   logger,
+	}
+}
+
+func (l Logout) Execute(_ []string) error {
+	if l.Options.All {
+		err := l.cache.Clear()
+		if err != nil {
+			return err
+		}
+
+		l.logger.Printf("removed every cached UAA token")
+		return nil
+	}
+
+	key, err := l.cacheKey()
+	if err != nil {
+		return fmt.Errorf("could not find the cached token to remove, use --all to remove every cached token: %w", err)
+	}
+
+	err = l.cache.Delete(key)
+	if err != nil {
+		return err
+	}
+
+	l.logger.Printf("removed the cached UAA token of the current target and user")
+	return nil
+}
@@ -0,0 +1,79 @@
+package commands_test
+
+import (
+	"errors"
+
+	"github.com/pivotal-cf/om/commands"
+	"github.com/pivotal-cf/om/commands/fakes"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Logout", func() {
+	var (
+		cache    *fakes.TokenCache
+		logger   *fakes.Logger
+		cacheKey func() (string, error)
+	)
+
+	BeforeEach(func() {
+		cache = &fakes.TokenCache{}
+		logger = &fakes.Logger{}
+		cacheKey = func() (string, error) {
+			return "some-cache-key", nil
+		}
+	})
+
+	It("removes the cached token of the current target and user", func() {
+		command := commands.NewLogout(cache, cacheKey, logger)
+
+		err := executeCommand(command, []string{})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(cache.DeleteCallCount()).To(Equal(1))
+		Expect(cache.DeleteArgsForCall(0)).To(Equal("some-cache-key"))
+		Expect(cache.ClearCallCount()).To(Equal(0))
+
+		format, _ := logger.PrintfArgsForCall(0)
+		Expect(format).To(Equal("removed the cached UAA token of the current target and user"))
+	})
+
+	When("--all is provided", func() {
+		It("removes every cached token", func() {
+			command := commands.NewLogout(cache, cacheKey, logger)
+
+			err := executeCommand(command, []string{"--all"})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(cache.ClearCallCount()).To(Equal(1))
+			Expect(cache.DeleteCallCount()).To(Equal(0))
+
+			format, _ := logger.PrintfArgsForCall(0)
+			Expect(format).To(Equal("removed every cached UAA token"))
+		})
+	})
+
+	When("the current target is not known", func() {
+		It("returns an error", func() {
+			cacheKey = func() (string, error) {
+				return "", errors.New("target is missing")
+			}
+			command := commands.NewLogout(cache, cacheKey, logger)
+
+			err := executeCommand(command, []string{})
+			Expect(err).To(MatchError("could not find the cached token to remove, use --all to remove every cached token: target is missing"))
+			Expect(cache.DeleteCallCount()).To(Equal(0))
+		})
+	})
+
+	When("the cache cannot be changed", func() {
+		It("returns an error", func() {
+			cache.DeleteReturns(errors.New("could not write the token cache"))
+			command := commands.NewLogout(cache, cacheKey, logger)
+
+			err := executeCommand(command, []string{})
+			Expect(err).To(MatchError("could not write the token cache"))
+		})
+	})
+})
@@ -0,0 +1,230 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/pivotal-cf/om/download_clients"
+	"github.com/pivotal-cf/om/validator"
+)
+
+type MirrorProduct struct {
+	environFunc    func() []string
+	progressWriter io.Writer
+	stderr         *log.Logger
+	stdout         *log.Logger
+	Options        struct {
+		Destination  string `long:"destination"             required:"true" description:"the blobstore the product and stemcell are mirrored to [s3|gcs|azure|local]"`
+		Bucket       string `long:"blobstore-bucket"                        description:"bucket name where the product is mirrored to in the s3|gcs|azure compatible blobstore"`
+		ProductPath  string `long:"blobstore-product-path"                  description:"specify the path the product artifacts are mirrored to"`
+		StemcellPath string `long:"blobstore-stemcell-path"                 description:"specify the path the stemcell artifacts are mirrored to"`
+
+		AzureOptions
+		GCSOptions
+		InterpolateOptions interpolateConfigFileOptions `group:"config file interpolation"`
+		LocalOptions
+		PivnetOptions
+		S3Options
+		StemcellOptions
+	}
+}
+
+func NewMirrorProduct(environFunc func() []string, stdout *log.Logger, stderr *log.Logger, progressWriter io.Writer) *MirrorProduct {
+	return &MirrorProduct{
+		environFunc:    environFunc,
+		stderr:         stderr,
+		stdout:         stdout,
+		progressWriter: progressWriter,
+	}
+}
+
+func (c *MirrorProduct) Execute(args []string) error {
+	switch c.Options.Destination {
+	case "s3", "gcs", "azure", "local":
+	default:
+		return fmt.Errorf("--destination must be one of s3, gcs, azure or local, got %q", c.Options.Destination)
+	}
+
+	workDir, err := os.MkdirTemp("", "om-mirror-product-")
+	if err != nil {
+		return fmt.Errorf("could not create a directory to download to: %w", err)
+	}
+	defer os.RemoveAll(workDir)
+
+	download := NewDownloadProduct(c.environFunc, c.stdout, c.stderr, c.progressWriter, nil)
+	download.Options.Source = "pivnet"
+	download.Options.OutputDir = workDir
+	download.Options.PivnetOptions = c.Options.PivnetOptions
+	download.Options.StemcellOptions = c.Options.StemcellOptions
+
+	err = download.validate()
+	if err != nil {
+		return err
+	}
+
+	err = download.createClient()
+	if err != nil {
+		return err
+	}
+
+	uploader, err := c.createUploader()
+	if err != nil {
+		return err
+	}
+
+	slug := download.Options.PivnetProductSlug
+	glob := download.Options.FileGlob
+
+	productVersion, err := download.determineProductVersion()
+	if err != nil {
+		return err
+	}
+
+	productFileArtifact, err := download.downloadClient.GetLatestProductFile(slug, productVersion, glob)
+	if err != nil {
+		return fmt.Errorf("could not mirror product: %s", err)
+	}
+
+	err = c.mirrorFile(download, uploader, productFileArtifact, slug, productVersion, glob, c.Options.ProductPath)
+	if err != nil {
+		return fmt.Errorf("could not mirror product: %s", err)
+	}
+
+	if c.Options.StemcellIaas == "" {
+		return nil
+	}
+
+	if filepath.Ext(productFileArtifact.Name()) != ".pivotal" {
+		c.stderr.Printf("the mirrored file is not a .pivotal file. Not determining and mirroring required stemcell.")
+		return nil
+	}
+
+	stemcell, err := download.downloadClient.GetLatestStemcellForProduct(productFileArtifact, "", c.Options.StemcellSlug)
+	if err != nil {
+		return fmt.Errorf("could not get information about stemcell: %s", err)
+	}
+
+	stemcellVersion := stemcell.Version()
+	if c.Options.StemcellVersion != "" {
+		stemcellVersion = c.Options.StemcellVersion
+	}
+
+	var stemcellFileArtifact download_clients.FileArtifacter
+	for _, stemcellGlob := range download.stemcellGlobs() {
+		stemcellFileArtifact, err = download.downloadClient.GetLatestProductFile(stemcell.Slug(), stemcellVersion, stemcellGlob)
+		if err == nil {
+			glob = stemcellGlob
+			break
+		}
+	}
+	if err != nil {
+		return download.stemcellNotFoundError("could not mirror stemcell", err)
+	}
+
+	err = c.mirrorFile(download, uploader, stemcellFileArtifact, stemcell.Slug(), stemcellVersion, glob, c.Options.StemcellPath)
+	if err != nil {
+		return fmt.Errorf("could not mirror stemcell: %s", err)
+	}
+
+	return nil
+}
+
+func (c *MirrorProduct) createUploader() (download_clients.ProductUploader, error) {
+	client, err := newDownloadClientFromSource(DownloadProductOptions{
+		Source:       c.Options.Destination,
+		Bucket:       c.Options.Bucket,
+		ProductPath:  c.Options.ProductPath,
+		StemcellPath: c.Options.StemcellPath,
+		AzureOptions: c.Options.AzureOptions,
+		GCSOptions:   c.Options.GCSOptions,
+		LocalOptions: c.Options.LocalOptions,
+		S3Options:    c.Options.S3Options,
+	}, c.progressWriter, c.stdout, c.stderr)
+	if err != nil {
+		return nil, fmt.Errorf("could not find valid destination for '%s': %w", c.Options.Destination, err)
+	}
+
+	uploader, ok := client.(download_clients.ProductUploader)
+	if !ok {
+		return nil, fmt.Errorf("cannot mirror to destination '%s'", c.Options.Destination)
+	}
+
+	return uploader, nil
+}
+
+// mirrorFile uploads the file, named with the [slug,version] prefix the
+// blobstore sources expect, along with a sidecar holding its SHA256.
+// Objects that already exist in the destination with a sidecar are not
+// uploaded again.
+func (c *MirrorProduct) mirrorFile(download *DownloadProduct, uploader download_clients.ProductUploader, fileArtifact download_clients.FileArtifacter, slug, version, glob, destinationPath string) error {
+	objectName := path.Join(strings.Trim(destinationPath, "/"), fmt.Sprintf("[%s,%s]%s", slug, version, path.Base(fileArtifact.Name())))
+	checksumName := objectName + ".sha256"
+
+	// The sidecar is uploaded after the file, so a file without one
+	// may be a partial upload and is uploaded again.
+	checksumExists, err := uploader.FileExists(checksumName)
+	if err != nil {
+		return err
+	}
+
+	if checksumExists {
+		fileExists, err := uploader.FileExists(objectName)
+		if err != nil {
+			return err
+		}
+
+		if fileExists {
+			c.stderr.Printf("%s already exists in %s, skipping", objectName, uploader.Name())
+			return nil
+		}
+	}
+
+	filePath, _, err := download.downloadFileArtifact(fileArtifact, slug, glob, "", download.Options.OutputDir)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(filePath)
+
+	checksum := fileArtifact.SHA256()
+	if checksum == "" {
+		checksum, err = validator.NewSHA256Calculator().Checksum(filePath)
+		if err != nil {
+			return fmt.Errorf("could not calculate the sha256 of %s: %w", filePath, err)
+		}
+	}
+
+	err = c.uploadFile(uploader, objectName, filePath)
+	if err != nil {
+		return err
+	}
+
+	contents := fmt.Sprintf("%s  %s\n", checksum, path.Base(objectName))
+	err = uploader.UploadFile(checksumName, strings.NewReader(contents), int64(len(contents)))
+	if err != nil {
+		return err
+	}
+
+	c.stderr.Printf("mirrored %s to %s", objectName, uploader.Name())
+	return nil
+}
+
+func (c *MirrorProduct) uploadFile(uploader download_clients.ProductUploader, objectName, filePath string) error {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	c.stderr.Printf("uploading %s to %s", objectName, uploader.Name())
+	return uploader.UploadFile(objectName, file, info.Size())
+}
@@ -0,0 +1,153 @@
+package commands_test
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/onsi/gomega/gbytes"
+
+	"github.com/pivotal-cf/om/commands"
+	"github.com/pivotal-cf/om/download_clients"
+	"github.com/pivotal-cf/om/download_clients/fakes"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("MirrorProduct", func() {
+	var (
+		command               *commands.MirrorProduct
+		fakeProductDownloader *fakes.ProductDownloader
+		stderr                *gbytes.Buffer
+		destination           string
+		stemcellSHA           string
+	)
+
+	BeforeEach(func() {
+		destination = GinkgoT().TempDir()
+		stemcellSHA = fmt.Sprintf("%x", sha256.Sum256([]byte("some-stemcell")))
+
+		fakeProductDownloader = &fakes.ProductDownloader{}
+		fakeProductDownloader.NameReturns("pivnet")
+		fakeProductDownloader.GetAllProductVersionsReturns([]string{"2.0.0", "97.190"}, nil)
+		fakeProductDownloader.GetLatestProductFileStub = func(slug, version, glob string) (download_clients.FileArtifacter, error) {
+			fa := &fakes.FileArtifacter{}
+			if strings.Contains(glob, "bosh") {
+				fa.NameReturns("product-files/light-bosh-stemcell-97.190-google.tgz")
+				fa.SHA256Returns(stemcellSHA)
+			} else {
+				fa.NameReturns("product-files/" + slug + "-" + version + ".pivotal")
+			}
+			return fa, nil
+		}
+		fakeProductDownloader.DownloadProductToFileStub = func(fa download_clients.FileArtifacter, file *os.File) error {
+			if filepath.Ext(fa.Name()) == ".pivotal" {
+				createProductPivotalFile(file)
+				return nil
+			}
+			_, err := file.WriteString("some-stemcell")
+			return err
+		}
+
+		sa := &fakes.StemcellArtifacter{}
+		sa.SlugReturns("stemcells-ubuntu-xenial")
+		sa.VersionReturns("97.190")
+		fakeProductDownloader.GetLatestStemcellForProductReturns(sa, nil)
+
+		download_clients.NewPivnetClient = func(stdout *log.Logger, stderr *log.Logger, factory download_clients.PivnetFactory, token string, skipSSL bool, pivnetHost string, proxyURL string, proxyUsername string, proxyPassword string, proxyAuthType string, proxyKrb5Config string) (download_clients.ProductDownloader, error) {
+			return fakeProductDownloader, nil
+		}
+
+		stderr = gbytes.NewBuffer()
+		command = commands.NewMirrorProduct(func() []string { return nil }, log.New(GinkgoWriter, "", 0), log.New(stderr, "", 0), GinkgoWriter)
+	})
+
+	It("uploads the product and its stemcell with checksum sidecars", func() {
+		err := executeCommand(command, []string{
+			"--pivnet-api-token", "token",
+			"--pivnet-product-slug", "cf",
+			"--product-version", "2.0.0",
+			"--file-glob", "*.pivotal",
+			"--stemcell-iaas", "google",
+			"--destination", "local",
+			"--local-directory", destination,
+			"--blobstore-product-path", "/tiles/",
+			"--blobstore-stemcell-path", "stemcells",
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		productPath := filepath.Join(destination, "tiles", "[cf,2.0.0]cf-2.0.0.pivotal")
+		Expect(productPath).To(BeAnExistingFile())
+
+		productSHA, err := os.ReadFile(productPath + ".sha256")
+		Expect(err).ToNot(HaveOccurred())
+		fields := strings.Fields(string(productSHA))
+		Expect(fields).To(HaveLen(2))
+		Expect(fields[1]).To(Equal("[cf,2.0.0]cf-2.0.0.pivotal"))
+
+		contents, err := os.ReadFile(productPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fields[0]).To(Equal(fmt.Sprintf("%x", sha256.Sum256(contents))))
+
+		stemcellPath := filepath.Join(destination, "stemcells", "[stemcells-ubuntu-xenial,97.190]light-bosh-stemcell-97.190-google.tgz")
+		Expect(os.ReadFile(stemcellPath)).To(Equal([]byte("some-stemcell")))
+		Expect(os.ReadFile(stemcellPath + ".sha256")).To(Equal([]byte(stemcellSHA + "  [stemcells-ubuntu-xenial,97.190]light-bosh-stemcell-97.190-google.tgz\n")))
+
+		Expect(stderr).To(gbytes.Say(`mirrored tiles/\[cf,2.0.0\]cf-2.0.0.pivotal to local`))
+	})
+
+	When("the files already exist in the destination", func() {
+		It("does not download or upload them again", func() {
+			productPath := filepath.Join(destination, "[cf,2.0.0]cf-2.0.0.pivotal")
+			Expect(os.WriteFile(productPath, []byte("existing"), 0644)).To(Succeed())
+			Expect(os.WriteFile(productPath+".sha256", []byte("existing"), 0644)).To(Succeed())
+
+			err := executeCommand(command, []string{
+				"--pivnet-api-token", "token",
+				"--pivnet-product-slug", "cf",
+				"--product-version", "2.0.0",
+				"--file-glob", "*.pivotal",
+				"--destination", "local",
+				"--local-directory", destination,
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fakeProductDownloader.DownloadProductToFileCallCount()).To(Equal(0))
+			Expect(os.ReadFile(productPath)).To(Equal([]byte("existing")))
+			Expect(stderr).To(gbytes.Say(`\[cf,2.0.0\]cf-2.0.0.pivotal already exists in local, skipping`))
+		})
+
+		It("uploads a file without a checksum again, as it may be a partial upload", func() {
+			stemcellPath := filepath.Join(destination, "[stemcells-ubuntu-xenial,97.190]light-bosh-stemcell-97.190-google.tgz")
+			Expect(os.WriteFile(stemcellPath, []byte("some-stem"), 0644)).To(Succeed())
+
+			err := executeCommand(command, []string{
+				"--pivnet-api-token", "token",
+				"--pivnet-product-slug", "stemcells-ubuntu-xenial",
+				"--product-version", "97.190",
+				"--file-glob", "light-bosh-stemcell-*-google.tgz",
+				"--destination", "local",
+				"--local-directory", destination,
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fakeProductDownloader.DownloadProductToFileCallCount()).To(Equal(1))
+			Expect(os.ReadFile(stemcellPath)).To(Equal([]byte("some-stemcell")))
+			Expect(os.ReadFile(stemcellPath + ".sha256")).To(Equal([]byte(stemcellSHA + "  [stemcells-ubuntu-xenial,97.190]light-bosh-stemcell-97.190-google.tgz\n")))
+		})
+	})
+
+	It("errors with an unknown destination", func() {
+		err := executeCommand(command, []string{
+			"--pivnet-api-token", "token",
+			"--pivnet-product-slug", "cf",
+			"--product-version", "2.0.0",
+			"--destination", "ftp",
+		})
+		Expect(err).To(MatchError(`--destination must be one of s3, gcs, azure or local, got "ftp"`))
+	})
+})
@@ -9,6 +9,7 @@ import (
 	"gopkg.in/yaml.v2"
 
 	"github.com/pivotal-cf/om/api"
+	"github.com/pivotal-cf/om/extractor"
 )
 
 type StageProduct struct {
@@ -20,6 +21,7 @@ type StageProduct struct {
 		Version          string `yaml:"product-version" long:"product-version"        description:"version of product"`
 		StageAllReplicas bool   `yaml:"stage-all-replicas" long:"stage-all-replicas" description:"stage this product for all replicas of this product, default false"`
 		StageReplicas    string `yaml:"stage-replicas" long:"stage-replicas" description:"accepts a comma-separated list of tile names of a replicated product to stage"`
+		ProductPath      string `yaml:"product-path" long:"product-path" description:"path or http(s) URL of the product file, to check its dependencies and minimum Ops Manager version before staging. Ops Manager does not expose the metadata of available products, so without it nothing is checked"`
 	}
 }
 
@@ -96,6 +98,13 @@ func (sp StageProduct) Execute(args []string) error {
 		return fmt.Errorf("failed to stage product: cannot find product %s %s", productName, productVersion)
 	}
 
+	if sp.Options.ProductPath != "" {
+		err = sp.checkCompatibility(productName, productVersion)
+		if err != nil {
+			return fmt.Errorf("failed to stage product: %w", err)
+		}
+	}
+
 	if replicaFlagsUsed {
 		return sp.stageReplicas(productName, productVersion)
 	}
@@ -195,6 +204,26 @@ func (sp StageProduct) stageReplicas(productName, productVersion string) error {
 	return nil
 }
 
+func (sp StageProduct) checkCompatibility(productName, productVersion string) error {
+	inspection, err := inspectProductPath(extractor.NewMetadataExtractor(), sp.Options.ProductPath, false)
+	if err != nil {
+		return err
+	}
+
+	if inspection.Name != productName || inspection.Version != productVersion {
+		return fmt.Errorf("the product file %s contains %s %s, not %s %s", sp.Options.ProductPath, inspection.Name, inspection.Version, productName, productVersion)
+	}
+
+	sp.logger.Printf("checking the compatibility of %s %s", productName, productVersion)
+
+	report, err := checkProductCompatibility(sp.service, inspection)
+	if err != nil {
+		return err
+	}
+
+	return report.err()
+}
+
 func (sp StageProduct) validateReplicaFlags() error {
 	if sp.Options.StageAllReplicas && sp.Options.StageReplicas != "" {
 		return fmt.Errorf("--stage-all-replicas and --stage-replicas are mutually exclusive")
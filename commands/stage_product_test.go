@@ -58,6 +58,60 @@ var _ = Describe("StageProduct", func() {
 		Expect(fmt.Sprintf(format, v...)).To(Equal("finished staging"))
 	})
 
+	When("--product-path is provided", func() {
+		BeforeEach(func() {
+			fakeService.CheckProductAvailabilityReturns(true, nil)
+			fakeService.InfoReturns(api.Info{Version: "3.0.12"}, nil)
+		})
+
+		It("stages the product when its requirements are satisfied", func() {
+			fakeService.ListStagedProductsReturns(api.StagedProductsOutput{Products: []api.StagedProduct{
+				{Type: "cf", ProductVersion: "2.13.5"},
+				{Type: "p-isolation-segment", ProductVersion: "2.13.2"},
+			}}, nil)
+
+			command := commands.NewStageProduct(fakeService, logger)
+			err := executeCommand(command, []string{
+				"--product-name", "some-product",
+				"--product-version", "1.2.3",
+				"--product-path", createTileWithMetadata(compatibilityMetadata),
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fakeService.StageCallCount()).To(Equal(1))
+
+			format, v := logger.PrintfArgsForCall(0)
+			Expect(fmt.Sprintf(format, v...)).To(Equal("checking the compatibility of some-product 1.2.3"))
+		})
+
+		It("does not stage the product when a dependency is not satisfied", func() {
+			fakeService.ListStagedProductsReturns(api.StagedProductsOutput{Products: []api.StagedProduct{
+				{Type: "cf", ProductVersion: "2.12.0"},
+				{Type: "p-isolation-segment", ProductVersion: "2.13.2"},
+			}}, nil)
+
+			command := commands.NewStageProduct(fakeService, logger)
+			err := executeCommand(command, []string{
+				"--product-name", "some-product",
+				"--product-version", "1.2.3",
+				"--product-path", createTileWithMetadata(compatibilityMetadata),
+			})
+			Expect(err).To(MatchError("failed to stage product: some-product 1.2.3 cannot be staged:\n- requires cf ~> 2.13, but cf 2.12.0 is staged"))
+			Expect(fakeService.StageCallCount()).To(Equal(0))
+		})
+
+		It("errors when the product file is for another product", func() {
+			command := commands.NewStageProduct(fakeService, logger)
+			productPath := createTileWithMetadata(compatibilityMetadata)
+			err := executeCommand(command, []string{
+				"--product-name", "some-product",
+				"--product-version", "4.5.6",
+				"--product-path", productPath,
+			})
+			Expect(err).To(MatchError(fmt.Sprintf("failed to stage product: the product file %s contains some-product 1.2.3, not some-product 4.5.6", productPath)))
+			Expect(fakeService.StageCallCount()).To(Equal(0))
+		})
+	})
+
 	When("the product-version is `latest`", func() {
 		It("uses the latest available product version", func() {
 			fakeService.CheckProductAvailabilityReturns(true, nil)
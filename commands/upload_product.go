@@ -5,6 +5,7 @@ import (
 	"github.com/pivotal-cf/om/api"
 	"github.com/pivotal-cf/om/extractor"
 	"github.com/pivotal-cf/om/validator"
+	"time"
 )
 
 const maxProductUploadRetries = 2
@@ -20,8 +21,12 @@ type UploadProduct struct {
 		PollingInterval int    `long:"polling-interval" short:"i"  description:"interval (in seconds) at which to print status" default:"1"`
 		Shasum          string `long:"shasum"                       description:"shasum of the provided product file to be used for validation"`
 		Version         string `long:"product-version"              description:"version of the provided product file to be used for validation"`
+		Verify          bool   `long:"verify"                       description:"verify the zip entries and bundled releases of the product file, as verify-product does, before uploading"`
+		Signature       string `long:"signature"                    description:"with --verify, path to a detached signature of the product file (default: the product path with a .sig suffix)"`
+		PublicKey       string `long:"public-key"                   description:"with --verify, path to a PEM encoded RSA or ECDSA public key to verify the signature of the product file with"`
 	}
 	metadataExtractor metadataExtractor
+	retryBackoff      time.Duration
 }
 
 //counterfeiter:generate -o ./fakes/upload_product_service.go --fake-name UploadProductService . uploadProductService
@@ -44,6 +49,12 @@ func NewUploadProduct(multipart multipart, metadataExtractor metadataExtractor,
 	}
 }
 
+// SetRetryBackoff sets how long to wait before retrying a failed upload,
+// which doubles with each retry. Without it, uploads are retried right away.
+func (up *UploadProduct) SetRetryBackoff(backoff time.Duration) {
+	up.retryBackoff = backoff
+}
+
 func (up UploadProduct) Execute(args []string) error {
 	if up.Options.Shasum != "" {
 		shaValidator := validator.NewSHA256Calculator()
@@ -60,6 +71,15 @@ func (up UploadProduct) Execute(args []string) error {
 		up.logger.Printf("expected shasum matches product shasum.")
 	}
 
+	if up.Options.Verify {
+		err := verifyProduct(up.logger, up.Options.Product, up.Options.Signature, up.Options.PublicKey)
+		if err != nil {
+			return err
+		}
+	} else if up.Options.Signature != "" || up.Options.PublicKey != "" {
+		return fmt.Errorf("--signature and --public-key require --verify")
+	}
+
 	metadata, err := up.metadataExtractor.ExtractFromFile(up.Options.Product)
 	if err != nil {
 		return fmt.Errorf("failed to extract product metadata: %s", err)
@@ -113,6 +133,8 @@ func (up UploadProduct) Execute(args []string) error {
 				up.logger.Printf("product %s %s has been successfully uploaded", metadata.Name, metadata.Version)
 				return nil
 			}
+
+			waitBeforeUploadRetry(up.logger, up.retryBackoff, i)
 		} else {
 			break
 		}
@@ -8,6 +8,7 @@ import (
 	"os"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/onsi/gomega/gbytes"
 
@@ -230,6 +231,46 @@ var _ = Describe("UploadProduct", func() {
 		})
 	})
 
+	When("the --verify flag is defined", func() {
+		It("verifies the product before uploading it", func() {
+			productPath := createTileWithReleases("some-release")
+			publicKeyPath := signProduct(productPath)
+
+			command := commands.NewUploadProduct(multipart, metadataExtractor, fakeService, logger)
+			err := executeCommand(command, []string{
+				"--product", productPath,
+				"--verify",
+				"--public-key", publicKeyPath,
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fakeService.UploadAvailableProductCallCount()).To(Equal(1))
+
+			format, v := logger.PrintfArgsForCall(2)
+			Expect(fmt.Sprintf(format, v...)).To(Equal(fmt.Sprintf("signature %s.sig matches the public key %s", productPath, publicKeyPath)))
+		})
+
+		It("does not upload a product that fails verification", func() {
+			productPath := createTileWithReleases("tampered-release")
+
+			command := commands.NewUploadProduct(multipart, metadataExtractor, fakeService, logger)
+			err := executeCommand(command, []string{
+				"--product", productPath,
+				"--verify",
+			})
+			Expect(err).To(MatchError(ContainSubstring(productPath + " failed verification")))
+			Expect(fakeService.UploadAvailableProductCallCount()).To(Equal(0))
+		})
+
+		It("requires --verify to check a signature", func() {
+			command := commands.NewUploadProduct(multipart, metadataExtractor, fakeService, logger)
+			err := executeCommand(command, []string{
+				"--product", "/path/to/some-product.tgz",
+				"--public-key", "key.pub",
+			})
+			Expect(err).To(MatchError("--signature and --public-key require --verify"))
+		})
+	})
+
 	When("the product fails to upload the first time with a retryable error", func() {
 		When("the product is now present", func() {
 			It("succeeds", func() {
@@ -275,6 +316,21 @@ var _ = Describe("UploadProduct", func() {
 
 			Expect(fakeService.UploadAvailableProductCallCount()).To(Equal(2))
 		})
+
+		It("backs off exponentially between attempts", func() {
+			stdout := gbytes.NewBuffer()
+			command := commands.NewUploadProduct(multipart, metadataExtractor, fakeService, log.New(stdout, "", 0))
+			command.SetRetryBackoff(time.Millisecond)
+
+			fakeService.UploadAvailableProductReturns(api.UploadAvailableProductOutput{}, fmt.Errorf("some upload error: %w", io.EOF))
+
+			err := executeCommand(command, []string{"--product", "/some/path"})
+			Expect(err).To(MatchError(ContainSubstring("EOF")))
+
+			Expect(stdout).To(gbytes.Say("waiting 1ms before retrying the upload"))
+			Expect(stdout).To(gbytes.Say("waiting 2ms before retrying the upload"))
+			Expect(fakeService.CheckProductAvailabilityCallCount()).To(Equal(3))
+		})
 	})
 
 	When("the product fails to upload three times", func() {
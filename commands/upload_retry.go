@@ -0,0 +1,23 @@
+package commands
+
+import (
+	"time"
+)
+
+// UploadRetryBackoff is how long om waits before retrying a failed upload of
+// a product or stemcell. The wait doubles with each retry.
+const UploadRetryBackoff = 5 * time.Second
+
+// waitBeforeUploadRetry backs off exponentially between upload attempts, so
+// that an Ops Manager busy processing a large upload is not sent it again
+// right away. Ops Manager cannot resume an interrupted upload, so every
+// attempt sends the whole file.
+func waitBeforeUploadRetry(logger logger, backoff time.Duration, attempt int) {
+	if backoff <= 0 {
+		return
+	}
+
+	wait := backoff << attempt
+	logger.Printf("waiting %s before retrying the upload", wait)
+	time.Sleep(wait)
+}
@@ -9,15 +9,17 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"time"
 )
 
 const maxStemcellUploadRetries = 2
 
 type UploadStemcell struct {
-	multipart multipart
-	logger    logger
-	service   uploadStemcellService
-	Options   struct {
+	multipart    multipart
+	logger       logger
+	service      uploadStemcellService
+	retryBackoff time.Duration
+	Options      struct {
 		InterpolateOptions interpolateConfigFileOptions `group:"config file interpolation"`
 
 		Stemcell string `long:"stemcell" short:"s" required:"true" description:"path to stemcell"`
@@ -51,6 +53,12 @@ func NewUploadStemcell(multipart multipart, service uploadStemcellService, logge
 	}
 }
 
+// SetRetryBackoff sets how long to wait before retrying a failed upload,
+// which doubles with each retry. Without it, uploads are retried right away.
+func (us *UploadStemcell) SetRetryBackoff(backoff time.Duration) {
+	us.retryBackoff = backoff
+}
+
 func (us UploadStemcell) Execute(args []string) error {
 	err := us.validate()
 	if err != nil {
@@ -125,6 +133,16 @@ func (us UploadStemcell) uploadStemcell(stemcellFilename string) (err error) {
 		if err != nil && i < maxStemcellUploadRetries {
 			us.logger.Printf("retrying stemcell upload after error: %s\n", err)
 			us.multipart.Reset()
+
+			if !us.Options.Force {
+				found, checkErr := us.service.CheckStemcellAvailability(us.Options.Stemcell)
+				if checkErr == nil && found {
+					us.logger.Printf("stemcell has been successfully uploaded")
+					return nil
+				}
+			}
+
+			waitBeforeUploadRetry(us.logger, us.retryBackoff, i)
 		} else {
 			break
 		}
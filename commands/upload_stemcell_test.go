@@ -214,6 +214,31 @@ var _ = Describe("UploadStemcell", func() {
 			})
 		})
 
+		When("the stemcell is present after an upload fails", func() {
+			It("does not upload it again", func() {
+				fakeService.InfoReturns(api.Info{Version: "2.2-build.1"}, nil)
+				multipart.FinalizeReturns(formcontent.ContentSubmission{
+					Content:       io.NopCloser(strings.NewReader("")),
+					ContentType:   "some content-type",
+					ContentLength: 10,
+				})
+
+				command := commands.NewUploadStemcell(multipart, fakeService, logger)
+
+				fakeService.CheckStemcellAvailabilityReturnsOnCall(0, false, nil)
+				fakeService.CheckStemcellAvailabilityReturnsOnCall(1, true, nil)
+				fakeService.UploadStemcellReturns(api.StemcellUploadOutput{}, fmt.Errorf("some upload error: %w", io.EOF))
+
+				err := executeCommand(command, []string{
+					"--stemcell", "/path/to/stemcell.tgz",
+				})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(fakeService.UploadStemcellCallCount()).To(Equal(1))
+				Expect(fakeService.CheckStemcellAvailabilityCallCount()).To(Equal(2))
+			})
+		})
+
 		When("the product fails to upload three times", func() {
 			It("returns an error", func() {
 				fakeService.InfoReturns(api.Info{Version: "2.2-build.1"}, nil)
@@ -0,0 +1,63 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/pivotal-cf/om/validator"
+)
+
+type VerifyProduct struct {
+	logger  logger
+	Options struct {
+		ProductPath string `long:"product-path" short:"p" required:"true" description:"path to the product file to verify"`
+		Signature   string `long:"signature"                              description:"path to a detached signature of the product file (default: the product path with a .sig suffix)"`
+		PublicKey   string `long:"public-key"                             description:"path to a PEM encoded RSA or ECDSA public key to verify the signature of the product file with"`
+	}
+}
+
+func NewVerifyProduct(logger logger) *VerifyProduct {
+	return &VerifyProduct{
+		logger: logger,
+	}
+}
+
+func (vp VerifyProduct) Execute(_ []string) error {
+	return verifyProduct(vp.logger, vp.Options.ProductPath, vp.Options.Signature, vp.Options.PublicKey)
+}
+
+func verifyProduct(logger logger, productPath, signaturePath, publicKeyPath string) error {
+	if signaturePath != "" && publicKeyPath == "" {
+		return fmt.Errorf("--signature requires --public-key to be defined")
+	}
+
+	verifier := validator.NewProductVerifier()
+
+	verification, err := verifier.Verify(productPath)
+	if err != nil {
+		return err
+	}
+
+	logger.Printf("read %d entries of %s without errors", verification.Entries, productPath)
+	for _, release := range verification.Releases {
+		if release.SHA1 == "" {
+			logger.Printf("release %s %s is present; the metadata has no SHA1 to verify it against", release.Name, release.Version)
+			continue
+		}
+		logger.Printf("release %s %s matches SHA1 %s", release.Name, release.Version, release.SHA1)
+	}
+
+	if publicKeyPath != "" {
+		if signaturePath == "" {
+			signaturePath = productPath + ".sig"
+		}
+
+		err = verifier.VerifySignature(productPath, signaturePath, publicKeyPath)
+		if err != nil {
+			return err
+		}
+
+		logger.Printf("signature %s matches the public key %s", signaturePath, publicKeyPath)
+	}
+
+	return nil
+}
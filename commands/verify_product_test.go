@@ -0,0 +1,108 @@
+package commands_test
+
+import (
+	"archive/zip"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/pivotal-cf/om/commands"
+	"github.com/pivotal-cf/om/commands/fakes"
+)
+
+func createTileWithReleases(releaseContents string) string {
+	productPath := filepath.Join(GinkgoT().TempDir(), "product.pivotal")
+	file, err := os.Create(productPath)
+	Expect(err).ToNot(HaveOccurred())
+	defer file.Close()
+
+	z := zip.NewWriter(file)
+	for name, contents := range map[string]string{
+		"metadata/some-product.yml": fmt.Sprintf(`{name: some-product, product_version: 1.2.3, releases: [{name: some-release, version: 1.0.0, file: some-release-1.0.0.tgz, sha1: %x}]}`, sha1.Sum([]byte("some-release"))),
+		"releases/some-release-1.0.0.tgz": releaseContents,
+	} {
+		f, err := z.Create(name)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = f.Write([]byte(contents))
+		Expect(err).ToNot(HaveOccurred())
+	}
+	Expect(z.Close()).To(Succeed())
+
+	return productPath
+}
+
+func signProduct(productPath string) string {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).ToNot(HaveOccurred())
+
+	contents, err := os.ReadFile(productPath)
+	Expect(err).ToNot(HaveOccurred())
+	digest := sha256.Sum256(contents)
+	signature, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	Expect(err).ToNot(HaveOccurred())
+	Expect(os.WriteFile(productPath+".sig", signature, 0600)).To(Succeed())
+
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	Expect(err).ToNot(HaveOccurred())
+	publicKeyPath := filepath.Join(GinkgoT().TempDir(), "key.pub")
+	Expect(os.WriteFile(publicKeyPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0600)).To(Succeed())
+
+	return publicKeyPath
+}
+
+var _ = Describe("VerifyProduct", func() {
+	var (
+		logger  *fakes.Logger
+		command *commands.VerifyProduct
+	)
+
+	BeforeEach(func() {
+		logger = &fakes.Logger{}
+		command = commands.NewVerifyProduct(logger)
+	})
+
+	It("verifies the entries and releases of the product", func() {
+		productPath := createTileWithReleases("some-release")
+
+		err := executeCommand(command, []string{"--product-path", productPath})
+		Expect(err).ToNot(HaveOccurred())
+
+		format, v := logger.PrintfArgsForCall(0)
+		Expect(fmt.Sprintf(format, v...)).To(Equal(fmt.Sprintf("read 2 entries of %s without errors", productPath)))
+		format, v = logger.PrintfArgsForCall(1)
+		Expect(fmt.Sprintf(format, v...)).To(Equal(fmt.Sprintf("release some-release 1.0.0 matches SHA1 %x", sha1.Sum([]byte("some-release")))))
+	})
+
+	It("verifies the signature of the product with the public key", func() {
+		productPath := createTileWithReleases("some-release")
+		publicKeyPath := signProduct(productPath)
+
+		err := executeCommand(command, []string{"--product-path", productPath, "--public-key", publicKeyPath})
+		Expect(err).ToNot(HaveOccurred())
+
+		format, v := logger.PrintfArgsForCall(2)
+		Expect(fmt.Sprintf(format, v...)).To(Equal(fmt.Sprintf("signature %s.sig matches the public key %s", productPath, publicKeyPath)))
+	})
+
+	It("errors when a release has been tampered with", func() {
+		productPath := createTileWithReleases("tampered-release")
+
+		err := executeCommand(command, []string{"--product-path", productPath})
+		Expect(err).To(MatchError(ContainSubstring("the release some-release 1.0.0 has SHA1 %x, but the metadata expects %x", sha1.Sum([]byte("tampered-release")), sha1.Sum([]byte("some-release")))))
+	})
+
+	It("requires a public key to verify a signature", func() {
+		err := executeCommand(command, []string{"--product-path", "product.pivotal", "--signature", "product.pivotal.sig"})
+		Expect(err).To(MatchError("--signature requires --public-key to be defined"))
+	})
+})
@@ -0,0 +1,390 @@
+package download_clients
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/cheggaaa/pb/v3"
+)
+
+const defaultChunkSize int64 = 64 * 1024 * 1024
+
+var errRangesUnsupported = errors.New("ranged downloads are not supported")
+
+// fileRanger reads a remote file in byte ranges. The end of a range is
+// inclusive, matching the semantics of the HTTP Range header.
+type fileRanger interface {
+	Size() int64
+	// Identity changes whenever the remote file changes, so that progress
+	// saved for an older file is never resumed.
+	Identity() string
+	OpenRange(start, end int64) (io.ReadCloser, error)
+}
+
+// rangedProductDownloader is implemented by the sources that can read a
+// product file in byte ranges. When the file turns out not to support it,
+// errRangesUnsupported is returned.
+type rangedProductDownloader interface {
+	fileRanger(fa FileArtifacter) (fileRanger, error)
+}
+
+type chunkProgress struct {
+	Size      int64  `json:"size"`
+	Identity  string `json:"identity"`
+	ChunkSize int64  `json:"chunk_size"`
+	Completed []int  `json:"completed"`
+}
+
+// DownloadProductToFileInChunks downloads the file with the given number of
+// parallel ranged requests. The completed chunks are recorded in a progress
+// file next to the destination file, so that when a download is interrupted
+// running it again only fetches the missing chunks. Sources that cannot read
+// ranges fall back to downloading the file sequentially.
+func DownloadProductToFileInChunks(client ProductDownloader, fa FileArtifacter, destinationFile *os.File, chunks int, stderr *log.Logger) error {
+	var (
+		ranger fileRanger
+		err    error
+	)
+
+	rangedClient, ok := client.(rangedProductDownloader)
+	if ok {
+		ranger, err = rangedClient.fileRanger(fa)
+	}
+
+	if !ok || errors.Is(err, errRangesUnsupported) {
+		stderr.Printf("source %s does not support ranged downloads, downloading sequentially", client.Name())
+
+		err = resetFile(destinationFile, 0)
+		if err != nil {
+			return err
+		}
+
+		_ = os.Remove(progressFilePath(destinationFile))
+		return client.DownloadProductToFile(fa, destinationFile)
+	}
+	if err != nil {
+		return err
+	}
+
+	return downloadInChunks(ranger, destinationFile, chunks, defaultChunkSize, stderr)
+}
+
+func downloadInChunks(ranger fileRanger, destinationFile *os.File, workers int, chunkSize int64, stderr *log.Logger) error {
+	if workers < 1 {
+		workers = 1
+	}
+
+	progressPath := progressFilePath(destinationFile)
+	size := ranger.Size()
+
+	progress := loadChunkProgress(progressPath)
+	if progress.Size != size || progress.Identity != ranger.Identity() || progress.ChunkSize != chunkSize {
+		progress = chunkProgress{
+			Size:      size,
+			Identity:  ranger.Identity(),
+			ChunkSize: chunkSize,
+		}
+
+		err := resetFile(destinationFile, size)
+		if err != nil {
+			return err
+		}
+	}
+
+	completed := map[int]bool{}
+	for _, index := range progress.Completed {
+		completed[index] = true
+	}
+
+	var (
+		pending        []int
+		completedBytes int64
+	)
+	for index := 0; int64(index)*chunkSize < size; index++ {
+		if completed[index] {
+			completedBytes += chunkLength(index, chunkSize, size)
+			continue
+		}
+
+		pending = append(pending, index)
+	}
+
+	if completedBytes > 0 {
+		stderr.Printf("resuming download, %d of %d bytes already downloaded", completedBytes, size)
+	}
+
+	progressBar := pb.Default.New(0)
+	progressBar.SetWriter(stderr.Writer())
+	progressBar.Set(pb.Bytes, true)
+	progressBar.SetTotal(size)
+	progressBar.SetCurrent(completedBytes)
+	progressBar.SetMaxWidth(80)
+	progressBar.Start()
+	defer progressBar.Finish()
+
+	var (
+		mutex    sync.Mutex
+		firstErr error
+		wg       sync.WaitGroup
+	)
+
+	indexes := make(chan int)
+	go func() {
+		defer close(indexes)
+		for _, index := range pending {
+			mutex.Lock()
+			failed := firstErr != nil
+			mutex.Unlock()
+
+			if failed {
+				return
+			}
+
+			indexes <- index
+		}
+	}()
+
+	for worker := 0; worker < workers; worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range indexes {
+				err := downloadChunk(ranger, destinationFile, index, chunkSize, size, progressBar)
+
+				mutex.Lock()
+				if err == nil {
+					progress.Completed = append(progress.Completed, index)
+					err = saveChunkProgress(progressPath, progress)
+				}
+				if err != nil && firstErr == nil {
+					firstErr = err
+				}
+				mutex.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return fmt.Errorf("could not download file, rerun to resume the download: %w", firstErr)
+	}
+
+	_ = os.Remove(progressPath)
+	return nil
+}
+
+func downloadChunk(ranger fileRanger, destinationFile *os.File, index int, chunkSize, size int64, progressBar *pb.ProgressBar) error {
+	start := int64(index) * chunkSize
+	length := chunkLength(index, chunkSize, size)
+
+	reader, err := ranger.OpenRange(start, start+length-1)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	written, err := io.Copy(io.NewOffsetWriter(destinationFile, start), progressBar.NewProxyReader(io.LimitReader(reader, length)))
+	if err != nil {
+		return err
+	}
+
+	if written != length {
+		return fmt.Errorf("expected %d bytes starting at byte %d, but received %d", length, start, written)
+	}
+
+	return nil
+}
+
+func chunkLength(index int, chunkSize, size int64) int64 {
+	start := int64(index) * chunkSize
+	if start+chunkSize > size {
+		return size - start
+	}
+
+	return chunkSize
+}
+
+func progressFilePath(destinationFile *os.File) string {
+	return destinationFile.Name() + ".progress"
+}
+
+func loadChunkProgress(path string) chunkProgress {
+	var progress chunkProgress
+
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return chunkProgress{}
+	}
+
+	err = json.Unmarshal(contents, &progress)
+	if err != nil {
+		return chunkProgress{}
+	}
+
+	return progress
+}
+
+func saveChunkProgress(path string, progress chunkProgress) error {
+	contents, err := json.Marshal(progress)
+	if err != nil {
+		return err
+	}
+
+	err = os.WriteFile(path+".tmp", contents, 0644)
+	if err != nil {
+		return fmt.Errorf("could not save download progress: %w", err)
+	}
+
+	return os.Rename(path+".tmp", path)
+}
+
+func resetFile(file *os.File, size int64) error {
+	err := file.Truncate(0)
+	if err != nil {
+		return fmt.Errorf("could not truncate file %s: %w", file.Name(), err)
+	}
+
+	err = file.Truncate(size)
+	if err != nil {
+		return fmt.Errorf("could not allocate file %s: %w", file.Name(), err)
+	}
+
+	_, err = file.Seek(0, io.SeekStart)
+	return err
+}
+
+type httpDoer interface {
+	Do(*http.Request) (*http.Response, error)
+}
+
+// httpFileRanger reads ranges of a file over HTTP. The link is requested
+// again when the server responds with 403 Forbidden, which happens once a
+// signed link has expired.
+type httpFileRanger struct {
+	client   httpDoer
+	fetch    func() (string, error)
+	link     string
+	size     int64
+	identity string
+	mutex    sync.Mutex
+}
+
+func newHTTPFileRanger(client httpDoer, fetch func() (string, error)) (*httpFileRanger, error) {
+	link, err := fetch()
+	if err != nil {
+		return nil, err
+	}
+
+	ranger := &httpFileRanger{
+		client: client,
+		fetch:  fetch,
+		link:   link,
+	}
+
+	response, err := ranger.get(0, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode == http.StatusOK || response.StatusCode == http.StatusRequestedRangeNotSatisfiable {
+		return nil, errRangesUnsupported
+	}
+
+	if response.StatusCode != http.StatusPartialContent {
+		return nil, fmt.Errorf("unexpected response from '%s': %s", redactedLink(ranger.link), response.Status)
+	}
+
+	contentRange := response.Header.Get("Content-Range")
+	total := contentRange[strings.LastIndex(contentRange, "/")+1:]
+	ranger.size, err = strconv.ParseInt(total, 10, 64)
+	if err != nil {
+		return nil, errRangesUnsupported
+	}
+
+	ranger.identity = response.Header.Get("ETag")
+	if ranger.identity == "" {
+		ranger.identity = response.Header.Get("Last-Modified")
+	}
+
+	return ranger, nil
+}
+
+func (h *httpFileRanger) Size() int64 {
+	return h.size
+}
+
+func (h *httpFileRanger) Identity() string {
+	return h.identity
+}
+
+func (h *httpFileRanger) OpenRange(start, end int64) (io.ReadCloser, error) {
+	response, err := h.get(start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode != http.StatusPartialContent {
+		_ = response.Body.Close()
+		return nil, fmt.Errorf("unexpected response for bytes %d-%d: %s", start, end, response.Status)
+	}
+
+	return response.Body, nil
+}
+
+func (h *httpFileRanger) get(start, end int64) (*http.Response, error) {
+	h.mutex.Lock()
+	link := h.link
+	h.mutex.Unlock()
+
+	response, err := h.getLink(link, start, end)
+	if err != nil || response.StatusCode != http.StatusForbidden {
+		return response, err
+	}
+	_ = response.Body.Close()
+
+	h.mutex.Lock()
+	if h.link == link {
+		var refreshed string
+		refreshed, err = h.fetch()
+		if err == nil {
+			h.link = refreshed
+		}
+	}
+	link = h.link
+	h.mutex.Unlock()
+	if err != nil {
+		return nil, fmt.Errorf("could not refresh download link: %w", err)
+	}
+
+	return h.getLink(link, start, end)
+}
+
+func (h *httpFileRanger) getLink(link string, start, end int64) (*http.Response, error) {
+	request, err := http.NewRequest("GET", link, nil)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", start, end))
+
+	response, err := h.client.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("could not reach '%s': %w", redactedLink(link), err)
+	}
+
+	return response, nil
+}
+
+// redactedLink drops the query of a link, since signed links carry their
+// credentials there.
+func redactedLink(link string) string {
+	return strings.SplitN(link, "?", 2)[0]
+}
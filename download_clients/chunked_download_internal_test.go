@@ -0,0 +1,164 @@
+package download_clients
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("downloadInChunks", func() {
+	var (
+		stderr   *log.Logger
+		contents []byte
+		server   *httptest.Server
+		mutex    sync.Mutex
+		requests []string
+		failing  map[string]bool
+		file     *os.File
+	)
+
+	BeforeEach(func() {
+		stderr = log.New(GinkgoWriter, "", 0)
+		contents = bytes.Repeat([]byte("0123456789"), 10)
+		requests = nil
+		failing = map[string]bool{}
+
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mutex.Lock()
+			requestedRange := r.Header.Get("Range")
+			requests = append(requests, requestedRange)
+			fail := failing[requestedRange]
+			mutex.Unlock()
+
+			if fail {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			w.Header().Set("ETag", `"some-etag"`)
+			http.ServeContent(w, r, "file", time.Time{}, bytes.NewReader(contents))
+		}))
+
+		var err error
+		file, err = os.Create(filepath.Join(GinkgoT().TempDir(), "file.partial"))
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		server.Close()
+		_ = file.Close()
+	})
+
+	newRanger := func() fileRanger {
+		ranger, err := newHTTPFileRanger(http.DefaultClient, func() (string, error) {
+			return server.URL, nil
+		})
+		Expect(err).ToNot(HaveOccurred())
+		return ranger
+	}
+
+	It("downloads the file in ranged chunks", func() {
+		ranger := newRanger()
+		Expect(ranger.Size()).To(Equal(int64(100)))
+		Expect(ranger.Identity()).To(Equal(`"some-etag"`))
+
+		err := downloadInChunks(ranger, file, 3, 30, stderr)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(os.ReadFile(file.Name())).To(Equal(contents))
+		Expect(requests).To(ConsistOf("bytes=0-0", "bytes=0-29", "bytes=30-59", "bytes=60-89", "bytes=90-99"))
+		Expect(file.Name() + ".progress").ToNot(BeAnExistingFile())
+	})
+
+	It("resumes an interrupted download", func() {
+		failing["bytes=60-89"] = true
+
+		err := downloadInChunks(newRanger(), file, 1, 30, stderr)
+		Expect(err).To(MatchError(ContainSubstring("rerun to resume the download")))
+		Expect(file.Name() + ".progress").To(BeAnExistingFile())
+
+		mutex.Lock()
+		requests = nil
+		failing = map[string]bool{}
+		mutex.Unlock()
+
+		err = downloadInChunks(newRanger(), file, 2, 30, stderr)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(os.ReadFile(file.Name())).To(Equal(contents))
+		Expect(requests).To(ContainElement("bytes=60-89"))
+		Expect(requests).ToNot(ContainElement("bytes=0-29"))
+		Expect(requests).ToNot(ContainElement("bytes=30-59"))
+		Expect(file.Name() + ".progress").ToNot(BeAnExistingFile())
+	})
+
+	It("starts over when the file has changed since the progress was saved", func() {
+		failing["bytes=30-59"] = true
+
+		err := downloadInChunks(newRanger(), file, 1, 30, stderr)
+		Expect(err).To(HaveOccurred())
+
+		mutex.Lock()
+		requests = nil
+		failing = map[string]bool{}
+		contents = bytes.Repeat([]byte("abcdefghij"), 10)
+		mutex.Unlock()
+
+		ranger := newRanger().(*httpFileRanger)
+		ranger.identity = `"another-etag"`
+
+		err = downloadInChunks(ranger, file, 2, 30, stderr)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(os.ReadFile(file.Name())).To(Equal(contents))
+		Expect(requests).To(ContainElement("bytes=0-29"))
+	})
+
+	It("requests the link again when it has expired", func() {
+		server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/expired" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			http.ServeContent(w, r, "file", time.Time{}, bytes.NewReader(contents))
+		})
+
+		links := 0
+		ranger, err := newHTTPFileRanger(http.DefaultClient, func() (string, error) {
+			links++
+			if links == 1 {
+				return server.URL + "/expired", nil
+			}
+			return server.URL + "/signed", nil
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(links).To(Equal(2))
+
+		err = downloadInChunks(ranger, file, 2, 30, stderr)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(os.ReadFile(file.Name())).To(Equal(contents))
+	})
+
+	When("the server does not support ranges", func() {
+		It("falls back to downloading the file sequentially", func() {
+			server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write(contents)
+			})
+
+			client, err := NewHTTPClient(HTTPConfiguration{URL: server.URL}, stderr)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = DownloadProductToFileInChunks(client, &httpFileArtifact{name: "file", url: server.URL + "/file", client: client}, file, 4, stderr)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(os.ReadFile(file.Name())).To(Equal(contents))
+		})
+	})
+})
@@ -20,6 +20,8 @@ func DetermineProductVersion(
 	slug string,
 	exactVersion string,
 	versionRegex string,
+	versionConstraint string,
+	excludePrerelease bool,
 	versioner productVersioner,
 	stderr *log.Logger,
 ) (string, error) {
@@ -30,10 +32,13 @@ func DetermineProductVersion(
 		existingVersions = "none"
 	}
 
-	if versionRegex != "" {
-		foundVersion, err := findLatestVersionFromRegex(productVersions, versionRegex, stderr)
+	if versionRegex != "" || versionConstraint != "" {
+		foundVersion, err := findLatestVersion(productVersions, versionRegex, versionConstraint, excludePrerelease, stderr)
 		if err != nil {
 			msg := fmt.Errorf("no valid versions found for product %q and product version regex %q\nexisting versions: %s", slug, versionRegex, existingVersions)
+			if versionConstraint != "" {
+				msg = fmt.Errorf("no valid versions found for product %q and product version constraint %q\nexisting versions: %s", slug, versionConstraint, existingVersions)
+			}
 			if productVersionError != nil {
 				msg = fmt.Errorf("%w: %s", productVersionError, msg)
 			}
@@ -57,15 +62,33 @@ func DetermineProductVersion(
 	return "", msg
 }
 
-func findLatestVersionFromRegex(productVersions []string, regex string, stderr *log.Logger) (string, error) {
-	re, err := regexp.Compile(regex)
-	if err != nil {
-		return "", fmt.Errorf("could not compile regex %q: %w", regex, err)
+// findLatestVersion returns the highest version matching the regex and
+// satisfying the constraint, whichever of the two are provided.
+// Constraints follow hashicorp/go-version, e.g. "~> 2.10.0" or ">= 4.0, < 5".
+func findLatestVersion(productVersions []string, regex string, constraint string, excludePrerelease bool, stderr *log.Logger) (string, error) {
+	var (
+		re          *regexp.Regexp
+		constraints version.Constraints
+		err         error
+	)
+
+	if regex != "" {
+		re, err = regexp.Compile(regex)
+		if err != nil {
+			return "", fmt.Errorf("could not compile regex %q: %w", regex, err)
+		}
+	}
+
+	if constraint != "" {
+		constraints, err = version.NewConstraint(constraint)
+		if err != nil {
+			return "", fmt.Errorf("could not parse version constraint %q: %w", constraint, err)
+		}
 	}
 
 	var versions version.Collection
 	for _, productVersion := range productVersions {
-		if !re.MatchString(productVersion) {
+		if re != nil && !re.MatchString(productVersion) {
 			continue
 		}
 
@@ -74,6 +97,15 @@ func findLatestVersionFromRegex(productVersions []string, regex string, stderr *
 			stderr.Printf("warning: could not parse semver version from: %s", productVersion)
 			continue
 		}
+
+		if excludePrerelease && (v.Prerelease() != "" || v.Metadata() != "") {
+			continue
+		}
+
+		if constraints != nil && !constraints.Check(v) {
+			continue
+		}
+
 		versions = append(versions, v)
 	}
 
@@ -23,6 +23,8 @@ var _ = Describe("DetermineProductVersion", func() {
 					"product",
 					"2.2.2",
 					"",
+					"",
+					false,
 					versioner,
 					nil,
 				)
@@ -40,6 +42,8 @@ var _ = Describe("DetermineProductVersion", func() {
 					"product",
 					"4.5.6",
 					"",
+					"",
+					false,
 					versioner,
 					nil,
 				)
@@ -57,6 +61,8 @@ var _ = Describe("DetermineProductVersion", func() {
 				"product",
 				"",
 				`2\.2\..*`,
+				"",
+				false,
 				versioner,
 				nil,
 			)
@@ -73,6 +79,8 @@ var _ = Describe("DetermineProductVersion", func() {
 					"product",
 					"",
 					`[a--z]`,
+					"",
+					false,
 					versioner,
 					nil,
 				)
@@ -89,6 +97,8 @@ var _ = Describe("DetermineProductVersion", func() {
 					"product",
 					"",
 					`2\.2\..*`,
+					"",
+					false,
 					versioner,
 					nil,
 				)
@@ -106,6 +116,8 @@ var _ = Describe("DetermineProductVersion", func() {
 					"product",
 					"",
 					`2\.2\..*`,
+					"",
+					false,
 					versioner,
 					nil,
 				)
@@ -126,6 +138,8 @@ var _ = Describe("DetermineProductVersion", func() {
 					"product",
 					"",
 					`2\.2\..*`,
+					"",
+					false,
 					versioner,
 					logger,
 				)
@@ -143,6 +157,8 @@ var _ = Describe("DetermineProductVersion", func() {
 					"product",
 					"",
 					`2\.2\..*`,
+					"",
+					false,
 					versioner,
 					nil,
 				)
@@ -151,4 +167,112 @@ var _ = Describe("DetermineProductVersion", func() {
 			})
 		})
 	})
+
+	When("a constraint is provided", func() {
+		It("returns the latest version satisfying a pessimistic constraint", func() {
+			versioner := &fakes.ProductVersioner{}
+			versioner.GetAllProductVersionsReturns([]string{"2.10.1", "2.10.12", "2.100.0", "2.11.0", "2.10.13-rc.1"}, nil)
+
+			version, err := download_clients.DetermineProductVersion(
+				"product",
+				"",
+				"",
+				"~> 2.10.0",
+				false,
+				versioner,
+				nil,
+			)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(version).To(Equal("2.10.12"))
+		})
+
+		It("returns the latest version within a range", func() {
+			versioner := &fakes.ProductVersioner{}
+			versioner.GetAllProductVersionsReturns([]string{"3.9.0", "4.0.0", "4.7.2", "5.0.0"}, nil)
+
+			version, err := download_clients.DetermineProductVersion(
+				"product",
+				"",
+				"",
+				">=4.0, <5",
+				false,
+				versioner,
+				nil,
+			)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(version).To(Equal("4.7.2"))
+		})
+
+		When("excluding pre-releases", func() {
+			It("skips versions with pre-release or build metadata", func() {
+				versioner := &fakes.ProductVersioner{}
+				versioner.GetAllProductVersionsReturns([]string{"4.0.0", "4.0.1+build.5", "4.1.0-build.2"}, nil)
+
+				version, err := download_clients.DetermineProductVersion(
+					"product",
+					"",
+					"",
+					">= 4.0",
+					true,
+					versioner,
+					nil,
+				)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(version).To(Equal("4.0.0"))
+			})
+
+			It("also applies to regexes", func() {
+				versioner := &fakes.ProductVersioner{}
+				versioner.GetAllProductVersionsReturns([]string{"2.2.1", "2.2.2-rc.1"}, nil)
+
+				version, err := download_clients.DetermineProductVersion(
+					"product",
+					"",
+					`2\.2\..*`,
+					"",
+					true,
+					versioner,
+					nil,
+				)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(version).To(Equal("2.2.1"))
+			})
+		})
+
+		When("providing an invalid constraint", func() {
+			It("returns an error", func() {
+				versioner := &fakes.ProductVersioner{}
+				versioner.GetAllProductVersionsReturns([]string{"1.2.1"}, nil)
+
+				_, err := download_clients.DetermineProductVersion(
+					"product",
+					"",
+					"",
+					"~> two",
+					false,
+					versioner,
+					nil,
+				)
+				Expect(err).To(MatchError(ContainSubstring(`could not parse version constraint "~> two"`)))
+			})
+		})
+
+		When("no versions satisfy the constraint", func() {
+			It("returns an error", func() {
+				versioner := &fakes.ProductVersioner{}
+				versioner.GetAllProductVersionsReturns([]string{"1.2.1"}, nil)
+
+				_, err := download_clients.DetermineProductVersion(
+					"product",
+					"",
+					"",
+					"~> 2.10.0",
+					false,
+					versioner,
+					nil,
+				)
+				Expect(err).To(MatchError(ContainSubstring("no valid versions found for product \"product\" and product version constraint \"~> 2.10.0\"\nexisting versions: 1.2.1")))
+			})
+		})
+	})
 })
@@ -59,3 +59,57 @@ func (f stowFileArtifact) Name() string {
 func (f stowFileArtifact) SHA256() string {
 	return f.sha256
 }
+
+type localFileArtifact struct {
+	name string
+	path string
+}
+
+func (f localFileArtifact) ProductMetadata() (*extractor.Metadata, error) {
+	return extractor.NewMetadataExtractor().ExtractFromFile(f.path)
+}
+
+func (f localFileArtifact) Name() string {
+	return f.name
+}
+
+func (f localFileArtifact) SHA256() string {
+	return ""
+}
+
+type httpFileArtifact struct {
+	name   string
+	url    string
+	client httpClient
+}
+
+func (f httpFileArtifact) ProductMetadata() (*extractor.Metadata, error) {
+	return extractor.NewMetadataExtractor(extractor.WithHTTPClient(f.client)).ExtractFromURL(f.url)
+}
+
+func (f httpFileArtifact) Name() string {
+	return f.name
+}
+
+func (f httpFileArtifact) SHA256() string {
+	return ""
+}
+
+type ociFileArtifact struct {
+	name   string
+	url    string
+	sha256 string
+	client ociClient
+}
+
+func (f ociFileArtifact) ProductMetadata() (*extractor.Metadata, error) {
+	return extractor.NewMetadataExtractor(extractor.WithHTTPClient(f.client)).ExtractFromURL(f.url)
+}
+
+func (f ociFileArtifact) Name() string {
+	return f.name
+}
+
+func (f ociFileArtifact) SHA256() string {
+	return f.sha256
+}
@@ -0,0 +1,278 @@
+package download_clients
+
+import (
+	"bufio"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+
+	"gopkg.in/go-playground/validator.v9"
+)
+
+type HTTPConfiguration struct {
+	URL          string `validate:"required"`
+	IndexPath    string
+	Username     string
+	Password     string
+	Token        string
+	DisableSSL   bool
+	ProductPath  string
+	StemcellPath string
+}
+
+type httpClient struct {
+	baseURL      *url.URL
+	indexPath    string
+	username     string
+	password     string
+	token        string
+	productPath  string
+	stemcellPath string
+	client       *http.Client
+	stderr       *log.Logger
+}
+
+var hrefRegexp = regexp.MustCompile(`(?i)href\s*=\s*["']([^"']+)["']`)
+
+func NewHTTPClient(config HTTPConfiguration, stderr *log.Logger) (httpClient, error) {
+	validate := validator.New()
+	err := validate.Struct(config)
+	if err != nil {
+		return httpClient{}, err
+	}
+
+	if config.Token != "" && config.Username != "" {
+		return httpClient{}, errors.New("the flags \"http-token\" and \"http-username\" cannot be used together; please choose one or the other")
+	}
+
+	if config.Username != "" && config.Password == "" {
+		return httpClient{}, errors.New("the flag \"http-password\" is required when \"http-username\" is provided")
+	}
+
+	baseURL, err := url.Parse(config.URL)
+	if err != nil {
+		return httpClient{}, fmt.Errorf("could not parse http url '%s': %w", config.URL, err)
+	}
+
+	if baseURL.Scheme != "http" && baseURL.Scheme != "https" {
+		return httpClient{}, fmt.Errorf("http url '%s' must use the http or https scheme", config.URL)
+	}
+
+	return httpClient{
+		baseURL:      baseURL,
+		indexPath:    config.IndexPath,
+		username:     config.Username,
+		password:     config.Password,
+		token:        config.Token,
+		productPath:  config.ProductPath,
+		stemcellPath: config.StemcellPath,
+		client: &http.Client{
+			Transport: &http.Transport{
+				Proxy: http.ProxyFromEnvironment,
+				TLSClientConfig: &tls.Config{
+					InsecureSkipVerify: config.DisableSSL,
+				},
+			},
+		},
+		stderr: stderr,
+	}, nil
+}
+
+func (h httpClient) Name() string {
+	return "http"
+}
+
+func (h httpClient) GetAllProductVersions(slug string) ([]string, error) {
+	files, err := h.listFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	return prefixedFileVersions(files, slug, h.productPath)
+}
+
+func (h httpClient) GetLatestProductFile(slug, version, glob string) (FileArtifacter, error) {
+	files, err := h.listFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	name, err := prefixedProductFile(files, h.productPath, h.stemcellPath, slug, version, glob)
+	if err != nil {
+		return nil, err
+	}
+
+	return &httpFileArtifact{
+		name:   name,
+		url:    h.fileURL(name),
+		client: h,
+	}, nil
+}
+
+func (h httpClient) DownloadProductToFile(fa FileArtifacter, destinationFile *os.File) error {
+	fileArtifact := fa.(*httpFileArtifact)
+
+	response, err := h.get(fileArtifact.url)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	progressBar, reader := startProgressBar(h.stderr, response.ContentLength, response.Body)
+	defer progressBar.Finish()
+
+	_, err = io.Copy(destinationFile, reader)
+	return err
+}
+
+func (h httpClient) fileRanger(fa FileArtifacter) (fileRanger, error) {
+	fileArtifact := fa.(*httpFileArtifact)
+
+	return newHTTPFileRanger(h, func() (string, error) {
+		return fileArtifact.url, nil
+	})
+}
+
+func (h httpClient) GetLatestStemcellForProduct(_ FileArtifacter, downloadedProductFileName string, stemcellSlug string) (StemcellArtifacter, error) {
+	return latestCompatibleStemcell(downloadedProductFileName, stemcellSlug, h.Name(), func(slug string) ([]string, error) {
+		files, err := h.listFiles()
+		if err != nil {
+			return nil, err
+		}
+
+		return prefixedFileVersions(files, slug, h.stemcellPath)
+	})
+}
+
+// Do sends the request with the configured basic or bearer authentication.
+// It allows the client to be used by the metadata extractor for range reads.
+func (h httpClient) Do(request *http.Request) (*http.Response, error) {
+	if h.token != "" {
+		request.Header.Set("Authorization", "Bearer "+h.token)
+	} else if h.username != "" {
+		request.SetBasicAuth(h.username, h.password)
+	}
+
+	return h.client.Do(request)
+}
+
+func (h httpClient) get(fileURL string) (*http.Response, error) {
+	request, err := http.NewRequest("GET", fileURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := h.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("could not reach '%s': %w", fileURL, err)
+	}
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		_ = response.Body.Close()
+		return nil, fmt.Errorf("unexpected response from '%s': %s", fileURL, response.Status)
+	}
+
+	return response, nil
+}
+
+// listFiles returns the slash separated paths, relative to the base URL, of
+// the files available on the server. When an index path is configured it is
+// expected to list one path per line, otherwise the product and stemcell
+// directories are browsed and the links in their listing are used.
+func (h httpClient) listFiles() ([]string, error) {
+	var (
+		paths []string
+		err   error
+	)
+
+	if h.indexPath != "" {
+		paths, err = h.listFilesFromIndex()
+	} else {
+		paths, err = h.listFilesFromDirectories()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("'%s' contains no files", h.baseURL.Redacted())
+	}
+
+	return paths, nil
+}
+
+func (h httpClient) listFilesFromIndex() ([]string, error) {
+	response, err := h.get(h.fileURL(h.indexPath))
+	if err != nil {
+		return nil, fmt.Errorf("could not read index: %w", err)
+	}
+	defer response.Body.Close()
+
+	var paths []string
+	scanner := bufio.NewScanner(response.Body)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		paths = append(paths, strings.TrimLeft(strings.TrimPrefix(line, "./"), "/"))
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("could not read index: %w", err)
+	}
+
+	return paths, nil
+}
+
+func (h httpClient) listFilesFromDirectories() ([]string, error) {
+	directories := []string{strings.Trim(h.productPath, "/")}
+	if stemcellPath := strings.Trim(h.stemcellPath, "/"); stemcellPath != directories[0] {
+		directories = append(directories, stemcellPath)
+	}
+
+	var paths []string
+	for _, directory := range directories {
+		response, err := h.get(h.fileURL(directory) + "/")
+		if err != nil {
+			return nil, fmt.Errorf("could not browse directory: %w", err)
+		}
+
+		contents, err := io.ReadAll(response.Body)
+		_ = response.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("could not browse directory: %w", err)
+		}
+
+		for _, match := range hrefRegexp.FindAllStringSubmatch(string(contents), -1) {
+			link, err := url.Parse(match[1])
+			if err != nil || link.RawQuery != "" || link.Path == "" || strings.HasSuffix(link.Path, "/") {
+				continue
+			}
+
+			paths = append(paths, path.Join(directory, path.Base(link.Path)))
+		}
+	}
+
+	return paths, nil
+}
+
+func (h httpClient) fileURL(name string) string {
+	var segments []string
+	for _, segment := range strings.Split(name, "/") {
+		if segment != "" {
+			segments = append(segments, url.PathEscape(segment))
+		}
+	}
+
+	return strings.TrimSuffix(h.baseURL.JoinPath(segments...).String(), "/")
+}
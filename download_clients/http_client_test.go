@@ -0,0 +1,169 @@
+package download_clients_test
+
+import (
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/onsi/gomega/ghttp"
+
+	"github.com/pivotal-cf/om/download_clients"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("httpClient", func() {
+	var (
+		stderr *log.Logger
+		server *ghttp.Server
+	)
+
+	BeforeEach(func() {
+		stderr = log.New(GinkgoWriter, "", 0)
+		server = ghttp.NewServer()
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("NewHTTPClient", func() {
+		It("requires a url", func() {
+			_, err := download_clients.NewHTTPClient(download_clients.HTTPConfiguration{}, stderr)
+			Expect(err).To(MatchError(ContainSubstring("Field validation for 'URL' failed on the 'required' tag")))
+		})
+
+		It("requires an http or https url", func() {
+			_, err := download_clients.NewHTTPClient(download_clients.HTTPConfiguration{URL: "ftp://example.com"}, stderr)
+			Expect(err).To(MatchError("http url 'ftp://example.com' must use the http or https scheme"))
+		})
+
+		It("does not allow both basic and bearer authentication", func() {
+			_, err := download_clients.NewHTTPClient(download_clients.HTTPConfiguration{
+				URL:      server.URL(),
+				Username: "username",
+				Password: "password",
+				Token:    "token",
+			}, stderr)
+			Expect(err).To(MatchError(ContainSubstring(`the flags "http-token" and "http-username" cannot be used together`)))
+		})
+
+		It("requires a password with a username", func() {
+			_, err := download_clients.NewHTTPClient(download_clients.HTTPConfiguration{
+				URL:      server.URL(),
+				Username: "username",
+			}, stderr)
+			Expect(err).To(MatchError(ContainSubstring(`the flag "http-password" is required`)))
+		})
+	})
+
+	When("browsing directory listings", func() {
+		BeforeEach(func() {
+			server.RouteToHandler("GET", "/repo/tiles/", ghttp.CombineHandlers(
+				ghttp.VerifyBasicAuth("username", "password"),
+				ghttp.RespondWith(http.StatusOK, `<html><body>
+<a href="../">../</a>
+<a href="nested/">nested/</a>
+<a href="%5Bproduct-slug%2C1.0.0%5Dproduct.pivotal">[product-slug,1.0.0]product.pivotal</a>
+<a href="/repo/tiles/%5Bproduct-slug%2C1.1.1%5Dproduct.pivotal">[product-slug,1.1.1]product.pivotal</a>
+<a href="?C=M;O=A">Last modified</a>
+</body></html>`),
+			))
+			server.RouteToHandler("GET", "/repo/stemcells/", ghttp.CombineHandlers(
+				ghttp.VerifyBasicAuth("username", "password"),
+				ghttp.RespondWith(http.StatusOK, `<a href='[stemcells-ubuntu-jammy,97.28]stemcell.tgz'>stemcell</a>`),
+			))
+			server.RouteToHandler("GET", "/repo/tiles/[product-slug,1.1.1]product.pivotal", ghttp.CombineHandlers(
+				ghttp.VerifyBasicAuth("username", "password"),
+				ghttp.RespondWith(http.StatusOK, "some-contents"),
+			))
+		})
+
+		It("lists versions, finds and downloads files with basic auth", func() {
+			client, err := download_clients.NewHTTPClient(download_clients.HTTPConfiguration{
+				URL:          server.URL() + "/repo",
+				Username:     "username",
+				Password:     "password",
+				ProductPath:  "tiles",
+				StemcellPath: "/stemcells/",
+			}, stderr)
+			Expect(err).ToNot(HaveOccurred())
+
+			versions, err := client.GetAllProductVersions("product-slug")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(versions).To(Equal([]string{"1.0.0", "1.1.1"}))
+
+			fileArtifact, err := client.GetLatestProductFile("product-slug", "1.1.1", "*.pivotal")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fileArtifact.Name()).To(Equal("tiles/[product-slug,1.1.1]product.pivotal"))
+
+			file, err := os.CreateTemp("", "")
+			Expect(err).ToNot(HaveOccurred())
+			defer os.Remove(file.Name())
+
+			err = client.DownloadProductToFile(fileArtifact, file)
+			Expect(err).ToNot(HaveOccurred())
+
+			contents, err := os.ReadFile(file.Name())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(contents)).To(Equal("some-contents"))
+		})
+
+		It("finds the latest stemcell for the product", func() {
+			exampleTileFileName := createPivotalFile("[example-product,1.0-build.0]example*pivotal", "ubuntu-jammy", "97.28")
+
+			client, err := download_clients.NewHTTPClient(download_clients.HTTPConfiguration{
+				URL:          server.URL() + "/repo/",
+				Username:     "username",
+				Password:     "password",
+				ProductPath:  "tiles",
+				StemcellPath: "stemcells",
+			}, stderr)
+			Expect(err).ToNot(HaveOccurred())
+
+			stemcell, err := client.GetLatestStemcellForProduct(nil, exampleTileFileName, "")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(stemcell.Slug()).To(Equal("stemcells-ubuntu-jammy"))
+			Expect(stemcell.Version()).To(Equal("97.28"))
+		})
+	})
+
+	When("an index is provided", func() {
+		BeforeEach(func() {
+			server.RouteToHandler("GET", "/index.txt", ghttp.CombineHandlers(
+				ghttp.VerifyHeaderKV("Authorization", "Bearer some-token"),
+				ghttp.RespondWith(http.StatusOK, "# generated\n./[product-slug,2.0.0]product.pivotal\n\n/[product-slug,2.1.0]product.pivotal\n"),
+			))
+		})
+
+		It("lists the files from the index with bearer auth", func() {
+			client, err := download_clients.NewHTTPClient(download_clients.HTTPConfiguration{
+				URL:       server.URL(),
+				IndexPath: "index.txt",
+				Token:     "some-token",
+			}, stderr)
+			Expect(err).ToNot(HaveOccurred())
+
+			versions, err := client.GetAllProductVersions("product-slug")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(versions).To(Equal([]string{"2.0.0", "2.1.0"}))
+		})
+	})
+
+	When("the server rejects the request", func() {
+		BeforeEach(func() {
+			server.RouteToHandler("GET", "/", ghttp.RespondWith(http.StatusUnauthorized, ""))
+		})
+
+		It("returns an error with the status", func() {
+			client, err := download_clients.NewHTTPClient(download_clients.HTTPConfiguration{
+				URL: server.URL(),
+			}, stderr)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = client.GetAllProductVersions("product-slug")
+			Expect(err).To(MatchError(ContainSubstring("401 Unauthorized")))
+		})
+	})
+})
@@ -1,6 +1,7 @@
 package download_clients
 
 import (
+	"io"
 	"os"
 
 	"github.com/pivotal-cf/om/extractor"
@@ -27,3 +28,10 @@ type ProductDownloader interface {
 	DownloadProductToFile(fa FileArtifacter, file *os.File) error
 	GetLatestStemcellForProduct(fa FileArtifacter, downloadedProductFileName string, stemcellSlug string) (StemcellArtifacter, error)
 }
+
+//counterfeiter:generate -o ./fakes/product_uploader_service.go --fake-name ProductUploader . ProductUploader
+type ProductUploader interface {
+	Name() string
+	FileExists(name string) (bool, error)
+	UploadFile(name string, reader io.Reader, size int64) error
+}
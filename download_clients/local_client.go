@@ -0,0 +1,185 @@
+package download_clients
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/go-playground/validator.v9"
+)
+
+type LocalConfiguration struct {
+	Directory    string `validate:"required"`
+	ProductPath  string
+	StemcellPath string
+}
+
+type localClient struct {
+	directory    string
+	productPath  string
+	stemcellPath string
+	stderr       *log.Logger
+}
+
+func NewLocalClient(config LocalConfiguration, stderr *log.Logger) (localClient, error) {
+	validate := validator.New()
+	err := validate.Struct(config)
+	if err != nil {
+		return localClient{}, err
+	}
+
+	info, err := os.Stat(config.Directory)
+	if err != nil {
+		return localClient{}, fmt.Errorf("could not access local directory '%s': %w", config.Directory, err)
+	}
+	if !info.IsDir() {
+		return localClient{}, fmt.Errorf("local directory '%s' is not a directory", config.Directory)
+	}
+
+	return localClient{
+		directory:    config.Directory,
+		productPath:  config.ProductPath,
+		stemcellPath: config.StemcellPath,
+		stderr:       stderr,
+	}, nil
+}
+
+func (l localClient) Name() string {
+	return "local"
+}
+
+func (l localClient) GetAllProductVersions(slug string) ([]string, error) {
+	files, err := l.listFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	return prefixedFileVersions(files, slug, l.productPath)
+}
+
+func (l localClient) GetLatestProductFile(slug, version, glob string) (FileArtifacter, error) {
+	files, err := l.listFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	name, err := prefixedProductFile(files, l.productPath, l.stemcellPath, slug, version, glob)
+	if err != nil {
+		return nil, err
+	}
+
+	return &localFileArtifact{
+		name: name,
+		path: filepath.Join(l.directory, filepath.FromSlash(name)),
+	}, nil
+}
+
+func (l localClient) DownloadProductToFile(fa FileArtifacter, destinationFile *os.File) error {
+	fileArtifact := fa.(*localFileArtifact)
+
+	sourceFile, err := os.Open(fileArtifact.path)
+	if err != nil {
+		return fmt.Errorf("could not open %s: %w", fileArtifact.path, err)
+	}
+	defer sourceFile.Close()
+
+	info, err := sourceFile.Stat()
+	if err != nil {
+		return fmt.Errorf("could not stat %s: %w", fileArtifact.path, err)
+	}
+
+	progressBar, reader := startProgressBar(l.stderr, info.Size(), sourceFile)
+	defer progressBar.Finish()
+
+	_, err = io.Copy(destinationFile, reader)
+	return err
+}
+
+func (l localClient) GetLatestStemcellForProduct(_ FileArtifacter, downloadedProductFileName string, stemcellSlug string) (StemcellArtifacter, error) {
+	return latestCompatibleStemcell(downloadedProductFileName, stemcellSlug, l.Name(), func(slug string) ([]string, error) {
+		files, err := l.listFiles()
+		if err != nil {
+			return nil, err
+		}
+
+		return prefixedFileVersions(files, slug, l.stemcellPath)
+	})
+}
+
+// FileExists reports whether a file with the slash separated name exists in
+// the directory.
+func (l localClient) FileExists(name string) (bool, error) {
+	_, err := os.Stat(filepath.Join(l.directory, filepath.FromSlash(name)))
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("could not check for '%s' in local directory: %w", name, err)
+	}
+
+	return true, nil
+}
+
+// UploadFile writes the contents of the reader to the slash separated name in
+// the directory. The file only appears once it has been completely written.
+func (l localClient) UploadFile(name string, reader io.Reader, size int64) error {
+	destination := filepath.Join(l.directory, filepath.FromSlash(name))
+
+	err := os.MkdirAll(filepath.Dir(destination), 0755)
+	if err != nil {
+		return fmt.Errorf("could not create directory for '%s': %w", name, err)
+	}
+
+	file, err := os.Create(destination + ".partial")
+	if err != nil {
+		return fmt.Errorf("could not create '%s': %w", name, err)
+	}
+	defer os.Remove(file.Name())
+
+	progressBar, wrappedReader := startProgressBar(l.stderr, size, reader)
+	_, err = io.Copy(file, wrappedReader)
+	progressBar.Finish()
+	if err != nil {
+		_ = file.Close()
+		return fmt.Errorf("could not write '%s': %w", name, err)
+	}
+
+	err = file.Close()
+	if err != nil {
+		return fmt.Errorf("could not write '%s': %w", name, err)
+	}
+
+	return os.Rename(file.Name(), destination)
+}
+
+// listFiles returns every regular file under the directory as a slash
+// separated path relative to it, mirroring the object keys of a blobstore.
+func (l localClient) listFiles() ([]string, error) {
+	var paths []string
+	err := filepath.Walk(l.directory, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		relativePath, err := filepath.Rel(l.directory, path)
+		if err != nil {
+			return err
+		}
+		paths = append(paths, filepath.ToSlash(relativePath))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not list files in local directory '%s': %w", l.directory, err)
+	}
+
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("local directory '%s' contains no files", l.directory)
+	}
+
+	return paths, nil
+}
@@ -0,0 +1,239 @@
+package download_clients_test
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pivotal-cf/om/download_clients"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("localClient", func() {
+	var (
+		stderr    *log.Logger
+		directory string
+	)
+
+	writeLocalFile := func(name, contents string) {
+		path := filepath.Join(directory, filepath.FromSlash(name))
+		Expect(os.MkdirAll(filepath.Dir(path), 0755)).To(Succeed())
+		Expect(os.WriteFile(path, []byte(contents), 0644)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		stderr = log.New(GinkgoWriter, "", 0)
+		directory = GinkgoT().TempDir()
+	})
+
+	Describe("NewLocalClient", func() {
+		It("requires a directory", func() {
+			_, err := download_clients.NewLocalClient(download_clients.LocalConfiguration{}, stderr)
+			Expect(err).To(MatchError(ContainSubstring("Field validation for 'Directory' failed on the 'required' tag")))
+		})
+
+		It("errors when the directory does not exist", func() {
+			_, err := download_clients.NewLocalClient(download_clients.LocalConfiguration{
+				Directory: filepath.Join(directory, "missing"),
+			}, stderr)
+			Expect(err).To(MatchError(ContainSubstring("could not access local directory")))
+		})
+
+		It("errors when the directory is a file", func() {
+			writeLocalFile("some-file", "")
+
+			_, err := download_clients.NewLocalClient(download_clients.LocalConfiguration{
+				Directory: filepath.Join(directory, "some-file"),
+			}, stderr)
+			Expect(err).To(MatchError(ContainSubstring("is not a directory")))
+		})
+	})
+
+	Describe("GetAllProductVersions", func() {
+		It("reports the versions of prefixed files in the product path", func() {
+			writeLocalFile("tiles/[product-slug,1.0.0]product.pivotal", "")
+			writeLocalFile("tiles/[product-slug,1.1.1]product.pivotal", "")
+			writeLocalFile("tiles/[product-slug,1.1.1]other.pivotal", "")
+			writeLocalFile("elsewhere/[product-slug,9.9.9]product.pivotal", "")
+
+			client, err := download_clients.NewLocalClient(download_clients.LocalConfiguration{
+				Directory:   directory,
+				ProductPath: "/tiles/",
+			}, stderr)
+			Expect(err).ToNot(HaveOccurred())
+
+			versions, err := client.GetAllProductVersions("product-slug")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(versions).To(ConsistOf("1.0.0", "1.1.1"))
+		})
+
+		It("errors when the directory is empty", func() {
+			client, err := download_clients.NewLocalClient(download_clients.LocalConfiguration{
+				Directory: directory,
+			}, stderr)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = client.GetAllProductVersions("product-slug")
+			Expect(err).To(MatchError(ContainSubstring("contains no files")))
+		})
+	})
+
+	Describe("GetLatestProductFile and DownloadProductToFile", func() {
+		It("copies the file that matches the glob", func() {
+			writeLocalFile("tiles/[product-slug,1.1.1]product.pivotal", "some-contents")
+			writeLocalFile("tiles/[product-slug,1.1.1]product.zip", "other-contents")
+
+			client, err := download_clients.NewLocalClient(download_clients.LocalConfiguration{
+				Directory:   directory,
+				ProductPath: "tiles",
+			}, stderr)
+			Expect(err).ToNot(HaveOccurred())
+
+			fileArtifact, err := client.GetLatestProductFile("product-slug", "1.1.1", "*.pivotal")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fileArtifact.Name()).To(Equal("tiles/[product-slug,1.1.1]product.pivotal"))
+			Expect(fileArtifact.SHA256()).To(BeEmpty())
+
+			file, err := os.CreateTemp("", "")
+			Expect(err).ToNot(HaveOccurred())
+			defer os.Remove(file.Name())
+
+			err = client.DownloadProductToFile(fileArtifact, file)
+			Expect(err).ToNot(HaveOccurred())
+
+			contents, err := os.ReadFile(file.Name())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(contents)).To(Equal("some-contents"))
+		})
+
+		It("errors when the glob matches no file", func() {
+			writeLocalFile("[product-slug,1.1.1]product.pivotal", "")
+
+			client, err := download_clients.NewLocalClient(download_clients.LocalConfiguration{
+				Directory: directory,
+			}, stderr)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = client.GetLatestProductFile("product-slug", "1.1.1", "*.zip")
+			Expect(err).To(MatchError(ContainSubstring("the glob '*.zip' matches no file")))
+		})
+	})
+
+	Describe("ProductMetadata", func() {
+		It("reads the metadata from the file in the directory", func() {
+			exampleTileFileName := createPivotalFile("[example-product,1.0-build.0]example*pivotal", "ubuntu-jammy", "97.28")
+			Expect(os.Rename(exampleTileFileName, filepath.Join(directory, "[example-product,1.0-build.0]example.pivotal"))).To(Succeed())
+
+			client, err := download_clients.NewLocalClient(download_clients.LocalConfiguration{
+				Directory: directory,
+			}, stderr)
+			Expect(err).ToNot(HaveOccurred())
+
+			fileArtifact, err := client.GetLatestProductFile("example-product", "1.0-build.0", "*.pivotal")
+			Expect(err).ToNot(HaveOccurred())
+
+			metadata, err := fileArtifact.ProductMetadata()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(metadata.Name).To(Equal("example-product"))
+		})
+	})
+
+	Describe("GetLatestStemcellForProduct", func() {
+		It("returns the latest stemcell from the stemcell path", func() {
+			exampleTileFileName := createPivotalFile("[example-product,1.0-build.0]example*pivotal", "ubuntu-jammy", "97.28")
+
+			writeLocalFile("stemcells/[stemcells-ubuntu-jammy,97.28]stemcell.tgz", "")
+			writeLocalFile("stemcells/[stemcells-ubuntu-jammy,97.101]stemcell.tgz", "")
+			writeLocalFile("stemcells/[stemcells-ubuntu-jammy,98.1]stemcell.tgz", "")
+
+			client, err := download_clients.NewLocalClient(download_clients.LocalConfiguration{
+				Directory:    directory,
+				StemcellPath: "stemcells",
+			}, stderr)
+			Expect(err).ToNot(HaveOccurred())
+
+			stemcell, err := client.GetLatestStemcellForProduct(nil, exampleTileFileName, "")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(stemcell.Slug()).To(Equal("stemcells-ubuntu-jammy"))
+			Expect(stemcell.Version()).To(Equal("97.101"))
+		})
+
+		It("returns the latest stemcell for the additional stemcell criteria matching the stemcell slug", func() {
+			exampleTileFileName := createMultiStemcellPivotalFile("[example-product,1.0-build.0]example*pivotal", "ubuntu-jammy", "97.28", map[string]string{"windows2019": "2019.41"})
+
+			writeLocalFile("stemcells/[stemcells-ubuntu-jammy,97.101]stemcell.tgz", "")
+			writeLocalFile("stemcells/[stemcells-windows-server,2019.41]stemcell.tgz", "")
+			writeLocalFile("stemcells/[stemcells-windows-server,2019.76]stemcell.tgz", "")
+
+			client, err := download_clients.NewLocalClient(download_clients.LocalConfiguration{
+				Directory:    directory,
+				StemcellPath: "stemcells",
+			}, stderr)
+			Expect(err).ToNot(HaveOccurred())
+
+			stemcell, err := client.GetLatestStemcellForProduct(nil, exampleTileFileName, "stemcells-windows-server")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(stemcell.Slug()).To(Equal("stemcells-windows-server"))
+			Expect(stemcell.Version()).To(Equal("2019.76"))
+		})
+
+		It("returns the latest stemcell for the stemcell criteria when no criteria match the stemcell slug", func() {
+			exampleTileFileName := createMultiStemcellPivotalFile("[example-product,1.0-build.0]example*pivotal", "ubuntu-jammy", "97.28", map[string]string{"windows2019": "2019.41"})
+
+			writeLocalFile("stemcells/[stemcells-ubuntu-jammy,97.101]stemcell.tgz", "")
+			writeLocalFile("stemcells/[stemcells-windows-server,2019.76]stemcell.tgz", "")
+
+			client, err := download_clients.NewLocalClient(download_clients.LocalConfiguration{
+				Directory:    directory,
+				StemcellPath: "stemcells",
+			}, stderr)
+			Expect(err).ToNot(HaveOccurred())
+
+			stemcell, err := client.GetLatestStemcellForProduct(nil, exampleTileFileName, "my-custom-stemcells")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(stemcell.Slug()).To(Equal("stemcells-ubuntu-jammy"))
+			Expect(stemcell.Version()).To(Equal("97.101"))
+		})
+
+		It("errors when no stemcells are available", func() {
+			exampleTileFileName := createPivotalFile("[example-product,1.0-build.0]example*pivotal", "ubuntu-jammy", "97.28")
+			writeLocalFile("[product-slug,1.1.1]product.pivotal", "")
+
+			client, err := download_clients.NewLocalClient(download_clients.LocalConfiguration{
+				Directory: directory,
+			}, stderr)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = client.GetLatestStemcellForProduct(nil, exampleTileFileName, "")
+			Expect(err).To(MatchError("could not find stemcells on local: no files matching pivnet-product-slug stemcells-ubuntu-jammy found"))
+		})
+	})
+
+	Describe("FileExists and UploadFile", func() {
+		It("writes the file under the directory and then reports it exists", func() {
+			client, err := download_clients.NewLocalClient(download_clients.LocalConfiguration{
+				Directory: directory,
+			}, stderr)
+			Expect(err).ToNot(HaveOccurred())
+
+			exists, err := client.FileExists("tiles/[product-slug,1.1.1]product.pivotal")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(exists).To(BeFalse())
+
+			err = client.UploadFile("tiles/[product-slug,1.1.1]product.pivotal", strings.NewReader("some-contents"), 13)
+			Expect(err).ToNot(HaveOccurred())
+
+			exists, err = client.FileExists("tiles/[product-slug,1.1.1]product.pivotal")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(exists).To(BeTrue())
+
+			contents, err := os.ReadFile(filepath.Join(directory, "tiles", "[product-slug,1.1.1]product.pivotal"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(contents)).To(Equal("some-contents"))
+			Expect(filepath.Join(directory, "tiles", "[product-slug,1.1.1]product.pivotal.partial")).ToNot(BeAnExistingFile())
+		})
+	})
+})
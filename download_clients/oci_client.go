@@ -0,0 +1,496 @@
+package download_clients
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/cpuguy83/dockercfg"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"gopkg.in/go-playground/validator.v9"
+)
+
+// OCISlugAnnotation is the manifest annotation holding the Pivotal Network
+// slug of the product stored in an OCI artifact.
+const OCISlugAnnotation = "io.pivotal.network.product-slug"
+
+type OCIConfiguration struct {
+	Registry     string `validate:"required"`
+	Username     string
+	Password     string
+	DockerConfig string
+	Insecure     bool
+	DisableSSL   bool
+	ProductPath  string
+	StemcellPath string
+}
+
+// ociClient reads products stored as OCI artifacts, with one repository
+// per slug and one tag per version. Each file of a version is a layer whose
+// title annotation is the file name.
+type ociClient struct {
+	baseURL      *url.URL
+	username     string
+	password     string
+	productPath  string
+	stemcellPath string
+	client       *http.Client
+	tokens       *ociTokens
+	stderr       *log.Logger
+}
+
+type ociTokens struct {
+	mutex  sync.Mutex
+	tokens map[string]string
+}
+
+var (
+	linkNextRegexp      = regexp.MustCompile(`<([^>]+)>\s*;\s*rel="?next"?`)
+	challengeParamRegex = regexp.MustCompile(`(\w+)="([^"]*)"`)
+)
+
+func NewOCIClient(config OCIConfiguration, stderr *log.Logger) (ociClient, error) {
+	validate := validator.New()
+	err := validate.Struct(config)
+	if err != nil {
+		return ociClient{}, err
+	}
+
+	if config.Username != "" && config.Password == "" {
+		return ociClient{}, errors.New("the flag \"oci-password\" is required when \"oci-username\" is provided")
+	}
+
+	scheme := "https"
+	if config.Insecure {
+		scheme = "http"
+	}
+
+	registry := strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(config.Registry, "https://"), "http://"), "/")
+	baseURL, err := url.Parse(scheme + "://" + registry)
+	if err != nil {
+		return ociClient{}, fmt.Errorf("could not parse oci registry '%s': %w", config.Registry, err)
+	}
+
+	username, password := config.Username, config.Password
+	if username == "" {
+		username, password, err = dockerConfigCredentials(config.DockerConfig, baseURL.Host)
+		if err != nil {
+			return ociClient{}, err
+		}
+	}
+
+	return ociClient{
+		baseURL:      baseURL,
+		username:     username,
+		password:     password,
+		productPath:  config.ProductPath,
+		stemcellPath: config.StemcellPath,
+		client: &http.Client{
+			Transport: &http.Transport{
+				Proxy: http.ProxyFromEnvironment,
+				TLSClientConfig: &tls.Config{
+					InsecureSkipVerify: config.DisableSSL,
+				},
+			},
+		},
+		tokens: &ociTokens{tokens: map[string]string{}},
+		stderr: stderr,
+	}, nil
+}
+
+// dockerConfigCredentials reads the credentials for the registry from the
+// docker config, including its credential helpers. The default config is
+// optional, while a config passed explicitly must exist.
+func dockerConfigCredentials(configPath string, host string) (string, string, error) {
+	explicit := configPath != ""
+	if !explicit {
+		var err error
+		configPath, err = dockercfg.ConfigPath()
+		if err != nil {
+			return "", "", nil
+		}
+	}
+
+	var config dockercfg.Config
+	err := dockercfg.FromFile(configPath, &config)
+	if err != nil {
+		if !explicit && errors.Is(err, os.ErrNotExist) {
+			return "", "", nil
+		}
+
+		return "", "", fmt.Errorf("could not load docker config '%s': %w", configPath, err)
+	}
+
+	username, password, err := config.GetRegistryCredentials(host)
+	if err != nil {
+		return "", "", fmt.Errorf("could not read the credentials for '%s' from docker config '%s': %w", host, configPath, err)
+	}
+
+	return username, password, nil
+}
+
+func (o ociClient) Name() string {
+	return "oci"
+}
+
+func (o ociClient) GetAllProductVersions(slug string) ([]string, error) {
+	return o.versions(o.repository(o.productPath, slug))
+}
+
+func (o ociClient) GetLatestProductFile(slug, version, glob string) (FileArtifacter, error) {
+	repositories := []string{o.repository(o.productPath, slug)}
+	if stemcellRepository := o.repository(o.stemcellPath, slug); stemcellRepository != repositories[0] {
+		repositories = append(repositories, stemcellRepository)
+	}
+
+	var (
+		manifest   *ocispec.Manifest
+		repository string
+		err        error
+	)
+	for _, repository = range repositories {
+		manifest, err = o.manifest(repository, version)
+		if err != nil {
+			return nil, err
+		}
+		if manifest != nil {
+			break
+		}
+	}
+
+	if manifest == nil {
+		return nil, fmt.Errorf("no artifact tagged %s found in %s", ociTag(version), strings.Join(repositories, " or "))
+	}
+
+	if manifestSlug, ok := manifest.Annotations[OCISlugAnnotation]; ok && manifestSlug != slug {
+		return nil, fmt.Errorf("the artifact %s:%s is annotated with product slug %q, expected %q", repository, ociTag(version), manifestSlug, slug)
+	}
+
+	var (
+		available []string
+		matched   []ocispec.Descriptor
+	)
+	for _, layer := range manifest.Layers {
+		title := layer.Annotations[ocispec.AnnotationTitle]
+		if title == "" {
+			continue
+		}
+		available = append(available, title)
+
+		if ok, _ := filepath.Match(glob, title); ok {
+			matched = append(matched, layer)
+		}
+	}
+
+	if len(matched) > 1 {
+		var titles []string
+		for _, layer := range matched {
+			titles = append(titles, layer.Annotations[ocispec.AnnotationTitle])
+		}
+		return nil, fmt.Errorf("the glob '%s' matches multiple files. Write your glob to match exactly one of the following:\n  %s", glob, strings.Join(titles, "\n  "))
+	}
+
+	if len(matched) == 0 {
+		availableFiles := strings.Join(available, ", ")
+		if availableFiles == "" {
+			availableFiles = "none"
+		}
+		return nil, fmt.Errorf("the glob '%s' matches no file\navailable files: %s", glob, availableFiles)
+	}
+
+	layer := matched[0]
+
+	var sha256 string
+	if layer.Digest.Algorithm() == "sha256" {
+		sha256 = layer.Digest.Encoded()
+	}
+
+	return &ociFileArtifact{
+		name:   layer.Annotations[ocispec.AnnotationTitle],
+		url:    o.registryURL(repository, "blobs", layer.Digest.String()),
+		sha256: sha256,
+		client: o,
+	}, nil
+}
+
+func (o ociClient) DownloadProductToFile(fa FileArtifacter, destinationFile *os.File) error {
+	fileArtifact := fa.(*ociFileArtifact)
+
+	response, err := o.get(fileArtifact.url)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	progressBar, reader := startProgressBar(o.stderr, response.ContentLength, response.Body)
+	defer progressBar.Finish()
+
+	_, err = io.Copy(destinationFile, reader)
+	return err
+}
+
+func (o ociClient) fileRanger(fa FileArtifacter) (fileRanger, error) {
+	fileArtifact := fa.(*ociFileArtifact)
+
+	return newHTTPFileRanger(o, func() (string, error) {
+		return fileArtifact.url, nil
+	})
+}
+
+func (o ociClient) GetLatestStemcellForProduct(_ FileArtifacter, downloadedProductFileName string, stemcellSlug string) (StemcellArtifacter, error) {
+	return latestCompatibleStemcell(downloadedProductFileName, stemcellSlug, o.Name(), func(slug string) ([]string, error) {
+		return o.versions(o.repository(o.stemcellPath, slug))
+	})
+}
+
+// Do sends the request with the registry credentials. When the registry
+// challenges the request, a bearer token is requested for the scope of the
+// challenge, cached per repository and the request is sent again.
+func (o ociClient) Do(request *http.Request) (*http.Response, error) {
+	repository := ociRepositoryFromPath(request.URL.Path)
+
+	o.tokens.mutex.Lock()
+	token := o.tokens.tokens[repository]
+	o.tokens.mutex.Unlock()
+
+	if token != "" {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	response, err := o.client.Do(request)
+	if err != nil || response.StatusCode != http.StatusUnauthorized {
+		return response, err
+	}
+
+	challenge := response.Header.Get("WWW-Authenticate")
+	_ = response.Body.Close()
+
+	retry := request.Clone(request.Context())
+	scheme, params := parseChallenge(challenge)
+	switch scheme {
+	case "basic":
+		if o.username == "" {
+			return nil, fmt.Errorf("the registry '%s' requires credentials, provide them with --oci-username or in the docker config", o.baseURL.Host)
+		}
+		retry.SetBasicAuth(o.username, o.password)
+	case "bearer":
+		token, err = o.token(params)
+		if err != nil {
+			return nil, err
+		}
+
+		o.tokens.mutex.Lock()
+		o.tokens.tokens[repository] = token
+		o.tokens.mutex.Unlock()
+
+		retry.Header.Set("Authorization", "Bearer "+token)
+	default:
+		return nil, fmt.Errorf("the registry '%s' responded with an unsupported authentication challenge %q", o.baseURL.Host, challenge)
+	}
+
+	return o.client.Do(retry)
+}
+
+// token requests a bearer token from the realm of the challenge, using the
+// credentials when there are any, or an identity token from the docker config.
+func (o ociClient) token(params map[string]string) (string, error) {
+	realm, err := url.Parse(params["realm"])
+	if err != nil || params["realm"] == "" {
+		return "", fmt.Errorf("the registry '%s' responded with an invalid token realm %q", o.baseURL.Host, params["realm"])
+	}
+
+	query := url.Values{}
+	for _, key := range []string{"service", "scope"} {
+		if params[key] != "" {
+			query.Set(key, params[key])
+		}
+	}
+
+	var request *http.Request
+	if o.username == "" && o.password != "" {
+		query.Set("grant_type", "refresh_token")
+		query.Set("refresh_token", o.password)
+		query.Set("client_id", "om")
+		request, err = http.NewRequest("POST", realm.String(), strings.NewReader(query.Encode()))
+		if err != nil {
+			return "", err
+		}
+		request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	} else {
+		realm.RawQuery = query.Encode()
+		request, err = http.NewRequest("GET", realm.String(), nil)
+		if err != nil {
+			return "", err
+		}
+		if o.username != "" {
+			request.SetBasicAuth(o.username, o.password)
+		}
+	}
+
+	response, err := o.client.Do(request)
+	if err != nil {
+		return "", fmt.Errorf("could not request a token from '%s': %w", redactedLink(realm.String()), err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("could not request a token from '%s': %s", redactedLink(realm.String()), response.Status)
+	}
+
+	var tokenResponse struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	err = json.NewDecoder(response.Body).Decode(&tokenResponse)
+	if err != nil {
+		return "", fmt.Errorf("could not parse the token from '%s': %w", redactedLink(realm.String()), err)
+	}
+
+	if tokenResponse.Token != "" {
+		return tokenResponse.Token, nil
+	}
+
+	return tokenResponse.AccessToken, nil
+}
+
+// versions lists the tags of the repository, following the pagination of
+// the registry, and turns them back into versions.
+func (o ociClient) versions(repository string) ([]string, error) {
+	var versions []string
+
+	next := o.registryURL(repository, "tags", "list")
+	for next != "" {
+		response, err := o.get(next)
+		if err != nil {
+			return nil, fmt.Errorf("could not list the tags of %s: %w", repository, err)
+		}
+
+		var tags struct {
+			Tags []string `json:"tags"`
+		}
+		err = json.NewDecoder(response.Body).Decode(&tags)
+		_ = response.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("could not parse the tags of %s: %w", repository, err)
+		}
+
+		for _, tag := range tags.Tags {
+			versions = append(versions, strings.ReplaceAll(tag, "_", "+"))
+		}
+
+		next = ""
+		if match := linkNextRegexp.FindStringSubmatch(response.Header.Get("Link")); match != nil {
+			link, err := response.Request.URL.Parse(match[1])
+			if err != nil {
+				return nil, fmt.Errorf("could not follow the tags of %s: %w", repository, err)
+			}
+			next = link.String()
+		}
+	}
+
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("no versions found in %s", repository)
+	}
+
+	return versions, nil
+}
+
+// manifest returns the manifest tagged with the version, or nil when the
+// repository or tag does not exist.
+func (o ociClient) manifest(repository, version string) (*ocispec.Manifest, error) {
+	request, err := http.NewRequest("GET", o.registryURL(repository, "manifests", ociTag(version)), nil)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Accept", ocispec.MediaTypeImageManifest)
+
+	response, err := o.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("could not reach '%s': %w", o.baseURL.Host, err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("could not get the manifest of %s:%s: %s", repository, ociTag(version), response.Status)
+	}
+
+	var manifest ocispec.Manifest
+	err = json.NewDecoder(response.Body).Decode(&manifest)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse the manifest of %s:%s: %w", repository, ociTag(version), err)
+	}
+
+	return &manifest, nil
+}
+
+func (o ociClient) get(link string) (*http.Response, error) {
+	request, err := http.NewRequest("GET", link, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := o.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("could not reach '%s': %w", redactedLink(link), err)
+	}
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		_ = response.Body.Close()
+		return nil, fmt.Errorf("unexpected response from '%s': %s", redactedLink(link), response.Status)
+	}
+
+	return response, nil
+}
+
+func (o ociClient) repository(repositoryPath, slug string) string {
+	return path.Join(strings.Trim(repositoryPath, "/"), slug)
+}
+
+func (o ociClient) registryURL(repository string, segments ...string) string {
+	return o.baseURL.JoinPath(append([]string{"v2", repository}, segments...)...).String()
+}
+
+// ociTag turns a version into a tag. Tags cannot contain '+', so build
+// metadata is separated with '_' instead, as helm does.
+func ociTag(version string) string {
+	return strings.ReplaceAll(version, "+", "_")
+}
+
+// ociRepositoryFromPath extracts the repository from a registry API path,
+// such as /v2/<repository>/manifests/<tag>.
+func ociRepositoryFromPath(requestPath string) string {
+	repository := strings.TrimPrefix(requestPath, "/v2/")
+	for _, endpoint := range []string{"/manifests/", "/blobs/", "/tags/"} {
+		if index := strings.LastIndex(repository, endpoint); index >= 0 {
+			return repository[:index]
+		}
+	}
+
+	return repository
+}
+
+func parseChallenge(challenge string) (string, map[string]string) {
+	scheme, rest, _ := strings.Cut(challenge, " ")
+
+	params := map[string]string{}
+	for _, match := range challengeParamRegex.FindAllStringSubmatch(rest, -1) {
+		params[strings.ToLower(match[1])] = match[2]
+	}
+
+	return strings.ToLower(scheme), params
+}
@@ -0,0 +1,327 @@
+package download_clients_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/pivotal-cf/om/download_clients"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// testRegistry is a minimal in-process OCI distribution registry, serving
+// manifests, blobs and paginated tag lists, optionally behind token auth.
+type testRegistry struct {
+	server    *httptest.Server
+	mutex     sync.Mutex
+	manifests map[string][]byte
+	tags      map[string][]string
+	blobs     map[string][]byte
+	username  string
+	password  string
+	requests  []string
+}
+
+func newTestRegistry() *testRegistry {
+	registry := &testRegistry{
+		manifests: map[string][]byte{},
+		tags:      map[string][]string{},
+		blobs:     map[string][]byte{},
+	}
+	registry.server = httptest.NewServer(http.HandlerFunc(registry.serveHTTP))
+	return registry
+}
+
+func (r *testRegistry) host() string {
+	return strings.TrimPrefix(r.server.URL, "http://")
+}
+
+func (r *testRegistry) push(repository, tag string, annotations map[string]string, files map[string]string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	manifest := ocispec.Manifest{
+		MediaType:   ocispec.MediaTypeImageManifest,
+		Annotations: annotations,
+	}
+	manifest.SchemaVersion = 2
+
+	for name, contents := range files {
+		layerDigest := digest.FromString(contents)
+		r.blobs[layerDigest.String()] = []byte(contents)
+		manifest.Layers = append(manifest.Layers, ocispec.Descriptor{
+			MediaType:   "application/octet-stream",
+			Digest:      layerDigest,
+			Size:        int64(len(contents)),
+			Annotations: map[string]string{ocispec.AnnotationTitle: name},
+		})
+	}
+
+	contents, err := json.Marshal(manifest)
+	Expect(err).ToNot(HaveOccurred())
+
+	r.manifests[repository+":"+tag] = contents
+	r.tags[repository] = append(r.tags[repository], tag)
+}
+
+func (r *testRegistry) serveHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mutex.Lock()
+	r.requests = append(r.requests, req.Method+" "+req.URL.Path)
+	r.mutex.Unlock()
+
+	if req.URL.Path == "/token" {
+		username, password, _ := req.BasicAuth()
+		if username != r.username || password != r.password {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"token": "some-token"}`))
+		return
+	}
+
+	path := strings.TrimPrefix(req.URL.Path, "/v2/")
+	if r.username != "" && req.Header.Get("Authorization") != "Bearer some-token" {
+		w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="test-registry",scope="repository:%s:pull"`, r.server.URL, path))
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	switch {
+	case strings.Contains(path, "/manifests/"):
+		parts := strings.SplitN(path, "/manifests/", 2)
+		manifest, ok := r.manifests[parts[0]+":"+parts[1]]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", ocispec.MediaTypeImageManifest)
+		_, _ = w.Write(manifest)
+	case strings.Contains(path, "/blobs/"):
+		parts := strings.SplitN(path, "/blobs/", 2)
+		blob, ok := r.blobs[parts[1]]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		http.ServeContent(w, req, "", time.Time{}, bytes.NewReader(blob))
+	case strings.HasSuffix(path, "/tags/list"):
+		repository := strings.TrimSuffix(path, "/tags/list")
+		tags := r.tags[repository]
+		if len(tags) == 0 {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		// serve one tag per page to exercise pagination
+		page := 0
+		_, _ = fmt.Sscanf(req.URL.Query().Get("page"), "%d", &page)
+		if page+1 < len(tags) {
+			w.Header().Set("Link", fmt.Sprintf(`</v2/%s/tags/list?page=%d>; rel="next"`, repository, page+1))
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"name": repository, "tags": tags[page : page+1]})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+var _ = Describe("ociClient", func() {
+	var (
+		stderr   *log.Logger
+		registry *testRegistry
+	)
+
+	BeforeEach(func() {
+		stderr = log.New(GinkgoWriter, "", 0)
+		registry = newTestRegistry()
+	})
+
+	AfterEach(func() {
+		registry.server.Close()
+	})
+
+	newClient := func(config download_clients.OCIConfiguration) download_clients.ProductDownloader {
+		config.Registry = registry.host()
+		config.Insecure = true
+		if config.DockerConfig == "" {
+			config.DockerConfig = filepath.Join(GinkgoT().TempDir(), "config.json")
+			Expect(os.WriteFile(config.DockerConfig, []byte(`{}`), 0600)).To(Succeed())
+		}
+
+		client, err := download_clients.NewOCIClient(config, stderr)
+		Expect(err).ToNot(HaveOccurred())
+		return client
+	}
+
+	Describe("NewOCIClient", func() {
+		It("requires a registry", func() {
+			_, err := download_clients.NewOCIClient(download_clients.OCIConfiguration{}, stderr)
+			Expect(err).To(MatchError(ContainSubstring("Field validation for 'Registry' failed on the 'required' tag")))
+		})
+
+		It("requires a password with a username", func() {
+			_, err := download_clients.NewOCIClient(download_clients.OCIConfiguration{
+				Registry: registry.host(),
+				Username: "username",
+			}, stderr)
+			Expect(err).To(MatchError(ContainSubstring(`the flag "oci-password" is required`)))
+		})
+
+		It("errors when the docker config does not exist", func() {
+			_, err := download_clients.NewOCIClient(download_clients.OCIConfiguration{
+				Registry:     registry.host(),
+				DockerConfig: "/does/not/exist/config.json",
+			}, stderr)
+			Expect(err).To(MatchError(ContainSubstring("could not load docker config '/does/not/exist/config.json'")))
+		})
+	})
+
+	Describe("GetAllProductVersions", func() {
+		It("lists the tags of the repository named after the slug", func() {
+			registry.push("tiles/cf", "2.13.5", nil, map[string]string{"srt.pivotal": "a"})
+			registry.push("tiles/cf", "2.13.6_LTS-T", nil, map[string]string{"srt.pivotal": "b"})
+
+			client := newClient(download_clients.OCIConfiguration{ProductPath: "/tiles/"})
+
+			versions, err := client.GetAllProductVersions("cf")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(versions).To(Equal([]string{"2.13.5", "2.13.6+LTS-T"}))
+		})
+
+		It("errors when the repository does not exist", func() {
+			client := newClient(download_clients.OCIConfiguration{})
+
+			_, err := client.GetAllProductVersions("cf")
+			Expect(err).To(MatchError(ContainSubstring("could not list the tags of cf")))
+		})
+	})
+
+	Describe("GetLatestProductFile and DownloadProductToFile", func() {
+		BeforeEach(func() {
+			registry.push("tiles/cf", "2.13.6_LTS-T", map[string]string{download_clients.OCISlugAnnotation: "cf"}, map[string]string{
+				"srt-2.13.6.pivotal": "small footprint",
+				"cf-2.13.6.pivotal":  "full footprint",
+			})
+		})
+
+		It("downloads the layer whose title matches the glob and verifies it against its digest", func() {
+			client := newClient(download_clients.OCIConfiguration{ProductPath: "tiles"})
+
+			fileArtifact, err := client.GetLatestProductFile("cf", "2.13.6+LTS-T", "srt-*.pivotal")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fileArtifact.Name()).To(Equal("srt-2.13.6.pivotal"))
+			Expect(fileArtifact.SHA256()).To(Equal(fmt.Sprintf("%x", sha256.Sum256([]byte("small footprint")))))
+
+			file, err := os.Create(filepath.Join(GinkgoT().TempDir(), "product.pivotal"))
+			Expect(err).ToNot(HaveOccurred())
+			defer file.Close()
+
+			err = client.DownloadProductToFile(fileArtifact, file)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(os.ReadFile(file.Name())).To(Equal([]byte("small footprint")))
+		})
+
+		It("supports ranged downloads", func() {
+			client := newClient(download_clients.OCIConfiguration{ProductPath: "tiles"})
+
+			fileArtifact, err := client.GetLatestProductFile("cf", "2.13.6+LTS-T", "cf-*.pivotal")
+			Expect(err).ToNot(HaveOccurred())
+
+			file, err := os.Create(filepath.Join(GinkgoT().TempDir(), "product.pivotal"))
+			Expect(err).ToNot(HaveOccurred())
+			defer file.Close()
+
+			buffer := &bytes.Buffer{}
+			err = download_clients.DownloadProductToFileInChunks(client, fileArtifact, file, 2, log.New(buffer, "", 0))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(buffer.String()).ToNot(ContainSubstring("does not support ranged downloads"))
+			Expect(os.ReadFile(file.Name())).To(Equal([]byte("full footprint")))
+		})
+
+		It("errors when the glob matches several files", func() {
+			client := newClient(download_clients.OCIConfiguration{ProductPath: "tiles"})
+
+			_, err := client.GetLatestProductFile("cf", "2.13.6+LTS-T", "*.pivotal")
+			Expect(err).To(MatchError(ContainSubstring("the glob '*.pivotal' matches multiple files")))
+		})
+
+		It("errors when the tag does not exist", func() {
+			client := newClient(download_clients.OCIConfiguration{ProductPath: "tiles"})
+
+			_, err := client.GetLatestProductFile("cf", "9.9.9", "*.pivotal")
+			Expect(err).To(MatchError("no artifact tagged 9.9.9 found in tiles/cf or cf"))
+		})
+
+		It("errors when the artifact is annotated with another slug", func() {
+			registry.push("tiles/p-healthwatch", "2.0.0", map[string]string{download_clients.OCISlugAnnotation: "cf"}, map[string]string{"healthwatch.pivotal": "a"})
+			client := newClient(download_clients.OCIConfiguration{ProductPath: "tiles"})
+
+			_, err := client.GetLatestProductFile("p-healthwatch", "2.0.0", "*.pivotal")
+			Expect(err).To(MatchError(`the artifact tiles/p-healthwatch:2.0.0 is annotated with product slug "cf", expected "p-healthwatch"`))
+		})
+
+		It("finds stemcells in the stemcell path", func() {
+			registry.push("stemcells/stemcells-ubuntu-jammy", "1.90", nil, map[string]string{"bosh-stemcell-1.90-vsphere.tgz": "stemcell"})
+			client := newClient(download_clients.OCIConfiguration{ProductPath: "tiles", StemcellPath: "stemcells"})
+
+			fileArtifact, err := client.GetLatestProductFile("stemcells-ubuntu-jammy", "1.90", "*vsphere*")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fileArtifact.Name()).To(Equal("bosh-stemcell-1.90-vsphere.tgz"))
+		})
+	})
+
+	When("the registry requires a token", func() {
+		BeforeEach(func() {
+			registry.username = "some-user"
+			registry.password = "some-password"
+			registry.push("cf", "2.0.0", nil, map[string]string{"cf.pivotal": "contents"})
+		})
+
+		It("requests a token with the credentials from the docker config", func() {
+			dockerConfig := filepath.Join(GinkgoT().TempDir(), "config.json")
+			auth := base64.StdEncoding.EncodeToString([]byte("some-user:some-password"))
+			Expect(os.WriteFile(dockerConfig, []byte(fmt.Sprintf(`{"auths": {%q: {"auth": %q}}}`, registry.host(), auth)), 0600)).To(Succeed())
+
+			client := newClient(download_clients.OCIConfiguration{DockerConfig: dockerConfig})
+
+			versions, err := client.GetAllProductVersions("cf")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(versions).To(Equal([]string{"2.0.0"}))
+
+			_, err = client.GetLatestProductFile("cf", "2.0.0", "*.pivotal")
+			Expect(err).ToNot(HaveOccurred())
+
+			var tokenRequests int
+			for _, request := range registry.requests {
+				if request == "GET /token" {
+					tokenRequests++
+				}
+			}
+			Expect(tokenRequests).To(Equal(1), "the token is cached for the repository")
+		})
+
+		It("errors when the credentials are rejected", func() {
+			client := newClient(download_clients.OCIConfiguration{Username: "some-user", Password: "wrong"})
+
+			_, err := client.GetAllProductVersions("cf")
+			Expect(err).To(MatchError(ContainSubstring("401 Unauthorized")))
+		})
+	})
+})
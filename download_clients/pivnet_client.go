@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"net/http"
 	"os"
 	"path"
 	"path/filepath"
@@ -171,6 +172,23 @@ func (p *pivnetClient) DownloadProductToFile(fa FileArtifacter, file *os.File) e
 	return nil
 }
 
+func (p *pivnetClient) fileRanger(fa FileArtifacter) (fileRanger, error) {
+	fileArtifact := fa.(*PivnetFileArtifact)
+
+	downloadLink, err := fileArtifact.productFile.DownloadLink()
+	if err != nil {
+		return nil, fmt.Errorf("cannot retrieve download link: %w", err)
+	}
+
+	fetcher := pivnet.NewProductFileLinkFetcher(downloadLink, p.client)
+	downloadClient := &http.Client{}
+	if p.client.HTTP != nil {
+		downloadClient.Transport = p.client.HTTP.Transport
+	}
+
+	return newHTTPFileRanger(downloadClient, fetcher.NewDownloadLink)
+}
+
 func (p *pivnetClient) GetLatestStemcellForProduct(fa FileArtifacter, _ string, stemcellSlug string) (StemcellArtifacter, error) {
 	fileArtifact := fa.(*PivnetFileArtifact)
 	dependencies, err := p.downloader.ReleaseDependencies(fileArtifact.slug, fileArtifact.releaseID)
@@ -0,0 +1,152 @@
+package download_clients
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// The helpers below implement the `[slug,version]filename` naming convention
+// used for artifacts persisted from Pivotal Network by download-product.
+// They operate on plain lists of file paths so any source that can list its
+// files (a blobstore bucket, a directory, an HTTP index) can share them.
+
+// checksumSuffix is appended to the name of a file to name the sidecar
+// holding its SHA256, in the format written by sha256sum.
+const checksumSuffix = ".sha256"
+
+var checksumRegex = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// parseChecksum returns the SHA256 from the first field of the contents,
+// which is either a bare checksum or a line written by sha256sum.
+func parseChecksum(source, contents string) (string, error) {
+	fields := strings.Fields(contents)
+	if len(fields) == 0 || !checksumRegex.MatchString(strings.ToLower(fields[0])) {
+		return "", fmt.Errorf("could not find a SHA256 checksum in %s", source)
+	}
+
+	return strings.ToLower(fields[0]), nil
+}
+
+func prefixedFileVersions(files []string, slug, path string) ([]string, error) {
+	productFileCompiledRegex := regexp.MustCompile(
+		fmt.Sprintf(`^/?%s/?\[%s,(.*?)\]`,
+			regexp.QuoteMeta(strings.Trim(path, "/")),
+			slug,
+		),
+	)
+
+	var versions []string
+	versionFound := make(map[string]bool)
+	for _, fileName := range files {
+		match := productFileCompiledRegex.FindStringSubmatch(fileName)
+		if match != nil {
+			version := match[1]
+			if !versionFound[version] {
+				versions = append(versions, version)
+				versionFound[version] = true
+			}
+		}
+	}
+
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("no files matching pivnet-product-slug %s found", slug)
+	}
+
+	return versions, nil
+}
+
+func prefixedProductFile(files []string, productPath, stemcellPath, slug, version, glob string) (string, error) {
+	validFile := regexp.MustCompile(
+		fmt.Sprintf(`^/?(%s|%s)/?\[%s,%s\]`,
+			regexp.QuoteMeta(strings.Trim(productPath, "/")),
+			regexp.QuoteMeta(strings.Trim(stemcellPath, "/")),
+			slug,
+			regexp.QuoteMeta(version),
+		),
+	)
+	var prefixedFilepaths []string
+	var globMatchedFilepaths []string
+
+	for _, f := range files {
+		if strings.HasSuffix(f, checksumSuffix) {
+			continue
+		}
+
+		if validFile.MatchString(f) {
+			prefixedFilepaths = append(prefixedFilepaths, f)
+		}
+	}
+
+	if len(prefixedFilepaths) == 0 {
+		return "", fmt.Errorf("no product files with expected prefix [%s,%s] found. Please ensure the file you're trying to download was initially persisted from Pivotal Network net using an appropriately configured download-product command", slug, version)
+	}
+
+	for _, f := range prefixedFilepaths {
+		removePrefixRegex := regexp.MustCompile(`^\[.*\]`)
+		baseFilename := removePrefixRegex.ReplaceAllString(filepath.Base(f), "")
+
+		matched, _ := filepath.Match(glob, baseFilename)
+		if matched {
+			globMatchedFilepaths = append(globMatchedFilepaths, f)
+		}
+	}
+
+	if len(globMatchedFilepaths) > 1 {
+		return "", fmt.Errorf("the glob '%s' matches multiple files. Write your glob to match exactly one of the following:\n  %s", glob, strings.Join(globMatchedFilepaths, "\n  "))
+	}
+
+	if len(globMatchedFilepaths) == 0 {
+		availableFiles := strings.Join(prefixedFilepaths, ", ")
+		if availableFiles == "" {
+			availableFiles = "none"
+		}
+		return "", fmt.Errorf("the glob '%s' matches no file\navailable files: %s", glob, availableFiles)
+	}
+
+	return globMatchedFilepaths[0], nil
+}
+
+// latestCompatibleStemcell finds the newest stemcell version, as listed by
+// stemcellVersions, that satisfies the stemcell criteria of the downloaded
+// product, or its criteria for the stemcell slug when one is given.
+func latestCompatibleStemcell(downloadedProductFileName string, stemcellSlug string, source string, stemcellVersions func(slug string) ([]string, error)) (StemcellArtifacter, error) {
+	definedStemcell, err := stemcellFromProduct(downloadedProductFileName, stemcellSlug)
+	if err != nil {
+		return nil, err
+	}
+
+	definedMajor, definedPatch, err := stemcellVersionPartsFromString(definedStemcell.Version())
+	if err != nil {
+		return nil, err
+	}
+
+	allStemcellVersions, err := stemcellVersions(definedStemcell.Slug())
+	if err != nil {
+		return nil, fmt.Errorf("could not find stemcells on %s: %s", source, err)
+	}
+
+	var filteredVersions []string
+	for _, version := range allStemcellVersions {
+		major, patch, _ := stemcellVersionPartsFromString(version)
+
+		if major == definedMajor && patch >= definedPatch {
+			filteredVersions = append(filteredVersions, version)
+		}
+	}
+
+	if len(filteredVersions) == 0 {
+		return nil, fmt.Errorf("no versions could be found equal to or greater than %s", definedStemcell.Version())
+	}
+
+	latestVersion, err := getLatestStemcellVersion(filteredVersions)
+	if err != nil {
+		return nil, err
+	}
+
+	return &stemcell{
+		version: latestVersion,
+		slug:    definedStemcell.Slug(),
+	}, nil
+}
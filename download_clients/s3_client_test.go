@@ -234,7 +234,9 @@ func (m mockLocation) ItemByURL(url *url.URL) (stow.Item, error) {
 }
 
 type mockContainer struct {
-	item mockItem
+	item      mockItem
+	itemError error
+	uploaded  map[string]string
 }
 
 func (m mockContainer) ID() string {
@@ -244,6 +246,9 @@ func (m mockContainer) Name() string {
 	return ""
 }
 func (m mockContainer) Item(id string) (stow.Item, error) {
+	if m.itemError != nil {
+		return nil, m.itemError
+	}
 	return m.item, nil
 }
 func (m mockContainer) Items(prefix, cursor string, count int) ([]stow.Item, string, error) {
@@ -253,6 +258,13 @@ func (m mockContainer) RemoveItem(id string) error {
 	return nil
 }
 func (m mockContainer) Put(name string, r io.Reader, size int64, metadata map[string]interface{}) (stow.Item, error) {
+	if m.uploaded != nil {
+		contents, err := io.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		m.uploaded[name] = string(contents)
+	}
 	return mockItem{}, nil
 }
 
@@ -260,6 +272,8 @@ type mockItem struct {
 	stow.Item
 	idString  string
 	fileError error
+	contents  string
+	metadata  map[string]interface{}
 }
 
 func newMockItem(idString string) mockItem {
@@ -273,9 +287,17 @@ func (m mockItem) Open() (io.ReadCloser, error) {
 		return nil, m.fileError
 	}
 
+	if m.contents != "" {
+		return io.NopCloser(strings.NewReader(m.contents)), nil
+	}
+
 	return io.NopCloser(strings.NewReader("hello world")), nil
 }
 
+func (m mockItem) Metadata() (map[string]interface{}, error) {
+	return m.metadata, nil
+}
+
 func (m mockItem) ID() string {
 	return m.idString
 }
@@ -285,6 +307,12 @@ func (m mockItem) Size() (int64, error) {
 }
 
 func createPivotalFile(productFileName, stemcellName, stemcellVersion string) string {
+	return createMultiStemcellPivotalFile(productFileName, stemcellName, stemcellVersion, nil)
+}
+
+// createMultiStemcellPivotalFile creates a tile with additional stemcell
+// criteria, mapping each operating system to its stemcell version.
+func createMultiStemcellPivotalFile(productFileName, stemcellName, stemcellVersion string, additionalStemcells map[string]string) string {
 	tempfile, err := os.CreateTemp("", productFileName)
 	Expect(err).ToNot(HaveOccurred())
 
@@ -296,11 +324,13 @@ func createPivotalFile(productFileName, stemcellName, stemcellVersion string) st
 	Expect(err).ToNot(HaveOccurred())
 
 	context := struct {
-		StemcellName    string
-		StemcellVersion string
+		StemcellName        string
+		StemcellVersion     string
+		AdditionalStemcells map[string]string
 	}{
-		StemcellName:    stemcellName,
-		StemcellVersion: stemcellVersion,
+		StemcellName:        stemcellName,
+		StemcellVersion:     stemcellVersion,
+		AdditionalStemcells: additionalStemcells,
 	}
 
 	tmpl, err := template.New("example-product").Parse(string(contents))
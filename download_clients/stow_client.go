@@ -1,12 +1,11 @@
 package download_clients
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"os"
-	"path/filepath"
-	"regexp"
 	"strings"
 
 	"github.com/cheggaaa/pb/v3"
@@ -73,45 +72,35 @@ func (s stowClient) getAllProductVersionsFromPath(slug, path string) ([]string,
 		return nil, err
 	}
 
-	productFileCompiledRegex := regexp.MustCompile(
-		fmt.Sprintf(`^/?%s/?\[%s,(.*?)\]`,
-			regexp.QuoteMeta(strings.Trim(path, "/")),
-			slug,
-		),
-	)
-
-	var versions []string
-	versionFound := make(map[string]bool)
-	for _, fileName := range files {
-		match := productFileCompiledRegex.FindStringSubmatch(fileName)
-		if match != nil {
-			version := match[1]
-			if !versionFound[version] {
-				versions = append(versions, version)
-				versionFound[version] = true
-			}
-		}
+	return prefixedFileVersions(files, slug, path)
+}
+
+func (s *stowClient) listFiles() ([]string, error) {
+	items, err := s.listItems()
+	if err != nil {
+		return nil, err
 	}
 
-	if len(versions) == 0 {
-		return nil, fmt.Errorf("no files matching pivnet-product-slug %s found", slug)
+	var paths []string
+	for _, item := range items {
+		paths = append(paths, item.ID())
 	}
 
-	return versions, nil
+	return paths, nil
 }
 
-func (s *stowClient) listFiles() ([]string, error) {
+func (s *stowClient) listItems() ([]stow.Item, error) {
 	container, err := s.getContainer()
 	if err != nil {
 		return nil, err
 	}
 
-	var paths []string
+	var items []stow.Item
 	err = s.stower.Walk(container, stow.NoPrefix, 100, func(item stow.Item, err error) error {
 		if err != nil {
 			return err
 		}
-		paths = append(paths, item.ID())
+		items = append(items, item)
 		return nil
 	})
 
@@ -119,11 +108,11 @@ func (s *stowClient) listFiles() ([]string, error) {
 		return nil, err
 	}
 
-	if len(paths) == 0 {
+	if len(items) == 0 {
 		return nil, fmt.Errorf("bucket '%s' contains no files", s.bucket)
 	}
 
-	return paths, nil
+	return items, nil
 }
 
 func (s *stowClient) getContainer() (stow.Container, error) {
@@ -152,55 +141,63 @@ func (s *stowClient) getContainer() (stow.Container, error) {
 }
 
 func (s stowClient) GetLatestProductFile(slug, version, glob string) (FileArtifacter, error) {
-	files, err := s.listFiles()
+	items, err := s.listItems()
 	if err != nil {
 		return nil, err
 	}
 
-	validFile := regexp.MustCompile(
-		fmt.Sprintf(`^/?(%s|%s)/?\[%s,%s\]`,
-			regexp.QuoteMeta(strings.Trim(s.productPath, "/")),
-			regexp.QuoteMeta(strings.Trim(s.stemcellPath, "/")),
-			slug,
-			regexp.QuoteMeta(version),
-		),
-	)
-	var prefixedFilepaths []string
-	var globMatchedFilepaths []string
-
-	for _, f := range files {
-		if validFile.MatchString(f) {
-			prefixedFilepaths = append(prefixedFilepaths, f)
-		}
+	var files []string
+	itemsByName := map[string]stow.Item{}
+	for _, item := range items {
+		files = append(files, item.ID())
+		itemsByName[item.ID()] = item
 	}
 
-	if len(prefixedFilepaths) == 0 {
-		return nil, fmt.Errorf("no product files with expected prefix [%s,%s] found. Please ensure the file you're trying to download was initially persisted from Pivotal Network net using an appropriately configured download-product command", slug, version)
+	name, err := prefixedProductFile(files, s.productPath, s.stemcellPath, slug, version, glob)
+	if err != nil {
+		return nil, err
 	}
 
-	for _, f := range prefixedFilepaths {
-		removePrefixRegex := regexp.MustCompile(`^\[.*\]`)
-		baseFilename := removePrefixRegex.ReplaceAllString(filepath.Base(f), "")
+	sha256, err := s.checksum(itemsByName, name)
+	if err != nil {
+		return nil, err
+	}
 
-		matched, _ := filepath.Match(glob, baseFilename)
-		if matched {
-			globMatchedFilepaths = append(globMatchedFilepaths, f)
+	return &stowFileArtifact{name: name, sha256: sha256, source: s.kind}, nil
+}
+
+// checksum returns the SHA256 of the named file, as recorded in a .sha256
+// sidecar next to it or in a sha256 metadata entry of the file itself.
+// An empty checksum is returned when neither is present.
+func (s stowClient) checksum(itemsByName map[string]stow.Item, name string) (string, error) {
+	sidecar, ok := itemsByName[name+checksumSuffix]
+	if ok {
+		reader, err := sidecar.Open()
+		if err != nil {
+			return "", fmt.Errorf("could not read checksum '%s': %w", sidecar.ID(), err)
+		}
+		defer reader.Close()
+
+		contents, err := io.ReadAll(io.LimitReader(reader, 4096))
+		if err != nil {
+			return "", fmt.Errorf("could not read checksum '%s': %w", sidecar.ID(), err)
 		}
+
+		return parseChecksum(sidecar.ID(), string(contents))
 	}
 
-	if len(globMatchedFilepaths) > 1 {
-		return nil, fmt.Errorf("the glob '%s' matches multiple files. Write your glob to match exactly one of the following:\n  %s", glob, strings.Join(globMatchedFilepaths, "\n  "))
+	metadata, err := itemsByName[name].Metadata()
+	if err != nil {
+		return "", fmt.Errorf("could not read metadata of '%s': %w", name, err)
 	}
 
-	if len(globMatchedFilepaths) == 0 {
-		availableFiles := strings.Join(prefixedFilepaths, ", ")
-		if availableFiles == "" {
-			availableFiles = "none"
+	for key, value := range metadata {
+		if strings.EqualFold(key, "sha256") {
+			return parseChecksum(name+" metadata", fmt.Sprint(value))
 		}
-		return nil, fmt.Errorf("the glob '%s' matches no file\navailable files: %s", glob, availableFiles)
 	}
 
-	return &stowFileArtifact{name: globMatchedFilepaths[0], source: s.kind}, nil
+	return "", nil
 }
 
 func (s stowClient) DownloadProductToFile(fa FileArtifacter, destinationFile *os.File) error {
@@ -219,6 +216,54 @@ func (s stowClient) DownloadProductToFile(fa FileArtifacter, destinationFile *os
 	return nil
 }
 
+func (s stowClient) fileRanger(fa FileArtifacter) (fileRanger, error) {
+	container, err := s.getContainer()
+	if err != nil {
+		return nil, err
+	}
+
+	item, err := container.Item(fa.Name())
+	if err != nil {
+		return nil, err
+	}
+
+	itemRanger, ok := item.(stow.ItemRanger)
+	if !ok {
+		return nil, errRangesUnsupported
+	}
+
+	size, err := item.Size()
+	if err != nil {
+		return nil, err
+	}
+
+	etag, _ := item.ETag()
+
+	return stowFileRanger{
+		item:     itemRanger,
+		size:     size,
+		identity: etag,
+	}, nil
+}
+
+type stowFileRanger struct {
+	item     stow.ItemRanger
+	size     int64
+	identity string
+}
+
+func (s stowFileRanger) Size() int64 {
+	return s.size
+}
+
+func (s stowFileRanger) Identity() string {
+	return s.identity
+}
+
+func (s stowFileRanger) OpenRange(start, end int64) (io.ReadCloser, error) {
+	return s.item.OpenRange(uint64(start), uint64(end))
+}
+
 func (s *stowClient) initializeBlobReader(filename string) (blobToRead io.ReadCloser, fileSize int64, err error) {
 	container, err := s.getContainer()
 	if err != nil {
@@ -239,8 +284,12 @@ func (s *stowClient) initializeBlobReader(filename string) (blobToRead io.ReadCl
 }
 
 func (s stowClient) startProgressBar(size int64, item io.Reader) (*pb.ProgressBar, io.Reader) {
+	return startProgressBar(s.stderr, size, item)
+}
+
+func startProgressBar(stderr *log.Logger, size int64, item io.Reader) (*pb.ProgressBar, io.Reader) {
 	progressBar := pb.Default.New(0)
-	progressBar.SetWriter(s.stderr.Writer())
+	progressBar.SetWriter(stderr.Writer())
 	progressBar.Set(pb.Bytes, true)
 	progressBar.SetTotal(size)
 	progressBar.SetMaxWidth(80)
@@ -254,62 +303,87 @@ func (s stowClient) streamBufferToFile(destinationFile *os.File, wrappedBlobRead
 	return err
 }
 
-func (s stowClient) GetLatestStemcellForProduct(_ FileArtifacter, downloadedProductFileName string, stemcellSlug string) (StemcellArtifacter, error) {
-	definedStemcell, err := stemcellFromProduct(downloadedProductFileName)
+// FileExists reports whether an object with the name exists in the bucket.
+func (s stowClient) FileExists(name string) (bool, error) {
+	container, err := s.getContainer()
 	if err != nil {
-		return nil, err
+		return false, err
 	}
 
-	definedMajor, definedPatch, err := stemcellVersionPartsFromString(definedStemcell.Version())
-	if err != nil {
-		return nil, err
+	_, err = container.Item(name)
+	if errors.Is(err, stow.ErrNotFound) {
+		return false, nil
 	}
-
-	allStemcellVersions, err := s.getAllProductVersionsFromPath(definedStemcell.Slug(), s.stemcellPath)
 	if err != nil {
-		return nil, fmt.Errorf("could not find stemcells on %s: %s", s.kind, err)
+		return false, fmt.Errorf("could not check for '%s' in bucket '%s': %w", name, s.bucket, err)
 	}
 
-	var filteredVersions []string
-	for _, version := range allStemcellVersions {
-		major, patch, _ := stemcellVersionPartsFromString(version)
+	return true, nil
+}
 
-		if major == definedMajor && patch >= definedPatch {
-			filteredVersions = append(filteredVersions, version)
-		}
+// UploadFile stores the contents of the reader in the bucket under the name.
+func (s stowClient) UploadFile(name string, reader io.Reader, size int64) error {
+	container, err := s.getContainer()
+	if err != nil {
+		return err
 	}
 
-	if len(filteredVersions) == 0 {
-		return nil, fmt.Errorf("no versions could be found equal to or greater than %s", definedStemcell.Version())
-	}
+	progressBar, wrappedReader := s.startProgressBar(size, reader)
+	defer progressBar.Finish()
 
-	latestVersion, err := getLatestStemcellVersion(filteredVersions)
+	_, err = container.Put(name, wrappedReader, size, nil)
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("could not upload '%s' to bucket '%s': %w", name, s.bucket, err)
 	}
 
-	return &stemcell{
-		version: latestVersion,
-		slug:    definedStemcell.Slug(),
-	}, nil
+	return nil
 }
 
-func stemcellFromProduct(filename string) (*stemcell, error) {
+func (s stowClient) GetLatestStemcellForProduct(_ FileArtifacter, downloadedProductFileName string, stemcellSlug string) (StemcellArtifacter, error) {
+	return latestCompatibleStemcell(downloadedProductFileName, stemcellSlug, s.kind, func(slug string) ([]string, error) {
+		return s.getAllProductVersionsFromPath(slug, s.stemcellPath)
+	})
+}
+
+// stemcellNameToPivnetProductName maps the operating system of a stemcell
+// criterion to the Pivotal Network slug of its stemcells.
+var stemcellNameToPivnetProductName = map[string]string{
+	"ubuntu-jammy":  "stemcells-ubuntu-jammy",
+	"ubuntu-xenial": "stemcells-ubuntu-xenial",
+	"ubuntu-trusty": "stemcells",
+	"windows2016":   "stemcells-windows-server",
+	"windows1803":   "stemcells-windows-server",
+	"windows2019":   "stemcells-windows-server",
+	"windows2025":   "stemcells-windows-server",
+	"ubuntu-noble":  "stemcells-ubuntu-noble",
+}
+
+// StemcellSlugForOS returns the Pivotal Network slug of the stemcells for the
+// operating system of a stemcell criterion, or an empty string when unknown.
+func StemcellSlugForOS(os string) string {
+	return stemcellNameToPivnetProductName[os]
+}
+
+// stemcellFromProduct returns the stemcell criterion of the tile. When a
+// stemcell slug is given, the criterion for that slug is picked among the
+// stemcell criteria and additional stemcell criteria of the tile. A slug no
+// criterion maps to, such as a custom one, gets the stemcell criterion.
+func stemcellFromProduct(filename string, stemcellSlug string) (*stemcell, error) {
 	metadataExtractor := extractor.MetadataExtractor{}
 	metadata, err := metadataExtractor.ExtractFromFile(filename)
 	if err != nil {
 		return nil, fmt.Errorf("could not find the appropriate stemcell associated with the tile %q: %s", filename, err)
 	}
 
-	stemcellNameToPivnetProductName := map[string]string{
-		"ubuntu-jammy":  "stemcells-ubuntu-jammy",
-		"ubuntu-xenial": "stemcells-ubuntu-xenial",
-		"ubuntu-trusty": "stemcells",
-		"windows2016":   "stemcells-windows-server",
-		"windows1803":   "stemcells-windows-server",
-		"windows2019":   "stemcells-windows-server",
-		"windows2025":   "stemcells-windows-server",
-		"ubuntu-noble":  "stemcells-ubuntu-noble",
+	if stemcellSlug != "" {
+		for _, criterion := range metadata.AllStemcellCriteria() {
+			if stemcellNameToPivnetProductName[criterion.OS] == stemcellSlug {
+				return &stemcell{
+					slug:    stemcellSlug,
+					version: criterion.Version,
+				}, nil
+			}
+		}
 	}
 
 	return &stemcell{
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strings"
 
 	"github.com/graymeta/stow"
 
@@ -206,6 +207,65 @@ var _ = Describe("stowClient", func() {
 			Entry("without a leading slash", "some-path/"),
 			Entry("without a leading or trailing slash", "some-path"),
 		)
+
+		Describe("the checksum of the file", func() {
+			var sha string
+
+			BeforeEach(func() {
+				sha = "b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9"
+			})
+
+			It("is read from the .sha256 sidecar next to the file", func() {
+				sidecar := newMockItem("[product-slug,1.1.1]pcf-vsphere-2.1-build.348.ova.sha256")
+				sidecar.contents = strings.ToUpper(sha) + "  [product-slug,1.1.1]pcf-vsphere-2.1-build.348.ova\n"
+
+				stower := newMockStower([]mockItem{
+					newMockItem("[product-slug,1.1.1]pcf-vsphere-2.1-build.348.ova"),
+					sidecar,
+				})
+				client := download_clients.NewStowClient(stower, nil, stow.ConfigMap{}, "", "", "", "bucket")
+
+				fileArtifact, err := client.GetLatestProductFile("product-slug", "1.1.1", "*")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(fileArtifact.Name()).To(Equal("[product-slug,1.1.1]pcf-vsphere-2.1-build.348.ova"))
+				Expect(fileArtifact.SHA256()).To(Equal(sha))
+			})
+
+			It("is read from the metadata of the file", func() {
+				item := newMockItem("[product-slug,1.1.1]pcf-vsphere-2.1-build.348.ova")
+				item.metadata = map[string]interface{}{"Sha256": sha}
+
+				stower := newMockStower([]mockItem{item})
+				client := download_clients.NewStowClient(stower, nil, stow.ConfigMap{}, "", "", "", "bucket")
+
+				fileArtifact, err := client.GetLatestProductFile("product-slug", "1.1.1", "*.ova")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(fileArtifact.SHA256()).To(Equal(sha))
+			})
+
+			It("is empty when there is neither", func() {
+				stower := newMockStower([]mockItem{newMockItem("[product-slug,1.1.1]pcf-vsphere-2.1-build.348.ova")})
+				client := download_clients.NewStowClient(stower, nil, stow.ConfigMap{}, "", "", "", "bucket")
+
+				fileArtifact, err := client.GetLatestProductFile("product-slug", "1.1.1", "*.ova")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(fileArtifact.SHA256()).To(BeEmpty())
+			})
+
+			It("errors when the sidecar does not hold a checksum", func() {
+				sidecar := newMockItem("[product-slug,1.1.1]pcf-vsphere-2.1-build.348.ova.sha256")
+				sidecar.contents = "not-a-checksum"
+
+				stower := newMockStower([]mockItem{
+					newMockItem("[product-slug,1.1.1]pcf-vsphere-2.1-build.348.ova"),
+					sidecar,
+				})
+				client := download_clients.NewStowClient(stower, nil, stow.ConfigMap{}, "", "", "", "bucket")
+
+				_, err := client.GetLatestProductFile("product-slug", "1.1.1", "*.ova")
+				Expect(err).To(MatchError("could not find a SHA256 checksum in [product-slug,1.1.1]pcf-vsphere-2.1-build.348.ova.sha256"))
+			})
+		})
 	})
 
 	Describe("DownloadProductToFile", func() {
@@ -384,4 +444,48 @@ var _ = Describe("stowClient", func() {
 			})
 		})
 	})
+
+	Describe("FileExists", func() {
+		It("reports whether the item is in the bucket", func() {
+			stower := &mockStower{
+				location: mockLocation{container: &mockContainer{item: newMockItem("[product-slug,1.1.1]product.pivotal")}},
+			}
+			client := download_clients.NewStowClient(stower, stderr, nil, "", "", "", "")
+
+			exists, err := client.FileExists("[product-slug,1.1.1]product.pivotal")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(exists).To(BeTrue())
+
+			stower.location = mockLocation{container: &mockContainer{itemError: stow.ErrNotFound}}
+			exists, err = client.FileExists("[product-slug,1.1.1]product.pivotal")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(exists).To(BeFalse())
+		})
+
+		It("errors when the bucket cannot be checked", func() {
+			stower := &mockStower{
+				location: mockLocation{container: &mockContainer{itemError: errors.New("access denied")}},
+			}
+			client := download_clients.NewStowClient(stower, stderr, nil, "", "", "", "")
+
+			_, err := client.FileExists("[product-slug,1.1.1]product.pivotal")
+			Expect(err).To(MatchError(ContainSubstring("access denied")))
+		})
+	})
+
+	Describe("UploadFile", func() {
+		It("puts the contents in the bucket", func() {
+			uploaded := map[string]string{}
+			stower := &mockStower{
+				location: mockLocation{container: &mockContainer{uploaded: uploaded}},
+			}
+			client := download_clients.NewStowClient(stower, stderr, nil, "", "", "", "")
+
+			err := client.UploadFile("tiles/[product-slug,1.1.1]product.pivotal", strings.NewReader("some-contents"), 13)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(uploaded).To(Equal(map[string]string{
+				"tiles/[product-slug,1.1.1]product.pivotal": "some-contents",
+			}))
+		})
+	})
 })
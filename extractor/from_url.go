@@ -48,6 +48,15 @@ func (me *MetadataExtractor) Head(url string) (*http.Response, error) {
 var _ ranger.HTTPClient = &MetadataExtractor{}
 
 func (me *MetadataExtractor) ExtractFromURL(productURL string) (*Metadata, error) {
+	zipReader, err := me.zipReaderFromURL(productURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return fromZipFiles(zipReader.File)
+}
+
+func (me *MetadataExtractor) zipReaderFromURL(productURL string) (*zip.Reader, error) {
 	url, err := url.Parse(productURL)
 	if err != nil {
 		return nil, err
@@ -69,5 +78,5 @@ func (me *MetadataExtractor) ExtractFromURL(productURL string) (*Metadata, error
 		return nil, fmt.Errorf("could not create zip reader: %w", err)
 	}
 
-	return fromZipFiles(zipReader.File)
+	return zipReader, nil
 }
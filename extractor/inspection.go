@@ -0,0 +1,196 @@
+package extractor
+
+import (
+	"archive/zip"
+	"crypto/sha1"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Inspection describes what a tile bundles and requires, beyond the name
+// and version of its Metadata.
+type Inspection struct {
+	Name                     string              `json:"name"                                  yaml:"name"`
+	Version                  string              `json:"version"                               yaml:"version"`
+	MinimumOpsManagerVersion string              `json:"minimum_ops_manager_version,omitempty" yaml:"minimum_ops_manager_version,omitempty"`
+	StemcellCriteria         []StemcellCriterion `json:"stemcell_criteria"                     yaml:"stemcell_criteria"`
+	Releases                 []InspectedRelease  `json:"releases"                              yaml:"releases"`
+	RequiredProducts         []RequiredProduct   `json:"required_products"                     yaml:"required_products"`
+	RuntimeConfigs           []string            `json:"runtime_configs"                       yaml:"runtime_configs"`
+	JobTypes                 []InspectedJobType  `json:"job_types"                             yaml:"job_types"`
+	Errands                  []InspectedErrand   `json:"errands"                               yaml:"errands"`
+}
+
+type InspectedRelease struct {
+	Name    string `json:"name"           yaml:"name"`
+	Version string `json:"version"        yaml:"version"`
+	File    string `json:"file"           yaml:"file"`
+	SHA1    string `json:"sha1,omitempty" yaml:"sha1,omitempty"`
+}
+
+type RequiredProduct struct {
+	Name    string `json:"name"    yaml:"name"`
+	Version string `json:"version" yaml:"version"`
+}
+
+type InspectedJobType struct {
+	Name             string `json:"name"                        yaml:"name"`
+	Label            string `json:"label,omitempty"             yaml:"label,omitempty"`
+	DefaultInstances *int   `json:"default_instances,omitempty" yaml:"default_instances,omitempty"`
+	Errand           bool   `json:"errand,omitempty"            yaml:"errand,omitempty"`
+}
+
+type InspectedErrand struct {
+	Name  string `json:"name"  yaml:"name"`
+	Phase string `json:"phase" yaml:"phase"`
+}
+
+type inspectedMetadata struct {
+	MetadataVersion string `yaml:"metadata_version"`
+	Releases        []struct {
+		Name    string `yaml:"name"`
+		Version string `yaml:"version"`
+		File    string `yaml:"file"`
+		SHA1    string `yaml:"sha1"`
+	} `yaml:"releases"`
+	RequiresProductVersions []RequiredProduct `yaml:"requires_product_versions"`
+	RuntimeConfigs          []struct {
+		Name string `yaml:"name"`
+	} `yaml:"runtime_configs"`
+	JobTypes []struct {
+		Name               string `yaml:"name"`
+		ResourceLabel      string `yaml:"resource_label"`
+		Errand             bool   `yaml:"errand"`
+		InstanceDefinition struct {
+			Default interface{} `yaml:"default"`
+		} `yaml:"instance_definition"`
+	} `yaml:"job_types"`
+	PostDeployErrands []struct {
+		Name string `yaml:"name"`
+	} `yaml:"post_deploy_errands"`
+	PreDeleteErrands []struct {
+		Name string `yaml:"name"`
+	} `yaml:"pre_delete_errands"`
+}
+
+// InspectFile inspects a tile on disk. With releaseSHA1s, the SHA1 of the
+// releases the metadata does not provide one for is computed from the
+// bundled release files.
+func (me *MetadataExtractor) InspectFile(productPath string, releaseSHA1s bool) (*Inspection, error) {
+	zipReader, err := zip.OpenReader(productPath)
+	if err != nil {
+		return nil, err
+	}
+
+	defer zipReader.Close()
+
+	return inspect(zipReader.File, releaseSHA1s)
+}
+
+// InspectURL inspects a tile served over HTTP with range requests, so that
+// only the metadata is downloaded, unless release SHA1s have to be computed.
+func (me *MetadataExtractor) InspectURL(productURL string, releaseSHA1s bool) (*Inspection, error) {
+	zipReader, err := me.zipReaderFromURL(productURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return inspect(zipReader.File, releaseSHA1s)
+}
+
+func inspect(files []*zip.File, releaseSHA1s bool) (*Inspection, error) {
+	metadata, err := fromZipFiles(files)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw inspectedMetadata
+	err = yaml.Unmarshal(metadata.Raw, &raw)
+	if err != nil {
+		return nil, fmt.Errorf("could not extract product metadata: %s", err)
+	}
+
+	inspection := &Inspection{
+		Name:                     metadata.Name,
+		Version:                  metadata.Version,
+		MinimumOpsManagerVersion: raw.MetadataVersion,
+		StemcellCriteria:         metadata.AllStemcellCriteria(),
+		RequiredProducts:         raw.RequiresProductVersions,
+	}
+
+	for _, release := range raw.Releases {
+		inspected := InspectedRelease{
+			Name:    release.Name,
+			Version: release.Version,
+			File:    release.File,
+			SHA1:    release.SHA1,
+		}
+
+		if inspected.SHA1 == "" && releaseSHA1s {
+			inspected.SHA1, err = releaseSHA1(files, release.File)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		inspection.Releases = append(inspection.Releases, inspected)
+	}
+
+	for _, runtimeConfig := range raw.RuntimeConfigs {
+		inspection.RuntimeConfigs = append(inspection.RuntimeConfigs, runtimeConfig.Name)
+	}
+
+	for _, jobType := range raw.JobTypes {
+		inspected := InspectedJobType{
+			Name:   jobType.Name,
+			Label:  jobType.ResourceLabel,
+			Errand: jobType.Errand,
+		}
+
+		// defaults can be expressions, such as (( ..cf.some.value )),
+		// which are only known once the product is configured
+		if instances, ok := jobType.InstanceDefinition.Default.(int); ok {
+			inspected.DefaultInstances = &instances
+		}
+
+		inspection.JobTypes = append(inspection.JobTypes, inspected)
+	}
+
+	for _, errand := range raw.PostDeployErrands {
+		inspection.Errands = append(inspection.Errands, InspectedErrand{Name: errand.Name, Phase: "post-deploy"})
+	}
+	for _, errand := range raw.PreDeleteErrands {
+		inspection.Errands = append(inspection.Errands, InspectedErrand{Name: errand.Name, Phase: "pre-delete"})
+	}
+
+	return inspection, nil
+}
+
+func releaseSHA1(files []*zip.File, releaseFile string) (string, error) {
+	for _, file := range files {
+		if path.Base(file.Name) != releaseFile || !strings.Contains(file.Name, "releases/") {
+			continue
+		}
+
+		reader, err := file.Open()
+		if err != nil {
+			return "", fmt.Errorf("could not read release %s: %w", releaseFile, err)
+		}
+		defer reader.Close()
+
+		hash := sha1.New()
+		_, err = io.Copy(hash, reader)
+		if err != nil {
+			return "", fmt.Errorf("could not read release %s: %w", releaseFile, err)
+		}
+
+		return fmt.Sprintf("%x", hash.Sum(nil)), nil
+	}
+
+	return "", fmt.Errorf("could not find release %s in the product: %w", releaseFile, os.ErrNotExist)
+}
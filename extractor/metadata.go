@@ -1,12 +1,27 @@
 package extractor
 
 type Metadata struct {
-	Name             string `yaml:"name"`
-	Version          string `yaml:"product_version"`
-	StemcellCriteria struct {
-		OS                   string `yaml:"os"`
-		Version              string `yaml:"version"`
-		PatchSecurityUpdates bool   `yaml:"enable_patch_security_updates"`
-	} `yaml:"stemcell_criteria"`
-	Raw []byte
+	Name                        string              `yaml:"name"`
+	Version                     string              `yaml:"product_version"`
+	StemcellCriteria            StemcellCriterion   `yaml:"stemcell_criteria"`
+	AdditionalStemcellsCriteria []StemcellCriterion `yaml:"additional_stemcells_criteria"`
+	Raw                         []byte
+}
+
+type StemcellCriterion struct {
+	OS                   string `yaml:"os"                            json:"os"`
+	Version              string `yaml:"version"                       json:"version"`
+	PatchSecurityUpdates bool   `yaml:"enable_patch_security_updates" json:"enable_patch_security_updates"`
+}
+
+// AllStemcellCriteria returns the stemcell criteria of the product followed
+// by its additional stemcell criteria, for products running on several
+// operating systems.
+func (m Metadata) AllStemcellCriteria() []StemcellCriterion {
+	var criteria []StemcellCriterion
+	if m.StemcellCriteria.OS != "" {
+		criteria = append(criteria, m.StemcellCriteria)
+	}
+
+	return append(criteria, m.AdditionalStemcellsCriteria...)
 }
@@ -3,6 +3,7 @@ package extractor_test
 import (
 	"archive/zip"
 	"bytes"
+	"crypto/sha1"
 	"errors"
 	"fmt"
 	"io"
@@ -230,6 +231,23 @@ var _ = Describe("MetadataExtractor", func() {
 			Expect(metadata.Raw).To(MatchYAML(validYAML))
 		})
 
+		It("extracts the additional stemcell criteria of products running on several operating systems", func() {
+			multiOSFile := createProductFile("metadata/some-product.yml", validYAML+`
+additional_stemcells_criteria:
+- os: windows2019
+  version: "2019.41"
+`)
+			defer os.Remove(multiOSFile.Name())
+
+			metadata, err := metadataExtractor.ExtractFromFile(multiOSFile.Name())
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(metadata.AllStemcellCriteria()).To(Equal([]extractor.StemcellCriterion{
+				{OS: "ubuntu-trusty", Version: "3586", PatchSecurityUpdates: true},
+				{OS: "windows2019", Version: "2019.41"},
+			}))
+		})
+
 		When("an error occurs", func() {
 			When("the product tarball does not exist", func() {
 				It("returns an error", func() {
@@ -306,4 +324,138 @@ var _ = Describe("MetadataExtractor", func() {
 			})
 		})
 	})
+
+	Describe("InspectFile", func() {
+		const inspectedYAML = validYAML + `metadata_version: "3.0"
+additional_stemcells_criteria:
+- os: windows2019
+  version: "2019.41"
+releases:
+- name: some-release
+  version: 1.2.3
+  file: some-release-1.2.3.tgz
+  sha1: abc123
+- name: other-release
+  version: 4.5.6
+  file: other-release-4.5.6.tgz
+requires_product_versions:
+- name: cf
+  version: ~> 2.0
+runtime_configs:
+- name: some-runtime-config
+  runtime_config: ""
+job_types:
+- name: web
+  resource_label: Web Server
+  instance_definition:
+    default: 2
+- name: worker
+  instance_definition:
+    default: (( ..cf.worker_count ))
+- name: smoke-tests
+  errand: true
+post_deploy_errands:
+- name: smoke-tests
+pre_delete_errands:
+- name: cleanup
+`
+
+		var inspectedFile string
+
+		BeforeEach(func() {
+			file, err := os.CreateTemp("", "")
+			Expect(err).ToNot(HaveOccurred())
+			defer file.Close()
+
+			zipper := zip.NewWriter(file)
+			for name, contents := range map[string]string{
+				"metadata/some-product.yml":        inspectedYAML,
+				"releases/some-release-1.2.3.tgz":  "some-release",
+				"releases/other-release-4.5.6.tgz": "other-release",
+			} {
+				writer, err := zipper.Create(name)
+				Expect(err).ToNot(HaveOccurred())
+				_, err = io.WriteString(writer, contents)
+				Expect(err).ToNot(HaveOccurred())
+			}
+			Expect(zipper.Close()).To(Succeed())
+
+			inspectedFile = file.Name()
+		})
+
+		AfterEach(func() {
+			os.Remove(inspectedFile)
+		})
+
+		It("lists what the product bundles and requires", func() {
+			inspection, err := metadataExtractor.InspectFile(inspectedFile, false)
+			Expect(err).ToNot(HaveOccurred())
+
+			webInstances := 2
+			Expect(inspection).To(Equal(&extractor.Inspection{
+				Name:                     "some-product",
+				Version:                  "1.8.14",
+				MinimumOpsManagerVersion: "3.0",
+				StemcellCriteria: []extractor.StemcellCriterion{
+					{OS: "ubuntu-trusty", Version: "3586", PatchSecurityUpdates: true},
+					{OS: "windows2019", Version: "2019.41"},
+				},
+				Releases: []extractor.InspectedRelease{
+					{Name: "some-release", Version: "1.2.3", File: "some-release-1.2.3.tgz", SHA1: "abc123"},
+					{Name: "other-release", Version: "4.5.6", File: "other-release-4.5.6.tgz"},
+				},
+				RequiredProducts: []extractor.RequiredProduct{{Name: "cf", Version: "~> 2.0"}},
+				RuntimeConfigs:   []string{"some-runtime-config"},
+				JobTypes: []extractor.InspectedJobType{
+					{Name: "web", Label: "Web Server", DefaultInstances: &webInstances},
+					{Name: "worker"},
+					{Name: "smoke-tests", Errand: true},
+				},
+				Errands: []extractor.InspectedErrand{
+					{Name: "smoke-tests", Phase: "post-deploy"},
+					{Name: "cleanup", Phase: "pre-delete"},
+				},
+			}))
+		})
+
+		It("computes the SHA1 of releases the metadata does not provide one for", func() {
+			inspection, err := metadataExtractor.InspectFile(inspectedFile, true)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(inspection.Releases[0].SHA1).To(Equal("abc123"))
+			Expect(inspection.Releases[1].SHA1).To(Equal(fmt.Sprintf("%x", sha1.Sum([]byte("other-release")))))
+		})
+
+		It("inspects a product served over http with range requests", func() {
+			contents, err := os.ReadFile(inspectedFile)
+			Expect(err).ToNot(HaveOccurred())
+
+			server := ghttp.NewServer()
+			defer server.Close()
+			server.RouteToHandler("HEAD", "/product.pivotal", func(w http.ResponseWriter, r *http.Request) {
+				http.ServeContent(w, r, "product.pivotal", time.Now(), bytes.NewReader(contents))
+			})
+			server.RouteToHandler("GET", "/product.pivotal", func(w http.ResponseWriter, r *http.Request) {
+				Expect(r.Header.Get("Range")).ToNot(BeEmpty())
+				http.ServeContent(w, r, "product.pivotal", time.Now(), bytes.NewReader(contents))
+			})
+
+			inspection, err := metadataExtractor.InspectURL(server.URL()+"/product.pivotal", false)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(inspection.Name).To(Equal("some-product"))
+			Expect(inspection.Releases).To(HaveLen(2))
+		})
+
+		It("errors when a release is missing from the product", func() {
+			missingFile := createProductFile("metadata/some-product.yml", validYAML+`releases:
+- name: missing
+  version: 1.0.0
+  file: missing-1.0.0.tgz
+`)
+			defer os.Remove(missingFile.Name())
+
+			_, err := metadataExtractor.InspectFile(missingFile.Name(), true)
+			Expect(err).To(MatchError(ContainSubstring("could not find release missing-1.0.0.tgz in the product")))
+		})
+	})
 })
@@ -0,0 +1,258 @@
+package network
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Cassette is a recording of the requests om sent to Ops Manager and the
+// responses it got, with credentials redacted. Replaying a cassette serves
+// the recorded responses without an Ops Manager.
+type Cassette struct {
+	path  string
+	mutex sync.Mutex
+
+	Interactions []Interaction `yaml:"interactions"`
+}
+
+type Interaction struct {
+	Request  CassetteRequest  `yaml:"request"`
+	Response CassetteResponse `yaml:"response"`
+
+	played bool
+}
+
+type CassetteRequest struct {
+	Method  string            `yaml:"method"`
+	URL     string            `yaml:"url"`
+	Headers map[string]string `yaml:"headers,omitempty"`
+	Body    *string           `yaml:"body,omitempty"`
+}
+
+type CassetteResponse struct {
+	Status  int               `yaml:"status"`
+	Headers map[string]string `yaml:"headers,omitempty"`
+	Body    string            `yaml:"body,omitempty"`
+
+	// Truncated is set when the body was too large to record, in which case
+	// the response cannot be replayed.
+	Truncated bool `yaml:"truncated,omitempty"`
+}
+
+// NewCassette starts an empty cassette, which is written to path as
+// requests are recorded.
+func NewCassette(path string) *Cassette {
+	return &Cassette{
+		path:         path,
+		Interactions: []Interaction{},
+	}
+}
+
+func LoadCassette(path string) (*Cassette, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read cassette: %w", err)
+	}
+
+	cassette := &Cassette{path: path}
+	err = yaml.UnmarshalStrict(contents, cassette)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse cassette %s: %w", path, err)
+	}
+
+	return cassette, nil
+}
+
+// record writes the whole cassette again with the interaction, so that the
+// cassette can be replayed even when om exits part way through a command.
+func (c *Cassette) record(interaction Interaction) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.Interactions = append(c.Interactions, interaction)
+
+	contents, err := yaml.Marshal(c)
+	if err != nil {
+		return err
+	}
+
+	err = os.WriteFile(c.path, contents, 0600)
+	if err != nil {
+		return fmt.Errorf("could not write cassette: %w", err)
+	}
+
+	return nil
+}
+
+// play returns the first interaction not played yet that matches the
+// request, so that polling the same endpoint plays its responses in order.
+func (c *Cassette) play(request CassetteRequest) (Interaction, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for i, interaction := range c.Interactions {
+		if interaction.played || !interaction.Request.matches(request) {
+			continue
+		}
+
+		c.Interactions[i].played = true
+		return interaction, true
+	}
+
+	return Interaction{}, false
+}
+
+func (r CassetteRequest) matches(request CassetteRequest) bool {
+	if r.Method != request.Method || r.URL != request.URL {
+		return false
+	}
+
+	// requests without a body, and bodies too large to record such as
+	// product uploads, match any body
+	if r.Body == nil || request.Body == nil {
+		return true
+	}
+
+	return *r.Body == *request.Body
+}
+
+// RecordClient records each request and response in a Cassette.
+type RecordClient struct {
+	client   httpClient
+	cassette *Cassette
+}
+
+func NewRecordClient(client httpClient, cassette *Cassette) *RecordClient {
+	return &RecordClient{
+		client:   client,
+		cassette: cassette,
+	}
+}
+
+func (c *RecordClient) Do(request *http.Request) (*http.Response, error) {
+	cassetteRequest, err := newCassetteRequest(request)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := c.client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+
+	body, complete, err := peekBody(response)
+	if err != nil {
+		return nil, err
+	}
+
+	cassetteResponse := CassetteResponse{
+		Status:  response.StatusCode,
+		Headers: cassetteHeaders(response.Header),
+	}
+	if complete {
+		cassetteResponse.Body = string(redactBody(response.Header.Get("Content-Type"), body))
+	} else {
+		cassetteResponse.Truncated = true
+	}
+
+	err = c.cassette.record(Interaction{
+		Request:  cassetteRequest,
+		Response: cassetteResponse,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// ReplayClient serves the responses of a Cassette, and fails requests that
+// were not recorded, or whose response body was too large to record.
+type ReplayClient struct {
+	cassette *Cassette
+}
+
+func NewReplayClient(cassette *Cassette) *ReplayClient {
+	return &ReplayClient{
+		cassette: cassette,
+	}
+}
+
+func (c *ReplayClient) Do(request *http.Request) (*http.Response, error) {
+	cassetteRequest, err := newCassetteRequest(request)
+	if err != nil {
+		return nil, err
+	}
+
+	interaction, ok := c.cassette.play(cassetteRequest)
+	if !ok {
+		return nil, fmt.Errorf("could not replay %s %s: the request is not in the cassette %s, or was already replayed", cassetteRequest.Method, cassetteRequest.URL, c.cassette.path)
+	}
+
+	if interaction.Response.Truncated {
+		return nil, fmt.Errorf("could not replay %s %s: the response body was too large to record in the cassette %s", cassetteRequest.Method, cassetteRequest.URL, c.cassette.path)
+	}
+
+	header := http.Header{}
+	for name, value := range interaction.Response.Headers {
+		header.Set(name, value)
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", interaction.Response.Status, http.StatusText(interaction.Response.Status)),
+		StatusCode:    interaction.Response.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(interaction.Response.Body)),
+		ContentLength: int64(len(interaction.Response.Body)),
+		Request:       request,
+	}, nil
+}
+
+// newCassetteRequest redacts the request in the same way for recording and
+// replaying, so that a replayed request matches its recording.
+func newCassetteRequest(request *http.Request) (CassetteRequest, error) {
+	cassetteRequest := CassetteRequest{
+		Method:  request.Method,
+		URL:     redactURL(request.URL).RequestURI(),
+		Headers: cassetteHeaders(request.Header),
+	}
+
+	if request.Body == nil || request.Body == http.NoBody || request.ContentLength <= 0 || request.ContentLength >= maxBodySize {
+		return cassetteRequest, nil
+	}
+
+	body, err := io.ReadAll(request.Body)
+	if err != nil {
+		return CassetteRequest{}, err
+	}
+	request.Body.Close()
+	request.Body = io.NopCloser(bytes.NewReader(body))
+
+	redactedBody := string(redactBody(request.Header.Get("Content-Type"), body))
+	cassetteRequest.Body = &redactedBody
+
+	return cassetteRequest, nil
+}
+
+func cassetteHeaders(header http.Header) map[string]string {
+	if len(header) == 0 {
+		return nil
+	}
+
+	headers := map[string]string{}
+	for name, values := range redactHeader(header) {
+		headers[name] = strings.Join(values, ", ")
+	}
+
+	return headers
+}
@@ -0,0 +1,163 @@
+package network_test
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/pivotal-cf/om/network"
+	"github.com/pivotal-cf/om/network/fakes"
+)
+
+var _ = Describe("Cassette", func() {
+	var (
+		fakeClient *fakes.HttpClient
+		path       string
+	)
+
+	BeforeEach(func() {
+		fakeClient = &fakes.HttpClient{}
+		path = filepath.Join(GinkgoT().TempDir(), "cassette.yml")
+	})
+
+	It("records requests and responses, with credentials redacted, to replay them", func() {
+		calls := 0
+		fakeClient.DoStub = func(request *http.Request) (*http.Response, error) {
+			calls++
+			body, err := io.ReadAll(request.Body)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(body)).To(Equal(`{"properties":{".properties.password":{"value":"some-password"}}}`))
+
+			return &http.Response{
+				StatusCode:    http.StatusOK,
+				Header:        http.Header{"Content-Type": []string{"application/json"}},
+				Body:          io.NopCloser(strings.NewReader(fmt.Sprintf(`{"step":%d}`, calls))),
+				ContentLength: -1,
+			}, nil
+		}
+
+		recorder := network.NewRecordClient(fakeClient, network.NewCassette(path))
+
+		newRequest := func() *http.Request {
+			request, err := http.NewRequest("PUT", "/api/v0/staged/products/some-guid/properties", strings.NewReader(`{"properties":{".properties.password":{"value":"some-password"}}}`))
+			Expect(err).ToNot(HaveOccurred())
+			request.Header.Set("Content-Type", "application/json")
+			request.Header.Set("Authorization", "Bearer some-token")
+			return request
+		}
+
+		for _, expected := range []string{`{"step":1}`, `{"step":2}`} {
+			response, err := recorder.Do(newRequest())
+			Expect(err).ToNot(HaveOccurred())
+
+			body, err := io.ReadAll(response.Body)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(body)).To(Equal(expected))
+		}
+
+		contents, err := os.ReadFile(path)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(contents)).ToNot(ContainSubstring("some-password"))
+		Expect(string(contents)).ToNot(ContainSubstring("some-token"))
+		Expect(string(contents)).To(ContainSubstring("url: /api/v0/staged/products/some-guid/properties"))
+
+		cassette, err := network.LoadCassette(path)
+		Expect(err).ToNot(HaveOccurred())
+		replayer := network.NewReplayClient(cassette)
+
+		for _, expected := range []string{`{"step":1}`, `{"step":2}`} {
+			response, err := replayer.Do(newRequest())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(response.StatusCode).To(Equal(http.StatusOK))
+			Expect(response.Header.Get("Content-Type")).To(Equal("application/json"))
+
+			body, err := io.ReadAll(response.Body)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(body)).To(Equal(expected))
+		}
+
+		_, err = replayer.Do(newRequest())
+		Expect(err).To(MatchError(ContainSubstring("could not replay PUT /api/v0/staged/products/some-guid/properties: the request is not in the cassette")))
+	})
+
+	When("a response body is too large to record", func() {
+		It("passes the whole body on, and fails to replay it", func() {
+			large := strings.Repeat("a", 1024*1024+1)
+			fakeClient.DoStub = func(request *http.Request) (*http.Response, error) {
+				return &http.Response{
+					StatusCode:    http.StatusOK,
+					Header:        http.Header{"Content-Type": []string{"application/octet-stream"}},
+					Body:          io.NopCloser(strings.NewReader(large)),
+					ContentLength: -1,
+				}, nil
+			}
+
+			recorder := network.NewRecordClient(fakeClient, network.NewCassette(path))
+
+			request, err := http.NewRequest("GET", "/api/v0/installation_asset_collection", nil)
+			Expect(err).ToNot(HaveOccurred())
+			response, err := recorder.Do(request)
+			Expect(err).ToNot(HaveOccurred())
+
+			body, err := io.ReadAll(response.Body)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(body)).To(Equal(large))
+
+			contents, err := os.ReadFile(path)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(contents)).To(ContainSubstring("truncated: true"))
+			Expect(string(contents)).ToNot(ContainSubstring("aaaa"))
+
+			cassette, err := network.LoadCassette(path)
+			Expect(err).ToNot(HaveOccurred())
+
+			request, err = http.NewRequest("GET", "/api/v0/installation_asset_collection", nil)
+			Expect(err).ToNot(HaveOccurred())
+			_, err = network.NewReplayClient(cassette).Do(request)
+			Expect(err).To(MatchError(ContainSubstring("could not replay GET /api/v0/installation_asset_collection: the response body was too large to record")))
+		})
+	})
+
+	When("replaying a request that was not recorded", func() {
+		It("returns an error", func() {
+			Expect(os.WriteFile(path, []byte(`
+interactions:
+- request:
+    method: PUT
+    url: /api/v0/staged/director/properties
+    body: '{"director_configuration":{}}'
+  response:
+    status: 200
+`), 0600)).To(Succeed())
+
+			cassette, err := network.LoadCassette(path)
+			Expect(err).ToNot(HaveOccurred())
+			replayer := network.NewReplayClient(cassette)
+
+			request, err := http.NewRequest("GET", "/api/v0/staged/director/properties", nil)
+			Expect(err).ToNot(HaveOccurred())
+			_, err = replayer.Do(request)
+			Expect(err).To(MatchError(ContainSubstring("could not replay GET /api/v0/staged/director/properties")))
+
+			request, err = http.NewRequest("PUT", "/api/v0/staged/director/properties", strings.NewReader(`{"director_configuration":{"ntp_servers_string":"pool.ntp.org"}}`))
+			Expect(err).ToNot(HaveOccurred())
+			_, err = replayer.Do(request)
+			Expect(err).To(MatchError(ContainSubstring("could not replay PUT /api/v0/staged/director/properties")))
+		})
+	})
+
+	When("the cassette cannot be parsed", func() {
+		It("returns an error", func() {
+			Expect(os.WriteFile(path, []byte("interactions: {"), 0600)).To(Succeed())
+
+			_, err := network.LoadCassette(path)
+			Expect(err).To(MatchError(ContainSubstring("could not parse cassette")))
+		})
+	})
+})
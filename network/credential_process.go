@@ -0,0 +1,87 @@
+package network
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// Credentials are what a credential process prints, as JSON, for om to
+// authenticate with: a username and password, or a client ID and secret.
+type Credentials struct {
+	Username     string `json:"username"`
+	Password     string `json:"password"`
+	ClientID     string `json:"client-id"`
+	ClientSecret string `json:"client-secret"`
+}
+
+// CredentialProcess runs a command for the credentials om authenticates
+// with, so that they are not stored in the env file. The command runs the
+// first time the credentials are needed, and only once.
+type CredentialProcess struct {
+	command string
+
+	once        sync.Once
+	credentials Credentials
+	err         error
+}
+
+func NewCredentialProcess(command string) *CredentialProcess {
+	return &CredentialProcess{
+		command: command,
+	}
+}
+
+func (p *CredentialProcess) Credentials() (Credentials, error) {
+	p.once.Do(func() {
+		p.credentials, p.err = p.run()
+	})
+
+	return p.credentials, p.err
+}
+
+func (p *CredentialProcess) run() (Credentials, error) {
+	shell, flag := "sh", "-c"
+	if runtime.GOOS == "windows" {
+		shell, flag = "cmd", "/C"
+	}
+
+	var stdout, stderr bytes.Buffer
+	command := exec.Command(shell, flag, p.command)
+	command.Stdout = &stdout
+	command.Stderr = &stderr
+
+	err := command.Run()
+	if err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return Credentials{}, fmt.Errorf("could not run the credential process %q: %w: %s", p.command, err, message)
+		}
+		return Credentials{}, fmt.Errorf("could not run the credential process %q: %w", p.command, err)
+	}
+
+	// the output is not part of the errors, as it can hold the credentials
+	var credentials Credentials
+	decoder := json.NewDecoder(&stdout)
+	decoder.DisallowUnknownFields()
+	err = decoder.Decode(&credentials)
+	if err != nil {
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			return Credentials{}, fmt.Errorf("the credential process %q did not print JSON credentials", p.command)
+		}
+		return Credentials{}, fmt.Errorf("the credential process %q printed invalid credentials: %w", p.command, err)
+	}
+
+	hasPassword := credentials.Username != "" && credentials.Password != ""
+	hasClient := credentials.ClientID != "" && credentials.ClientSecret != ""
+	if !hasPassword && !hasClient {
+		return Credentials{}, fmt.Errorf("the credential process %q must print a username and password, or a client-id and client-secret", p.command)
+	}
+
+	return credentials, nil
+}
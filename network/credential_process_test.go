@@ -0,0 +1,76 @@
+package network_test
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/pivotal-cf/om/network"
+)
+
+var _ = Describe("CredentialProcess", func() {
+	It("returns the credentials the command prints", func() {
+		process := network.NewCredentialProcess(`echo '{"username": "some-user", "password": "some-password"}'`)
+
+		credentials, err := process.Credentials()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(credentials).To(Equal(network.Credentials{
+			Username: "some-user",
+			Password: "some-password",
+		}))
+	})
+
+	It("runs the command once", func() {
+		runs := filepath.Join(GinkgoT().TempDir(), "runs")
+		process := network.NewCredentialProcess(`echo run >> ` + runs + `; echo '{"client-id": "some-client", "client-secret": "some-secret"}'`)
+
+		for i := 0; i < 3; i++ {
+			credentials, err := process.Credentials()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(credentials.ClientID).To(Equal("some-client"))
+			Expect(credentials.ClientSecret).To(Equal("some-secret"))
+		}
+
+		contents, err := os.ReadFile(runs)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(contents)).To(Equal("run\n"))
+	})
+
+	When("the command fails", func() {
+		It("returns an error with what the command printed to stderr", func() {
+			process := network.NewCredentialProcess(`echo "vault is sealed" >&2; exit 2`)
+
+			_, err := process.Credentials()
+			Expect(err).To(MatchError(`could not run the credential process "echo \"vault is sealed\" >&2; exit 2": exit status 2: vault is sealed`))
+		})
+	})
+
+	When("the command does not print JSON", func() {
+		It("returns an error without the output", func() {
+			process := network.NewCredentialProcess(`echo some-password`)
+
+			_, err := process.Credentials()
+			Expect(err).To(MatchError(`the credential process "echo some-password" did not print JSON credentials`))
+		})
+	})
+
+	When("the command prints an unknown key", func() {
+		It("returns an error", func() {
+			process := network.NewCredentialProcess(`echo '{"client_id": "some-client"}'`)
+
+			_, err := process.Credentials()
+			Expect(err).To(MatchError(ContainSubstring(`printed invalid credentials: json: unknown field "client_id"`)))
+		})
+	})
+
+	When("the command prints incomplete credentials", func() {
+		It("returns an error", func() {
+			process := network.NewCredentialProcess(`echo '{"username": "some-user"}'`)
+
+			_, err := process.Credentials()
+			Expect(err).To(MatchError(ContainSubstring("must print a username and password, or a client-id and client-secret")))
+		})
+	})
+})
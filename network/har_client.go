@@ -0,0 +1,337 @@
+package network
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// HARFile is a HAR 1.2 archive of the requests om sends, which browser
+// devtools can open. The clients of an om invocation share one file.
+type HARFile struct {
+	path  string
+	mutex sync.Mutex
+	log   harLog
+}
+
+type harLog struct {
+	Version string     `json:"version"`
+	Creator harCreator `json:"creator"`
+	Entries []harEntry `json:"entries"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harEntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	PostData    *harPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	Content     harContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type harContent struct {
+	Size     int64  `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+type harTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+func NewHARFile(path, version string) *HARFile {
+	return &HARFile{
+		path: path,
+		log: harLog{
+			Version: "1.2",
+			Creator: harCreator{Name: "om", Version: version},
+			Entries: []harEntry{},
+		},
+	}
+}
+
+// add writes the whole archive again with the entry to a temporary file,
+// which then replaces the archive, so that the file is valid HAR even when om
+// exits part way through a command or a write.
+func (f *HARFile) add(entry harEntry) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.log.Entries = append(f.log.Entries, entry)
+
+	contents, err := json.MarshalIndent(struct {
+		Log harLog `json:"log"`
+	}{f.log}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(f.path), "."+filepath.Base(f.path)+"-*")
+	if err != nil {
+		return fmt.Errorf("could not write trace file: %w", err)
+	}
+	defer os.Remove(file.Name())
+
+	err = file.Chmod(0600)
+	if err == nil {
+		_, err = file.Write(contents)
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("could not write trace file: %w", err)
+	}
+
+	err = os.Rename(file.Name(), f.path)
+	if err != nil {
+		return fmt.Errorf("could not write trace file: %w", err)
+	}
+
+	return nil
+}
+
+// HARClient records each request and response in a HARFile. Bodies of
+// maxBodySize or more are left out, as with the TraceClient.
+type HARClient struct {
+	client httpClient
+	file   *HARFile
+	redact bool
+}
+
+func NewHARClient(client httpClient, file *HARFile, redact bool) *HARClient {
+	return &HARClient{
+		client: client,
+		file:   file,
+		redact: redact,
+	}
+}
+
+func (c *HARClient) Do(request *http.Request) (*http.Response, error) {
+	var requestBody []byte
+	if request.Body != nil && request.Body != http.NoBody && request.ContentLength > 0 && request.ContentLength < maxBodySize {
+		var err error
+		requestBody, err = io.ReadAll(request.Body)
+		if err != nil {
+			return nil, err
+		}
+		request.Body.Close()
+		request.Body = io.NopCloser(bytes.NewReader(requestBody))
+	}
+
+	started := time.Now()
+	response, err := c.client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	waited := time.Since(started)
+
+	responseBody, complete, err := peekBody(response)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := harEntry{
+		StartedDateTime: started.Format(time.RFC3339Nano),
+		Time:            milliseconds(time.Since(started)),
+		Request:         c.harRequest(request, requestBody),
+		Response:        c.harResponse(response, responseBody, complete),
+		Timings: harTimings{
+			Wait:    milliseconds(waited),
+			Receive: milliseconds(time.Since(started) - waited),
+		},
+	}
+
+	err = c.file.add(entry)
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+func (c *HARClient) harRequest(request *http.Request, body []byte) harRequest {
+	header := request.Header
+	requestURL := request.URL
+	if c.redact {
+		header = redactHeader(header)
+		requestURL = redactURL(requestURL)
+	}
+
+	harReq := harRequest{
+		Method:      request.Method,
+		URL:         requestURL.String(),
+		HTTPVersion: request.Proto,
+		Cookies:     []harNameValue{},
+		Headers:     harHeaders(header),
+		QueryString: []harNameValue{},
+		HeadersSize: -1,
+		BodySize:    request.ContentLength,
+	}
+	if harReq.HTTPVersion == "" {
+		harReq.HTTPVersion = "HTTP/1.1"
+	}
+
+	for name, values := range requestURL.Query() {
+		for _, value := range values {
+			harReq.QueryString = append(harReq.QueryString, harNameValue{Name: name, Value: value})
+		}
+	}
+
+	if body != nil {
+		contentType := request.Header.Get("Content-Type")
+		if c.redact {
+			body = redactBody(contentType, body)
+		}
+		harReq.PostData = &harPostData{MimeType: contentType, Text: string(body)}
+	}
+
+	return harReq
+}
+
+func (c *HARClient) harResponse(response *http.Response, body []byte, complete bool) harResponse {
+	header := response.Header
+	if c.redact {
+		header = redactHeader(header)
+	}
+
+	contentType := response.Header.Get("Content-Type")
+	harResp := harResponse{
+		Status:      response.StatusCode,
+		StatusText:  http.StatusText(response.StatusCode),
+		HTTPVersion: response.Proto,
+		Cookies:     []harNameValue{},
+		Headers:     harHeaders(header),
+		Content: harContent{
+			Size:     response.ContentLength,
+			MimeType: contentType,
+		},
+		RedirectURL: response.Header.Get("Location"),
+		HeadersSize: -1,
+		BodySize:    response.ContentLength,
+	}
+	if harResp.HTTPVersion == "" {
+		harResp.HTTPVersion = "HTTP/1.1"
+	}
+
+	if !complete {
+		harResp.Content.Comment = "the body is too large to record"
+		return harResp
+	}
+
+	if c.redact {
+		body = redactBody(contentType, body)
+	}
+	harResp.Content.Size = int64(len(body))
+
+	if utf8.Valid(body) {
+		harResp.Content.Text = string(body)
+	} else {
+		harResp.Content.Text = base64.StdEncoding.EncodeToString(body)
+		harResp.Content.Encoding = "base64"
+	}
+
+	return harResp
+}
+
+// peekBody reads a response body of less than maxBodySize, and leaves the
+// body of the response to read as it was.
+func peekBody(response *http.Response) ([]byte, bool, error) {
+	if response.Body == nil || response.Body == http.NoBody {
+		return nil, true, nil
+	}
+	if response.ContentLength >= maxBodySize {
+		return nil, false, nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(response.Body, maxBodySize))
+	if err != nil {
+		return nil, false, err
+	}
+
+	if len(body) < maxBodySize {
+		response.Body.Close()
+		response.Body = io.NopCloser(bytes.NewReader(body))
+		return body, true, nil
+	}
+
+	response.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(body), response.Body), response.Body}
+
+	return nil, false, nil
+}
+
+func harHeaders(header http.Header) []harNameValue {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	headers := []harNameValue{}
+	for _, name := range names {
+		for _, value := range header[name] {
+			headers = append(headers, harNameValue{Name: name, Value: value})
+		}
+	}
+
+	return headers
+}
+
+func milliseconds(duration time.Duration) float64 {
+	return float64(duration) / float64(time.Millisecond)
+}
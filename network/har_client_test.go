@@ -0,0 +1,174 @@
+package network_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/pivotal-cf/om/network"
+	"github.com/pivotal-cf/om/network/fakes"
+)
+
+var _ = Describe("HARClient", func() {
+	var (
+		fakeClient *fakes.HttpClient
+		path       string
+	)
+
+	type har struct {
+		Log struct {
+			Version string
+			Creator struct{ Name, Version string }
+			Entries []struct {
+				Request struct {
+					Method   string
+					URL      string
+					Headers  []struct{ Name, Value string }
+					PostData struct{ MimeType, Text string }
+				}
+				Response struct {
+					Status  int
+					Content struct {
+						Size    int64
+						Text    string
+						Comment string
+					}
+				}
+			}
+		}
+	}
+
+	readHAR := func() har {
+		contents, err := os.ReadFile(path)
+		Expect(err).ToNot(HaveOccurred())
+
+		var archive har
+		Expect(json.Unmarshal(contents, &archive)).To(Succeed())
+		return archive
+	}
+
+	BeforeEach(func() {
+		fakeClient = &fakes.HttpClient{}
+		fakeClient.DoStub = func(*http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode:    http.StatusOK,
+				Header:        http.Header{"Content-Type": []string{"application/json"}},
+				Body:          io.NopCloser(strings.NewReader(`{"access_token":"some-token"}`)),
+				ContentLength: -1,
+			}, nil
+		}
+
+		path = filepath.Join(GinkgoT().TempDir(), "trace.har")
+	})
+
+	It("records each request and response in a HAR 1.2 file", func() {
+		file := network.NewHARFile(path, "1.2.3")
+		client := network.NewHARClient(fakeClient, file, true)
+
+		request, err := http.NewRequest("POST", "https://example.com/uaa/oauth/token", strings.NewReader("grant_type=password&password=some-password"))
+		Expect(err).ToNot(HaveOccurred())
+		request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		request.Header.Set("Authorization", "Basic b3BzbWFuOg==")
+
+		response, err := client.Do(request)
+		Expect(err).ToNot(HaveOccurred())
+
+		body, err := io.ReadAll(response.Body)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(body)).To(Equal(`{"access_token":"some-token"}`))
+
+		request, err = http.NewRequest("GET", "https://example.com/api/v0/info", nil)
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = client.Do(request)
+		Expect(err).ToNot(HaveOccurred())
+
+		archive := readHAR()
+		Expect(archive.Log.Version).To(Equal("1.2"))
+		Expect(archive.Log.Creator.Name).To(Equal("om"))
+		Expect(archive.Log.Creator.Version).To(Equal("1.2.3"))
+		Expect(archive.Log.Entries).To(HaveLen(2))
+
+		entry := archive.Log.Entries[0]
+		Expect(entry.Request.Method).To(Equal("POST"))
+		Expect(entry.Request.URL).To(Equal("https://example.com/uaa/oauth/token"))
+		Expect(entry.Request.Headers).To(ContainElement(struct{ Name, Value string }{"Authorization", "[REDACTED]"}))
+		Expect(entry.Request.PostData.MimeType).To(Equal("application/x-www-form-urlencoded"))
+		Expect(entry.Request.PostData.Text).To(Equal("grant_type=password&password=%5BREDACTED%5D"))
+		Expect(entry.Response.Status).To(Equal(http.StatusOK))
+		Expect(entry.Response.Content.Text).To(Equal(`{"access_token":"[REDACTED]"}`))
+
+		Expect(archive.Log.Entries[1].Request.URL).To(Equal("https://example.com/api/v0/info"))
+
+		info, err := os.Stat(path)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(info.Mode().Perm()).To(Equal(os.FileMode(0600)))
+	})
+
+	It("replaces the file with each entry, leaving no temporary files", func() {
+		file := network.NewHARFile(path, "1.2.3")
+		client := network.NewHARClient(fakeClient, file, true)
+
+		for range 3 {
+			request, err := http.NewRequest("GET", "https://example.com/api/v0/info", nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = client.Do(request)
+			Expect(err).ToNot(HaveOccurred())
+		}
+
+		Expect(readHAR().Log.Entries).To(HaveLen(3))
+
+		entries, err := os.ReadDir(filepath.Dir(path))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(entries).To(HaveLen(1))
+		Expect(entries[0].Name()).To(Equal("trace.har"))
+	})
+
+	It("leaves out bodies that are too large to record", func() {
+		large := strings.Repeat("a", 1024*1024+1)
+		fakeClient.DoStub = func(*http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode:    http.StatusOK,
+				Body:          io.NopCloser(strings.NewReader(large)),
+				ContentLength: -1,
+			}, nil
+		}
+
+		client := network.NewHARClient(fakeClient, network.NewHARFile(path, "1.2.3"), true)
+
+		request, err := http.NewRequest("GET", "https://example.com/download", nil)
+		Expect(err).ToNot(HaveOccurred())
+
+		response, err := client.Do(request)
+		Expect(err).ToNot(HaveOccurred())
+
+		body, err := io.ReadAll(response.Body)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(body)).To(Equal(large))
+
+		archive := readHAR()
+		Expect(archive.Log.Entries[0].Response.Content.Text).To(BeEmpty())
+		Expect(archive.Log.Entries[0].Response.Content.Comment).To(Equal("the body is too large to record"))
+	})
+
+	When("the trace is unredacted", func() {
+		It("records the credentials", func() {
+			client := network.NewHARClient(fakeClient, network.NewHARFile(path, "1.2.3"), false)
+
+			request, err := http.NewRequest("GET", "https://example.com/api/v0/info", nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = client.Do(request)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(readHAR().Log.Entries[0].Response.Content.Text).To(Equal(`{"access_token":"some-token"}`))
+		})
+	})
+})
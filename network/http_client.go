@@ -10,37 +10,123 @@ import (
 	"net/http"
 	"os"
 	"strings"
+	"sync"
 	"time"
 )
 
-func newHTTPClient(insecureSkipVerify bool, caCert string, requestTimeout time.Duration, connectTimeout time.Duration) (*http.Client, error) {
+type transportKey struct {
+	insecureSkipVerify bool
+	caCert             string
+	clientCertificate  *tls.Certificate
+	proxy              ProxyConfig
+	connectTimeout     time.Duration
+}
+
+var (
+	transportsMutex sync.Mutex
+	transports      = map[transportKey]http.RoundTripper{}
+)
+
+// sharedTransport returns the same transport for the same settings, so that
+// the authenticated and unauthenticated clients pool their connections, and
+// reuse them with keep-alive and TLS session resumption.
+func sharedTransport(insecureSkipVerify bool, caCert string, clientCertificate *tls.Certificate, proxy ProxyConfig, connectTimeout time.Duration) (http.RoundTripper, error) {
+	transportsMutex.Lock()
+	defer transportsMutex.Unlock()
+
+	key := transportKey{
+		insecureSkipVerify: insecureSkipVerify,
+		caCert:             caCert,
+		clientCertificate:  clientCertificate,
+		proxy:              proxy,
+		connectTimeout:     connectTimeout,
+	}
+	if transport, ok := transports[key]; ok {
+		return transport, nil
+	}
+
 	tlsConfig := &tls.Config{
 		InsecureSkipVerify: insecureSkipVerify,
 		MinVersion:         tls.VersionTLS12,
+		ClientSessionCache: tls.NewLRUClientSessionCache(0),
 	}
 	err := setCACert(caCert, tlsConfig)
 	if err != nil {
 		return nil, err
 	}
+	if clientCertificate != nil {
+		tlsConfig.Certificates = []tls.Certificate{*clientCertificate}
+	}
+
+	transport := &http.Transport{
+		TLSClientConfig: tlsConfig,
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			d := net.Dialer{
+				Timeout:   connectTimeout,
+				KeepAlive: 30 * time.Second,
+			}
+			return d.DialContext(ctx, network, addr)
+		},
+		MaxIdleConns:        100,
+		MaxIdleConnsPerHost: 10,
+		IdleConnTimeout:     90 * time.Second,
+	}
+
+	roundTripper, err := configureProxy(transport, proxy)
+	if err != nil {
+		return nil, err
+	}
+	transports[key] = roundTripper
+
+	return roundTripper, nil
+}
+
+func newHTTPClient(insecureSkipVerify bool, caCert string, clientCertificate *tls.Certificate, proxy ProxyConfig, requestTimeout time.Duration, connectTimeout time.Duration) (*http.Client, error) {
+	transport, err := sharedTransport(insecureSkipVerify, caCert, clientCertificate, proxy, connectTimeout)
+	if err != nil {
+		return nil, err
+	}
+
 	return &http.Client{
 		CheckRedirect: func(req *http.Request, via []*http.Request) error {
 			return http.ErrUseLastResponse
 		},
-		Transport: &http.Transport{
-			Proxy:           http.ProxyFromEnvironment,
-			TLSClientConfig: tlsConfig,
-			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
-				d := net.Dialer{
-					Timeout:   connectTimeout,
-					KeepAlive: 30 * time.Second,
-				}
-				return d.DialContext(ctx, network, addr)
-			},
+		Transport: &handshakeErrorTransport{
+			transport:         transport,
+			clientCertificate: clientCertificate != nil,
 		},
 		Timeout: requestTimeout,
 	}, nil
 }
 
+// handshakeErrorTransport explains the TLS alerts of servers that require a
+// client certificate, such as a proxy in front of Ops Manager enforcing
+// mutual TLS, which otherwise only read as "remote error: tls: ...".
+type handshakeErrorTransport struct {
+	transport         http.RoundTripper
+	clientCertificate bool
+}
+
+func (t *handshakeErrorTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	response, err := t.transport.RoundTrip(request)
+	if err == nil || !isTLSAlert(err) {
+		return response, err
+	}
+
+	if t.clientCertificate {
+		return nil, fmt.Errorf("%s rejected the client certificate, check that --client-cert is signed by a CA it trusts and has not expired: %w", request.URL.Host, err)
+	}
+
+	return nil, fmt.Errorf("%s rejected the TLS handshake, it may require a client certificate with --client-cert and --client-key: %w", request.URL.Host, err)
+}
+
+// isTLSAlert is true for the alerts a server sends to reject a handshake,
+// which crypto/tls reports as remote errors.
+func isTLSAlert(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "remote error"
+}
+
 func setCACert(caCert string, tlsConfig *tls.Config) error {
 	if caCert == "" {
 		return nil
@@ -1,8 +1,16 @@
 package network
 
 import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"net/url"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/cloudfoundry-community/go-uaa"
@@ -10,17 +18,18 @@ import (
 )
 
 type OAuthClient struct {
-	caCert             string
-	clientID           string
-	clientSecret       string
-	insecureSkipVerify bool
-	password           string
-	opsmanTarget       string
-	uaaTarget          string
-	token              *oauth2.Token
-	username           string
-	connectTimeout     time.Duration
-	requestTimeout     time.Duration
+	client       *http.Client
+	clientID     string
+	clientSecret string
+	password     string
+	opsmanTarget string
+	uaaTarget    string
+	tokenMutex   sync.Mutex
+	token        *oauth2.Token
+	tokenCache   *TokenCache
+	username     string
+
+	credentialProcess *CredentialProcess
 }
 
 func NewOAuthClient(
@@ -29,25 +38,40 @@ func NewOAuthClient(
 	clientID, clientSecret string,
 	insecureSkipVerify bool,
 	caCert string,
+	clientCertificate *tls.Certificate,
+	proxy ProxyConfig,
 	connectTimeout time.Duration,
 	requestTimeout time.Duration,
 ) (*OAuthClient, error) {
+	client, err := newHTTPClient(insecureSkipVerify, caCert, clientCertificate, proxy, requestTimeout, connectTimeout)
+	if err != nil {
+		return nil, err
+	}
+
 	return &OAuthClient{
-		caCert:             caCert,
-		clientID:           clientID,
-		clientSecret:       clientSecret,
-		insecureSkipVerify: insecureSkipVerify,
-		password:           password,
-		uaaTarget:          uaaTarget,
-		opsmanTarget:       opsmanTarget,
-		username:           username,
-		connectTimeout:     connectTimeout,
-		requestTimeout:     requestTimeout,
+		client:       client,
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		password:     password,
+		uaaTarget:    uaaTarget,
+		opsmanTarget: opsmanTarget,
+		username:     username,
 	}, nil
 }
 
+// SetTokenCache makes the client reuse the tokens stored by earlier om
+// invocations, and store the tokens it gets for later ones.
+func (oc *OAuthClient) SetTokenCache(cache *TokenCache) {
+	oc.tokenCache = cache
+}
+
+// SetCredentialProcess makes the client get its credentials from the
+// process, when it first needs a new token.
+func (oc *OAuthClient) SetCredentialProcess(process *CredentialProcess) {
+	oc.credentialProcess = process
+}
+
 func (oc *OAuthClient) Do(request *http.Request) (*http.Response, error) {
-	token := oc.token
 	opsmanTarget, uaaTarget, err := parseOpsmanAndUAAURLs(oc.opsmanTarget, oc.uaaTarget)
 	if err != nil {
 		return nil, err
@@ -56,44 +80,284 @@ func (oc *OAuthClient) Do(request *http.Request) (*http.Response, error) {
 	request.URL.Scheme = opsmanTarget.Scheme
 	request.URL.Host = opsmanTarget.Host
 
-	client, err := newHTTPClient(
-		oc.insecureSkipVerify,
-		oc.caCert,
-		oc.requestTimeout,
-		oc.connectTimeout,
+	principal, secret := oc.principal()
+	cacheKey := tokenCacheKey(opsmanTarget, uaaTarget, principal)
+
+	token, fromCache, err := oc.validToken(request.Context(), cacheKey, secret, uaaTarget)
+	if err != nil {
+		return nil, err
+	}
+
+	request.Header.Set(
+		"Authorization",
+		fmt.Sprintf("Bearer %s", token.AccessToken),
 	)
 
+	response, err := oc.client.Do(request)
+	if err != nil || response.StatusCode != http.StatusUnauthorized || !fromCache {
+		return response, err
+	}
+
+	// a cached token can have been revoked, such as by a UAA restart, in
+	// which case the request is made again with a new token if its body can
+	// be sent again
+	if request.Body != nil && request.GetBody == nil {
+		return response, nil
+	}
+
+	if request.GetBody != nil {
+		request.Body, err = request.GetBody()
+		if err != nil {
+			return response, nil
+		}
+	}
+	response.Body.Close()
+
+	token, err = oc.replaceToken(request.Context(), cacheKey, secret, uaaTarget, token)
 	if err != nil {
 		return nil, err
 	}
 
-	if token != nil && token.Valid() {
-		request.Header.Set(
-			"Authorization",
-			fmt.Sprintf("Bearer %s", token.AccessToken),
-		)
-		return client.Do(request)
+	request.Header.Set(
+		"Authorization",
+		fmt.Sprintf("Bearer %s", token.AccessToken),
+	)
+
+	return oc.client.Do(request)
+}
+
+// validToken returns the current token, from the token cache when there is
+// no current token, or a new one when it is not valid anymore. It also
+// reports whether the token came from the token cache.
+func (oc *OAuthClient) validToken(ctx context.Context, cacheKey, secret string, uaaTarget *url.URL) (*oauth2.Token, bool, error) {
+	oc.tokenMutex.Lock()
+	defer oc.tokenMutex.Unlock()
+
+	fromCache := false
+	if oc.token == nil && oc.tokenCache != nil {
+		// the cache is only an optimization, so a cache that cannot be
+		// read means a new token rather than an error
+		oc.token, _ = oc.tokenCache.Load(cacheKey, secret)
+		fromCache = oc.token != nil
+	}
+
+	if oc.token != nil && oc.token.Valid() {
+		return oc.token, fromCache, nil
+	}
+
+	token, err := oc.newToken(ctx, uaaTarget)
+	if err != nil {
+		return nil, false, err
+	}
+	oc.storeToken(cacheKey, secret, token)
+
+	return token, false, nil
+}
+
+// replaceToken gets a new token in place of a rejected one, unless another
+// request has replaced it already.
+func (oc *OAuthClient) replaceToken(ctx context.Context, cacheKey, secret string, uaaTarget *url.URL, rejected *oauth2.Token) (*oauth2.Token, error) {
+	oc.tokenMutex.Lock()
+	defer oc.tokenMutex.Unlock()
+
+	if oc.token != rejected {
+		return oc.token, nil
+	}
+
+	oc.token = nil
+	token, err := oc.newToken(ctx, uaaTarget)
+	if err != nil {
+		return nil, err
+	}
+	oc.storeToken(cacheKey, secret, token)
+
+	return token, nil
+}
+
+// PasscodeURL is where a user signed in to UAA, such as with SAML, gets a
+// one-time passcode for LoginWithPasscode.
+func (oc *OAuthClient) PasscodeURL() (string, error) {
+	_, uaaTarget, err := parseOpsmanAndUAAURLs(oc.opsmanTarget, oc.uaaTarget)
+	if err != nil {
+		return "", err
 	}
 
+	passcodeURL := *uaaTarget
+	passcodeURL.Path = strings.TrimSuffix(passcodeURL.Path, "/") + "/passcode"
+
+	return passcodeURL.String(), nil
+}
+
+// LoginWithPasscode makes a passcode grant, and stores the token in the token
+// cache, where later om invocations without credentials find it.
+func (oc *OAuthClient) LoginWithPasscode(passcode string) error {
+	if oc.tokenCache == nil {
+		return errors.New("could not store the SSO session: there is no token cache")
+	}
+
+	opsmanTarget, uaaTarget, err := parseOpsmanAndUAAURLs(oc.opsmanTarget, oc.uaaTarget)
+	if err != nil {
+		return err
+	}
+
+	clientID, clientSecret := oc.uaaClient()
+
+	request, err := http.NewRequest("POST", tokenURL(uaaTarget), strings.NewReader(url.Values{
+		"grant_type": []string{"password"},
+		"passcode":   []string{passcode},
+	}.Encode()))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Accept", "application/json")
+	request.SetBasicAuth(url.QueryEscape(clientID), url.QueryEscape(clientSecret))
+
+	response, err := oc.client.Do(request)
+	if err != nil {
+		return fmt.Errorf("could not log in with the passcode: %w", err)
+	}
+	defer response.Body.Close()
+
+	var body struct {
+		AccessToken      string `json:"access_token"`
+		TokenType        string `json:"token_type"`
+		RefreshToken     string `json:"refresh_token"`
+		ExpiresIn        int64  `json:"expires_in"`
+		ErrorDescription string `json:"error_description"`
+	}
+	err = json.NewDecoder(io.LimitReader(response.Body, maxBodySize)).Decode(&body)
+	if response.StatusCode != http.StatusOK {
+		if body.ErrorDescription != "" {
+			return fmt.Errorf("could not log in with the passcode: %s", body.ErrorDescription)
+		}
+		return fmt.Errorf("could not log in with the passcode: %s", response.Status)
+	}
+	if err != nil || body.AccessToken == "" {
+		return errors.New("could not log in with the passcode: UAA did not respond with a token")
+	}
+
+	token := &oauth2.Token{
+		AccessToken:  body.AccessToken,
+		TokenType:    body.TokenType,
+		RefreshToken: body.RefreshToken,
+	}
+	if body.ExpiresIn > 0 {
+		token.Expiry = time.Now().Add(time.Duration(body.ExpiresIn) * time.Second)
+	}
+
+	oc.tokenMutex.Lock()
+	defer oc.tokenMutex.Unlock()
+
+	oc.token = token
+	principal, secret := oc.principal()
+	err = oc.tokenCache.Store(tokenCacheKey(opsmanTarget, uaaTarget, principal), secret, token)
+	if err != nil {
+		return fmt.Errorf("could not store the SSO session: %w", err)
+	}
+
+	return nil
+}
+
+// principal does not change once the credential process has run, so that
+// the token it gets is cached for the next om invocation to find.
+func (oc *OAuthClient) principal() (string, string) {
+	if oc.credentialProcess != nil {
+		return TokenCachePrincipal("", "", "", "", oc.credentialProcess.command)
+	}
+
+	return TokenCachePrincipal(oc.username, oc.password, oc.clientID, oc.clientSecret, "")
+}
+
+// uaaClient is the UAA client om authenticates as. Users authenticate
+// through the opsman client, which has no secret.
+func (oc *OAuthClient) uaaClient() (string, string) {
+	if oc.clientID == "" || (oc.username != "" && oc.password != "") {
+		return "opsman", ""
+	}
+
+	return oc.clientID, oc.clientSecret
+}
+
+func tokenURL(uaaTarget *url.URL) string {
+	tokenURL := *uaaTarget
+	tokenURL.Path = strings.TrimSuffix(tokenURL.Path, "/") + "/oauth/token"
+	tokenURL.RawQuery = url.Values{"token_format": []string{uaa.JSONWebToken.String()}}.Encode()
+
+	return tokenURL.String()
+}
+
+func (oc *OAuthClient) storeToken(cacheKey, secret string, token *oauth2.Token) {
+	oc.token = token
+
+	if oc.tokenCache != nil {
+		_ = oc.tokenCache.Store(cacheKey, secret, token)
+	}
+}
+
+// newToken uses the refresh token of the current token when there is one,
+// and otherwise makes a password or client credentials grant.
+func (oc *OAuthClient) newToken(ctx context.Context, uaaTarget *url.URL) (*oauth2.Token, error) {
+	if oc.credentialProcess != nil {
+		credentials, err := oc.credentialProcess.Credentials()
+		if err != nil {
+			return nil, fmt.Errorf("could not get a token: %w", err)
+		}
+
+		oc.username, oc.password = credentials.Username, credentials.Password
+		oc.clientID, oc.clientSecret = credentials.ClientID, credentials.ClientSecret
+	}
+
+	clientID, clientSecret := oc.uaaClient()
+
+	if oc.token != nil && oc.token.RefreshToken != "" {
+		config := &oauth2.Config{
+			ClientID:     clientID,
+			ClientSecret: clientSecret,
+			Endpoint: oauth2.Endpoint{
+				TokenURL:  tokenURL(uaaTarget),
+				AuthStyle: oauth2.AuthStyleInHeader,
+			},
+		}
+
+		token, err := config.TokenSource(
+			context.WithValue(ctx, oauth2.HTTPClient, oc.client),
+			&oauth2.Token{RefreshToken: oc.token.RefreshToken},
+		).Token()
+		if err == nil {
+			return token, nil
+		}
+	}
+
+	if oc.clientID == "" && (oc.username == "" || oc.password == "") {
+		return nil, errors.New("could not get a token: there is no SSO session, run `om login --sso` or provide a username and password or a client ID and secret")
+	}
+
+	// go-uaa wraps the transport of the client it is given, and changes its
+	// TLS configuration, so it gets a client of its own around a transport
+	// it cannot change
 	options := []uaa.Option{
-		uaa.WithSkipSSLValidation(oc.insecureSkipVerify),
-		uaa.WithClient(client),
+		uaa.WithClient(&http.Client{
+			CheckRedirect: oc.client.CheckRedirect,
+			Transport:     &opaqueTransport{oc.client.Transport},
+			Timeout:       oc.client.Timeout,
+		}),
 	}
 
 	var authOption uaa.AuthenticationOption
 
 	if oc.username != "" && oc.password != "" {
 		authOption = uaa.WithPasswordCredentials(
-			"opsman",
-			"",
+			clientID,
+			clientSecret,
 			oc.username,
 			oc.password,
 			uaa.JSONWebToken,
 		)
 	} else {
 		authOption = uaa.WithClientCredentials(
-			oc.clientID,
-			oc.clientSecret,
+			clientID,
+			clientSecret,
 			uaa.JSONWebToken,
 		)
 	}
@@ -107,8 +371,9 @@ func (oc *OAuthClient) Do(request *http.Request) (*http.Response, error) {
 		return nil, fmt.Errorf("could not init UAA client: %w", err)
 	}
 
+	var token *oauth2.Token
 	for i := 0; i <= 2; i++ {
-		token, err = api.Token(request.Context())
+		token, err = api.Token(ctx)
 		if err == nil {
 			break
 		}
@@ -118,12 +383,9 @@ func (oc *OAuthClient) Do(request *http.Request) (*http.Response, error) {
 		return nil, fmt.Errorf("token could not be retrieved from target url: %w", err)
 	}
 
-	request.Header.Set(
-		"Authorization",
-		fmt.Sprintf("Bearer %s", token.AccessToken),
-	)
-
-	oc.token = token
+	return token, nil
+}
 
-	return client.Do(request)
+type opaqueTransport struct {
+	http.RoundTripper
 }
@@ -0,0 +1,84 @@
+package network_test
+
+import (
+	"crypto/tls"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pivotal-cf/om/network"
+)
+
+func newBenchmarkServer(b *testing.B) *httptest.Server {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/uaa/oauth/token" {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"access_token": "some-opsman-token", "token_type": "bearer", "expires_in": 3600}`))
+			return
+		}
+
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	server.Config.ErrorLog = log.New(io.Discard, "", 0)
+	b.Cleanup(server.Close)
+
+	return server
+}
+
+// BenchmarkOAuthClientDo makes requests through a single client, which
+// reuses its connection to the server.
+func BenchmarkOAuthClientDo(b *testing.B) {
+	server := newBenchmarkServer(b)
+
+	client, err := network.NewOAuthClient("", server.URL, "opsman-username", "opsman-password", "", "", true, "", nil, network.ProxyConfig{}, 5*time.Second, 30*time.Second)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		req, err := http.NewRequest("GET", "/api/v0/staged/products", nil)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			b.Fatal(err)
+		}
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+}
+
+// BenchmarkTransportPerRequest is the baseline of a new transport for every
+// request, which needs a new TCP connection and TLS handshake each time.
+func BenchmarkTransportPerRequest(b *testing.B) {
+	server := newBenchmarkServer(b)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		client := &http.Client{
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: true, MinVersion: tls.VersionTLS12},
+			},
+		}
+
+		req, err := http.NewRequest("GET", server.URL+"/api/v0/staged/products", nil)
+		if err != nil {
+			b.Fatal(err)
+		}
+		req.Header.Set("Authorization", "Bearer some-opsman-token")
+
+		resp, err := client.Do(req)
+		if err != nil {
+			b.Fatal(err)
+		}
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		client.CloseIdleConnections()
+	}
+}
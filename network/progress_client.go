@@ -1,9 +1,12 @@
 package network
 
 import (
+	"fmt"
 	"github.com/cheggaaa/pb/v3"
 	"io"
 	"net/http"
+	"sync/atomic"
+	"time"
 )
 
 type ProgressClient struct {
@@ -24,15 +27,26 @@ func (pc ProgressClient) Do(req *http.Request) (*http.Response, error) {
 	bar.Set(pb.Bytes, true)
 	bar.SetMaxWidth(80)
 
+	var body *uploadBody
 	switch req.Method {
 	case http.MethodPost, http.MethodPut:
-		req.Body = bar.NewProxyReader(req.Body)
+		if req.Body != nil {
+			body = &uploadBody{ReadCloser: bar.NewProxyReader(req.Body)}
+			req.Body = body
+		} else {
+			req.Body = bar.NewProxyReader(req.Body)
+		}
 		bar.SetTotal(req.ContentLength)
 	}
 
 	bar.Start()
+	start := time.Now()
 
 	resp, err := pc.client.Do(req)
+	if body != nil {
+		bar.Finish()
+		pc.reportThroughput(bar.Current(), req.ContentLength, body.elapsed(start), err)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -44,3 +58,62 @@ func (pc ProgressClient) Do(req *http.Request) (*http.Response, error) {
 
 	return resp, nil
 }
+
+// reportThroughput summarizes an upload, so that a slow or interrupted
+// upload of a large file can be told apart from Ops Manager processing it.
+func (pc ProgressClient) reportThroughput(sent int64, total int64, elapsed time.Duration, err error) {
+	if err != nil {
+		_, _ = fmt.Fprintf(pc.stderr, "upload interrupted after sending %s of %s\n", formatBytes(sent), formatBytes(total))
+		return
+	}
+
+	rate := float64(sent)
+	if elapsed > 0 {
+		rate = float64(sent) / elapsed.Seconds()
+	}
+
+	_, _ = fmt.Fprintf(pc.stderr, "uploaded %s in %s (%s/s)\n", formatBytes(sent), elapsed.Round(time.Millisecond), formatBytes(int64(rate)))
+}
+
+// uploadBody records when the body of an upload was sent, so that the
+// throughput leaves out the time Ops Manager takes to process the upload
+// before it responds.
+type uploadBody struct {
+	io.ReadCloser
+	sent atomic.Int64
+}
+
+func (b *uploadBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err == io.EOF {
+		b.sent.CompareAndSwap(0, time.Now().UnixNano())
+	}
+
+	return n, err
+}
+
+// elapsed is the time from start until the body was sent, or until now when
+// the body was not read to the end.
+func (b *uploadBody) elapsed(start time.Time) time.Duration {
+	sent := b.sent.Load()
+	if sent == 0 {
+		return time.Since(start)
+	}
+
+	return time.Unix(0, sent).Sub(start)
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -5,6 +5,7 @@ import (
 	"io"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/onsi/gomega/gbytes"
 
@@ -60,6 +61,30 @@ var _ = Describe("ProgressClient", func() {
 			Expect(request.URL.Path).To(Equal("/some/endpoint"))
 
 			Eventually(buffer).Should(gbytes.Say("---] 100.00%"))
+			Eventually(buffer).Should(gbytes.Say(`uploaded 12 B in \S+ \(\d+(\.\d)? [KMGTPE]?i?B/s\)`))
+		})
+
+		It("measures the throughput of an upload until its body is sent", func() {
+			client.DoStub = func(req *http.Request) (*http.Response, error) {
+				_, err := io.Copy(io.Discard, req.Body)
+				Expect(err).ToNot(HaveOccurred())
+
+				// Ops Manager processing the upload
+				time.Sleep(300 * time.Millisecond)
+
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(strings.NewReader(`{}`)),
+				}, nil
+			}
+
+			req, err := http.NewRequest("POST", "/some/endpoint", strings.NewReader("some content"))
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = progressClient.Do(req)
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(buffer).Should(gbytes.Say(`uploaded 12 B in (0s|\d{1,2}ms) `))
 		})
 
 		It("makes a request to download the product to the Ops Manager", func() {
@@ -102,6 +127,7 @@ var _ = Describe("ProgressClient", func() {
 
 					_, err = progressClient.Do(req)
 					Expect(err).To(MatchError("some client error"))
+					Expect(buffer).To(gbytes.Say("upload interrupted after sending 0 B of 12 B"))
 				})
 			})
 
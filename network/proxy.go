@@ -0,0 +1,93 @@
+package network
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/pivotal-cf/go-pivnet/v9"
+)
+
+// ProxyConfig is the proxy for the connections to Ops Manager and UAA, with
+// the same authentication download-product supports for Pivnet. Without a
+// URL, the proxy comes from the HTTPS_PROXY and NO_PROXY environment
+// variables.
+type ProxyConfig struct {
+	URL        string
+	Username   string
+	Password   string
+	AuthType   string
+	Krb5Config string
+}
+
+// configureProxy sets the proxy of the transport. The proxy credentials are
+// only sent to the proxy: with the CONNECT request that opens a tunnel to an
+// https target, or with each request to an http target. They are sent with
+// basic authentication unless the config sets another type.
+func configureProxy(transport *http.Transport, proxy ProxyConfig) (http.RoundTripper, error) {
+	if proxy.URL == "" {
+		transport.Proxy = http.ProxyFromEnvironment
+		return transport, nil
+	}
+
+	proxyURL, err := url.Parse(proxy.URL)
+	if err != nil || proxyURL.Host == "" {
+		return nil, fmt.Errorf("could not parse proxy URL %q", proxy.URL)
+	}
+	transport.Proxy = http.ProxyURL(proxyURL)
+
+	authType := pivnet.ProxyAuthType(proxy.AuthType)
+	if authType == "" && (proxy.Username != "" || proxy.Password != "") {
+		authType = pivnet.ProxyAuthTypeBasic
+	}
+
+	if authType == "" {
+		return transport, nil
+	}
+
+	authenticator, err := pivnet.NewProxyAuthenticator(pivnet.ProxyAuthConfig{
+		ProxyURL:   proxy.URL,
+		AuthType:   authType,
+		Username:   proxy.Username,
+		Password:   proxy.Password,
+		Krb5Config: proxy.Krb5Config,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not configure proxy authentication: %w", err)
+	}
+
+	transport.GetProxyConnectHeader = func(_ context.Context, _ *url.URL, _ string) (http.Header, error) {
+		request := &http.Request{Header: http.Header{}}
+		err := authenticator.Authenticate(request)
+		if err != nil {
+			return nil, fmt.Errorf("could not authenticate with the proxy: %w", err)
+		}
+
+		return http.Header{"Proxy-Authorization": request.Header.Values("Proxy-Authorization")}, nil
+	}
+
+	return &proxyAuthTransport{
+		transport:     transport,
+		authenticator: authenticator,
+	}, nil
+}
+
+type proxyAuthTransport struct {
+	transport     http.RoundTripper
+	authenticator pivnet.ProxyAuthenticator
+}
+
+func (t *proxyAuthTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	if request.URL.Scheme != "http" {
+		return t.transport.RoundTrip(request)
+	}
+
+	request = request.Clone(request.Context())
+	err := t.authenticator.Authenticate(request)
+	if err != nil {
+		return nil, fmt.Errorf("could not authenticate with the proxy: %w", err)
+	}
+
+	return t.transport.RoundTrip(request)
+}
@@ -0,0 +1,231 @@
+package network_test
+
+import (
+	"encoding/base64"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/onsi/gomega/ghttp"
+
+	"github.com/pivotal-cf/om/network"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Proxy", func() {
+	var (
+		proxyServer    *httptest.Server
+		target         *ghttp.Server
+		proxy          network.ProxyConfig
+		proxiedMutex   sync.Mutex
+		proxiedTargets []string
+	)
+
+	BeforeEach(func() {
+		proxiedTargets = nil
+
+		proxyServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if req.Header.Get("Proxy-Authorization") != "Basic "+base64.StdEncoding.EncodeToString([]byte("proxy-user:proxy-password")) {
+				w.WriteHeader(http.StatusProxyAuthRequired)
+				return
+			}
+
+			proxiedMutex.Lock()
+			proxiedTargets = append(proxiedTargets, req.Method+" "+req.Host)
+			proxiedMutex.Unlock()
+
+			if req.Method != http.MethodConnect {
+				req.RequestURI = ""
+				req.Header.Del("Proxy-Authorization")
+				resp, err := http.DefaultTransport.RoundTrip(req)
+				if err != nil {
+					w.WriteHeader(http.StatusBadGateway)
+					return
+				}
+				defer resp.Body.Close()
+				w.WriteHeader(resp.StatusCode)
+				_, _ = io.Copy(w, resp.Body)
+				return
+			}
+
+			upstream, err := net.Dial("tcp", req.Host)
+			if err != nil {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+
+			w.WriteHeader(http.StatusOK)
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err != nil {
+				upstream.Close()
+				return
+			}
+
+			go func() {
+				defer upstream.Close()
+				_, _ = io.Copy(upstream, conn)
+			}()
+			go func() {
+				defer conn.Close()
+				_, _ = io.Copy(conn, upstream)
+			}()
+		}))
+		proxyServer.Config.ErrorLog = log.New(GinkgoWriter, "", 0)
+
+		proxy = network.ProxyConfig{
+			URL:      proxyServer.URL,
+			Username: "proxy-user",
+			Password: "proxy-password",
+			AuthType: "basic",
+		}
+	})
+
+	AfterEach(func() {
+		proxyServer.Close()
+		if target != nil {
+			target.Close()
+		}
+	})
+
+	It("connects to Ops Manager and UAA through the proxy, without sending the proxy credentials to them", func() {
+		target = ghttp.NewTLSServer()
+		target.RouteToHandler("POST", "/uaa/oauth/token", ghttp.CombineHandlers(
+			func(_ http.ResponseWriter, req *http.Request) {
+				Expect(req.Header.Get("Proxy-Authorization")).To(BeEmpty())
+			},
+			ghttp.RespondWith(http.StatusOK, `{"access_token": "some-opsman-token", "token_type": "bearer", "expires_in": 3600}`, http.Header{
+				"Content-Type": []string{"application/json"},
+			}),
+		))
+		target.RouteToHandler("GET", "/api/v0/staged/products", ghttp.CombineHandlers(
+			ghttp.VerifyHeaderKV("Authorization", "Bearer some-opsman-token"),
+			func(_ http.ResponseWriter, req *http.Request) {
+				Expect(req.Header.Get("Proxy-Authorization")).To(BeEmpty())
+			},
+			ghttp.RespondWith(http.StatusOK, "[]"),
+		))
+		target.RouteToHandler("GET", "/api/v0/info", ghttp.RespondWith(http.StatusOK, "{}"))
+
+		client, err := network.NewOAuthClient("", target.URL(), "opsman-username", "opsman-password", "", "", true, "", nil, proxy, 5*time.Second, 30*time.Second)
+		Expect(err).ToNot(HaveOccurred())
+
+		req, err := http.NewRequest("GET", "/api/v0/staged/products", nil)
+		Expect(err).ToNot(HaveOccurred())
+
+		resp, err := client.Do(req)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+		unauthenticatedClient, err := network.NewUnauthenticatedClient(target.URL(), true, "", nil, proxy, 5*time.Second, 30*time.Second)
+		Expect(err).ToNot(HaveOccurred())
+
+		req, err = http.NewRequest("GET", "/api/v0/info", nil)
+		Expect(err).ToNot(HaveOccurred())
+
+		resp, err = unauthenticatedClient.Do(req)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+		proxiedMutex.Lock()
+		defer proxiedMutex.Unlock()
+		Expect(proxiedTargets).ToNot(BeEmpty())
+		for _, proxied := range proxiedTargets {
+			Expect(proxied).To(Equal("CONNECT " + target.Addr()))
+		}
+	})
+
+	It("authenticates each request to an http target with the proxy", func() {
+		target = ghttp.NewServer()
+		target.AppendHandlers(ghttp.CombineHandlers(
+			ghttp.VerifyRequest("GET", "/api/v0/info"),
+			ghttp.RespondWith(http.StatusOK, "{}"),
+		))
+
+		client, err := network.NewUnauthenticatedClient(target.URL(), true, "", nil, proxy, 5*time.Second, 30*time.Second)
+		Expect(err).ToNot(HaveOccurred())
+
+		req, err := http.NewRequest("GET", "/api/v0/info", nil)
+		Expect(err).ToNot(HaveOccurred())
+
+		resp, err := client.Do(req)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		Expect(req.Header.Get("Proxy-Authorization")).To(BeEmpty())
+
+		proxiedMutex.Lock()
+		defer proxiedMutex.Unlock()
+		Expect(proxiedTargets).To(Equal([]string{"GET " + target.Addr()}))
+	})
+
+	It("uses basic authentication when the credentials have no authentication type", func() {
+		target = ghttp.NewServer()
+		target.AppendHandlers(ghttp.CombineHandlers(
+			ghttp.VerifyRequest("GET", "/api/v0/info"),
+			ghttp.RespondWith(http.StatusOK, "{}"),
+		))
+		proxy.AuthType = ""
+
+		client, err := network.NewUnauthenticatedClient(target.URL(), true, "", nil, proxy, 5*time.Second, 30*time.Second)
+		Expect(err).ToNot(HaveOccurred())
+
+		req, err := http.NewRequest("GET", "/api/v0/info", nil)
+		Expect(err).ToNot(HaveOccurred())
+
+		resp, err := client.Do(req)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+		proxiedMutex.Lock()
+		defer proxiedMutex.Unlock()
+		Expect(proxiedTargets).To(Equal([]string{"GET " + target.Addr()}))
+	})
+
+	When("the proxy rejects the credentials", func() {
+		It("returns an error", func() {
+			target = ghttp.NewTLSServer()
+			proxy.Password = "wrong-password"
+
+			client, err := network.NewUnauthenticatedClient(target.URL(), true, "", nil, proxy, 5*time.Second, 30*time.Second)
+			Expect(err).ToNot(HaveOccurred())
+
+			req, err := http.NewRequest("GET", "/api/v0/info", nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = client.Do(req)
+			Expect(err).To(MatchError(ContainSubstring("Proxy Authentication Required")))
+		})
+	})
+
+	When("the proxy configuration is invalid", func() {
+		It("returns an error for a proxy URL without a host", func() {
+			proxy.URL = "not-a-url"
+
+			_, err := network.NewUnauthenticatedClient("opsman.example.com", true, "", nil, proxy, 5*time.Second, 30*time.Second)
+			Expect(err).To(MatchError(`could not parse proxy URL "not-a-url"`))
+		})
+
+		It("returns an error for an unsupported authentication type", func() {
+			proxy.AuthType = "ntlm"
+
+			_, err := network.NewOAuthClient("", "opsman.example.com", "", "", "", "", true, "", nil, proxy, 5*time.Second, 30*time.Second)
+			Expect(err).To(MatchError(ContainSubstring("unsupported proxy authentication type: ntlm")))
+		})
+
+		It("returns an error when the Kerberos configuration of SPNEGO cannot be loaded", func() {
+			proxy.AuthType = "spnego"
+			proxy.Krb5Config = filepath.Join(GinkgoT().TempDir(), "krb5.conf")
+			Expect(os.WriteFile(proxy.Krb5Config, []byte("not a krb5 config ["), 0600)).To(Succeed())
+
+			_, err := network.NewOAuthClient("", "opsman.example.com", "", "", "", "", true, "", nil, proxy, 5*time.Second, 30*time.Second)
+			Expect(err).To(MatchError(ContainSubstring("could not configure proxy authentication")))
+		})
+	})
+})
@@ -0,0 +1,184 @@
+package network
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+const redacted = "[REDACTED]"
+
+var (
+	sensitiveHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+	// sensitiveKey matches the JSON and form keys of passwords, secrets,
+	// tokens and private keys, such as the client_secret of a client
+	// credentials grant or the private_key_pem of a credential
+	sensitiveKey = regexp.MustCompile(`(?i)(password|passphrase|passcode|secret|(^|_)token$|private_?key)`)
+
+	privateKeyPEM = regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`)
+)
+
+// redactedRequest returns a copy of the request to trace, without the
+// credentials of the request. Reading the body to redact it leaves the body
+// of the request as it was.
+func redactedRequest(request *http.Request, withBody bool) (*http.Request, error) {
+	traced := request.Clone(request.Context())
+	traced.Header = redactHeader(request.Header)
+	traced.URL = redactURL(request.URL)
+
+	if !withBody || request.Body == nil || request.Body == http.NoBody {
+		return traced, nil
+	}
+
+	body, err := io.ReadAll(request.Body)
+	if err != nil {
+		return nil, err
+	}
+	request.Body.Close()
+	request.Body = io.NopCloser(bytes.NewReader(body))
+
+	redactedBody := redactBody(request.Header.Get("Content-Type"), body)
+	traced.Body = io.NopCloser(bytes.NewReader(redactedBody))
+	if !bytes.Equal(body, redactedBody) {
+		traced.ContentLength = int64(len(redactedBody))
+	}
+
+	return traced, nil
+}
+
+// redactedResponse is redactedRequest for a response.
+func redactedResponse(response *http.Response, withBody bool) (*http.Response, error) {
+	traced := *response
+	traced.Header = redactHeader(response.Header)
+
+	if !withBody || response.Body == nil || response.Body == http.NoBody {
+		return &traced, nil
+	}
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+	response.Body.Close()
+	response.Body = io.NopCloser(bytes.NewReader(body))
+
+	redactedBody := redactBody(response.Header.Get("Content-Type"), body)
+	traced.Body = io.NopCloser(bytes.NewReader(redactedBody))
+	if !bytes.Equal(body, redactedBody) {
+		traced.ContentLength = int64(len(redactedBody))
+	}
+
+	return &traced, nil
+}
+
+func redactHeader(header http.Header) http.Header {
+	if header == nil {
+		return nil
+	}
+
+	redactedHeader := header.Clone()
+	for _, name := range sensitiveHeaders {
+		if values := redactedHeader.Values(name); len(values) > 0 {
+			redactedHeader.Set(name, redacted)
+		}
+	}
+
+	return redactedHeader
+}
+
+func redactURL(u *url.URL) *url.URL {
+	if u == nil || u.RawQuery == "" {
+		return u
+	}
+
+	query, err := url.ParseQuery(u.RawQuery)
+	if err != nil || !redactValues(query) {
+		return u
+	}
+
+	redactedURL := *u
+	redactedURL.RawQuery = query.Encode()
+	return &redactedURL
+}
+
+// redactBody redacts the sensitive values of JSON and form bodies, and the
+// PEM encoded private keys of any other body. A body without anything to
+// redact is returned as it is.
+func redactBody(contentType string, body []byte) []byte {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+
+	switch {
+	case mediaType == "application/x-www-form-urlencoded":
+		values, err := url.ParseQuery(string(body))
+		if err == nil && redactValues(values) {
+			return []byte(values.Encode())
+		}
+	case json.Valid(body):
+		decoder := json.NewDecoder(bytes.NewReader(body))
+		decoder.UseNumber()
+
+		var value interface{}
+		if decoder.Decode(&value) == nil {
+			if redactedValue, changed := redactJSON(value); changed {
+				redactedBody, err := json.Marshal(redactedValue)
+				if err == nil {
+					return redactedBody
+				}
+			}
+			return body
+		}
+	}
+
+	return privateKeyPEM.ReplaceAll(body, []byte(redacted))
+}
+
+func redactValues(values url.Values) bool {
+	changed := false
+	for key := range values {
+		if sensitiveKey.MatchString(key) {
+			values[key] = []string{redacted}
+			changed = true
+		}
+	}
+
+	return changed
+}
+
+func redactJSON(value interface{}) (interface{}, bool) {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		changed := false
+		for key, nested := range value {
+			if sensitiveKey.MatchString(key) && nested != nil {
+				value[key] = redacted
+				changed = true
+				continue
+			}
+
+			var nestedChanged bool
+			value[key], nestedChanged = redactJSON(nested)
+			changed = changed || nestedChanged
+		}
+		return value, changed
+	case []interface{}:
+		changed := false
+		for i, nested := range value {
+			var nestedChanged bool
+			value[i], nestedChanged = redactJSON(nested)
+			changed = changed || nestedChanged
+		}
+		return value, changed
+	case string:
+		if strings.Contains(value, "PRIVATE KEY-----") {
+			return redacted, true
+		}
+	}
+
+	return value, false
+}
@@ -0,0 +1,166 @@
+package network
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+const (
+	maxRetryBackoff = 30 * time.Second
+	maxRetryAfter   = 5 * time.Minute
+)
+
+// idempotentPuts are the PUT endpoints that replace a configuration, so
+// sending them again has the same result as sending them once.
+var idempotentPuts = []*regexp.Regexp{
+	regexp.MustCompile(`^/api/v0/staged/director/(properties|networks|network_and_az|availability_zones/[^/]+|iaas_configurations/[^/]+|verifiers/install_time/[^/]+)$`),
+	regexp.MustCompile(`^/api/v0/staged/products/[^/]+/(properties|networks_and_azs|syslog_configuration|max_in_flight|errands|jobs/[^/]+/resource_config|verifiers/install_time/[^/]+)$`),
+	regexp.MustCompile(`^/api/v0/staged/vm_extensions/[^/]+$`),
+	regexp.MustCompile(`^/api/v0/vm_types$`),
+}
+
+// RetryClient sends idempotent requests again when Ops Manager cannot be
+// reached or is not ready to serve them, such as while it restarts.
+type RetryClient struct {
+	client     httpClient
+	maxRetries int
+	backoff    time.Duration
+	writer     io.Writer
+}
+
+// NewRetryClient retries a request up to maxRetries times, waiting from
+// backoff for the first retry and twice as long for each following one.
+func NewRetryClient(client httpClient, maxRetries int, backoff time.Duration, writer io.Writer) *RetryClient {
+	return &RetryClient{
+		client:     client,
+		maxRetries: maxRetries,
+		backoff:    backoff,
+		writer:     writer,
+	}
+}
+
+func (c *RetryClient) Do(request *http.Request) (*http.Response, error) {
+	if c.maxRetries <= 0 || !isIdempotent(request) {
+		return c.client.Do(request)
+	}
+
+	for attempt := 1; ; attempt++ {
+		response, err := c.client.Do(request)
+		if attempt > c.maxRetries || !shouldRetry(response, err) {
+			return response, err
+		}
+
+		if request.Body != nil && request.Body != http.NoBody {
+			if request.GetBody == nil {
+				return response, err
+			}
+
+			body, bodyErr := request.GetBody()
+			if bodyErr != nil {
+				return response, err
+			}
+			request.Body = body
+		}
+
+		wait := c.wait(attempt, response)
+
+		reason := ""
+		if err != nil {
+			reason = err.Error()
+		} else {
+			reason = response.Status
+			_, _ = io.Copy(io.Discard, io.LimitReader(response.Body, maxBodySize))
+			response.Body.Close()
+		}
+
+		fmt.Fprintf(c.writer, "retrying %s %s in %s (retry %d of %d): %s\n", request.Method, request.URL.Path, wait.Round(time.Millisecond), attempt, c.maxRetries, reason)
+
+		select {
+		case <-request.Context().Done():
+			return nil, request.Context().Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+// wait honours the Retry-After header of the response, and otherwise backs
+// off exponentially with jitter, so that many om processes do not all retry
+// at the same time.
+func (c *RetryClient) wait(attempt int, response *http.Response) time.Duration {
+	if response != nil {
+		if wait, ok := retryAfter(response.Header.Get("Retry-After")); ok {
+			return wait
+		}
+	}
+
+	backoff := c.backoff << (attempt - 1)
+	if backoff <= 0 || backoff > maxRetryBackoff {
+		backoff = maxRetryBackoff
+	}
+
+	return backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+}
+
+func retryAfter(header string) (time.Duration, bool) {
+	if header == "" {
+		return 0, false
+	}
+
+	var wait time.Duration
+	if seconds, err := strconv.Atoi(header); err == nil {
+		wait = time.Duration(seconds) * time.Second
+	} else if date, err := http.ParseTime(header); err == nil {
+		wait = time.Until(date)
+	} else {
+		return 0, false
+	}
+
+	if wait < 0 {
+		wait = 0
+	}
+	if wait > maxRetryAfter {
+		wait = maxRetryAfter
+	}
+
+	return wait, true
+}
+
+func isIdempotent(request *http.Request) bool {
+	switch request.Method {
+	case http.MethodGet, http.MethodHead:
+		return true
+	case http.MethodPut:
+		for _, path := range idempotentPuts {
+			if path.MatchString(request.URL.Path) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func shouldRetry(response *http.Response, err error) bool {
+	if err != nil {
+		if isTLSAlert(err) {
+			return false
+		}
+
+		var opErr *net.OpError
+		return errors.As(err, &opErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+	}
+
+	switch response.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout, http.StatusTooManyRequests:
+		return true
+	}
+
+	return false
+}
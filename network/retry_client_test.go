@@ -0,0 +1,180 @@
+package network_test
+
+import (
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+
+	"github.com/pivotal-cf/om/network"
+	"github.com/pivotal-cf/om/network/fakes"
+)
+
+var _ = Describe("RetryClient", func() {
+	var (
+		fakeClient *fakes.HttpClient
+		out        *gbytes.Buffer
+	)
+
+	response := func(statusCode int, body string) *http.Response {
+		return &http.Response{
+			StatusCode: statusCode,
+			Status:     http.StatusText(statusCode),
+			Header:     http.Header{},
+			Body:       io.NopCloser(strings.NewReader(body)),
+		}
+	}
+
+	BeforeEach(func() {
+		fakeClient = &fakes.HttpClient{}
+		out = gbytes.NewBuffer()
+	})
+
+	It("retries GET requests on gateway errors until they succeed", func() {
+		fakeClient.DoReturnsOnCall(0, response(http.StatusBadGateway, "bad gateway"), nil)
+		fakeClient.DoReturnsOnCall(1, response(http.StatusServiceUnavailable, "unavailable"), nil)
+		fakeClient.DoReturnsOnCall(2, response(http.StatusOK, "some-body"), nil)
+
+		client := network.NewRetryClient(fakeClient, 3, time.Millisecond, out)
+
+		request, err := http.NewRequest("GET", "/api/v0/staged/products", nil)
+		Expect(err).ToNot(HaveOccurred())
+
+		resp, err := client.Do(request)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		Expect(fakeClient.DoCallCount()).To(Equal(3))
+
+		Expect(out).To(gbytes.Say(`retrying GET /api/v0/staged/products in \S+ \(retry 1 of 3\): Bad Gateway`))
+		Expect(out).To(gbytes.Say(`retrying GET /api/v0/staged/products in \S+ \(retry 2 of 3\): Service Unavailable`))
+	})
+
+	It("retries on connection errors", func() {
+		fakeClient.DoReturnsOnCall(0, nil, &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")})
+		fakeClient.DoReturnsOnCall(1, response(http.StatusOK, ""), nil)
+
+		client := network.NewRetryClient(fakeClient, 3, time.Millisecond, out)
+
+		request, err := http.NewRequest("HEAD", "/api/v0/info", nil)
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = client.Do(request)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fakeClient.DoCallCount()).To(Equal(2))
+		Expect(out).To(gbytes.Say(`retrying HEAD /api/v0/info .*: dial tcp: connection refused`))
+	})
+
+	It("returns the last response once the retries are exhausted", func() {
+		fakeClient.DoReturns(response(http.StatusGatewayTimeout, ""), nil)
+
+		client := network.NewRetryClient(fakeClient, 2, time.Millisecond, out)
+
+		request, err := http.NewRequest("GET", "/api/v0/staged/products", nil)
+		Expect(err).ToNot(HaveOccurred())
+
+		resp, err := client.Do(request)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resp.StatusCode).To(Equal(http.StatusGatewayTimeout))
+		Expect(fakeClient.DoCallCount()).To(Equal(3))
+	})
+
+	It("waits for the Retry-After of the response", func() {
+		tooManyRequests := response(http.StatusTooManyRequests, "")
+		tooManyRequests.Header.Set("Retry-After", "1")
+		fakeClient.DoReturnsOnCall(0, tooManyRequests, nil)
+		fakeClient.DoReturnsOnCall(1, response(http.StatusOK, ""), nil)
+
+		client := network.NewRetryClient(fakeClient, 3, time.Millisecond, out)
+
+		request, err := http.NewRequest("GET", "/api/v0/staged/products", nil)
+		Expect(err).ToNot(HaveOccurred())
+
+		start := time.Now()
+		_, err = client.Do(request)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(time.Since(start)).To(BeNumerically(">=", time.Second))
+		Expect(out).To(gbytes.Say(`retrying GET /api/v0/staged/products in 1s`))
+	})
+
+	It("sends the body again when retrying a PUT that replaces a configuration", func() {
+		var bodies []string
+		fakeClient.DoStub = func(request *http.Request) (*http.Response, error) {
+			body, err := io.ReadAll(request.Body)
+			Expect(err).ToNot(HaveOccurred())
+			bodies = append(bodies, string(body))
+
+			if len(bodies) == 1 {
+				return response(http.StatusBadGateway, ""), nil
+			}
+			return response(http.StatusOK, ""), nil
+		}
+
+		client := network.NewRetryClient(fakeClient, 3, time.Millisecond, out)
+
+		request, err := http.NewRequest("PUT", "/api/v0/staged/products/some-guid/properties", strings.NewReader(`{"properties": {}}`))
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = client.Do(request)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(bodies).To(Equal([]string{`{"properties": {}}`, `{"properties": {}}`}))
+	})
+
+	DescribeTable("does not retry requests that are not known to be idempotent",
+		func(method, path string) {
+			fakeClient.DoReturns(response(http.StatusBadGateway, ""), nil)
+
+			client := network.NewRetryClient(fakeClient, 3, time.Millisecond, out)
+
+			request, err := http.NewRequest(method, path, strings.NewReader("{}"))
+			Expect(err).ToNot(HaveOccurred())
+
+			resp, err := client.Do(request)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resp.StatusCode).To(Equal(http.StatusBadGateway))
+			Expect(fakeClient.DoCallCount()).To(Equal(1))
+		},
+		Entry("POST", "POST", "/api/v0/installations"),
+		Entry("DELETE", "DELETE", "/api/v0/staged/products/some-guid"),
+		Entry("PUT of an upgrade", "PUT", "/api/v0/staged/products/some-guid"),
+	)
+
+	It("does not retry other errors", func() {
+		fakeClient.DoReturns(response(http.StatusInternalServerError, ""), nil)
+
+		client := network.NewRetryClient(fakeClient, 3, time.Millisecond, out)
+
+		request, err := http.NewRequest("GET", "/api/v0/staged/products", nil)
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = client.Do(request)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fakeClient.DoCallCount()).To(Equal(1))
+
+		fakeClient.DoReturns(nil, errors.New("could not parse Opsman target URL"))
+
+		_, err = client.Do(request)
+		Expect(err).To(MatchError("could not parse Opsman target URL"))
+		Expect(fakeClient.DoCallCount()).To(Equal(2))
+	})
+
+	When("retries are disabled", func() {
+		It("sends the request once", func() {
+			fakeClient.DoReturns(response(http.StatusBadGateway, ""), nil)
+
+			client := network.NewRetryClient(fakeClient, 0, time.Millisecond, out)
+
+			request, err := http.NewRequest("GET", "/api/v0/staged/products", nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = client.Do(request)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fakeClient.DoCallCount()).To(Equal(1))
+		})
+	})
+})
@@ -0,0 +1,218 @@
+package network
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/oauth2"
+)
+
+// TokenCache persists UAA tokens between om invocations, in a file only
+// readable by the current user.
+type TokenCache struct {
+	path string
+}
+
+func NewTokenCache(path string) *TokenCache {
+	return &TokenCache{path: path}
+}
+
+// DefaultTokenCachePath is om/uaa-tokens.json under $XDG_CACHE_HOME, or
+// under the user cache directory of the platform when it is not set.
+func DefaultTokenCachePath() (string, error) {
+	cacheDir := os.Getenv("XDG_CACHE_HOME")
+	if cacheDir == "" {
+		var err error
+		cacheDir, err = os.UserCacheDir()
+		if err != nil {
+			return "", fmt.Errorf("could not find a directory for the token cache: %w", err)
+		}
+	}
+
+	return filepath.Join(cacheDir, "om", "uaa-tokens.json"), nil
+}
+
+// TokenCacheKey identifies the tokens of a principal, as returned by
+// TokenCachePrincipal, for an Ops Manager and its UAA.
+func TokenCacheKey(opsmanTarget, uaaTarget, principal string) (string, error) {
+	opsmanURL, uaaURL, err := parseOpsmanAndUAAURLs(opsmanTarget, uaaTarget)
+	if err != nil {
+		return "", err
+	}
+
+	return tokenCacheKey(opsmanURL, uaaURL, principal), nil
+}
+
+// TokenCachePrincipal is the username when om authenticates with a password,
+// the client ID when it authenticates with client credentials,
+// credential-process when it gets its credentials from one, and sso for the
+// session of om login --sso otherwise. It also returns the secret the token
+// of the principal is bound to: the password, the client secret or the
+// credential process, so that a token is not reused once the secret changes,
+// or for a wrong one.
+func TokenCachePrincipal(username, password, clientID, clientSecret, credentialProcess string) (string, string) {
+	if username != "" && password != "" {
+		return username, password
+	}
+
+	if clientID != "" {
+		return clientID, clientSecret
+	}
+
+	// the credentials of a credential process are only known once it runs,
+	// which it should not for a cached token
+	if credentialProcess != "" {
+		return "credential-process", credentialProcess
+	}
+
+	return "sso", ""
+}
+
+func tokenCacheKey(opsmanURL, uaaURL *url.URL, principal string) string {
+	return fmt.Sprintf("%s %s %s", opsmanURL, uaaURL, principal)
+}
+
+// tokenCacheFile holds the tokens by key, along with an HMAC of the secret
+// each token is bound to. The HMAC key is generated for each cache, so that
+// the secrets cannot be looked up in precomputed tables of hashes.
+type tokenCacheFile struct {
+	Key    []byte                 `json:"key"`
+	Tokens map[string]cachedToken `json:"tokens"`
+}
+
+type cachedToken struct {
+	Token  *oauth2.Token `json:"token"`
+	Secret []byte        `json:"secret"`
+}
+
+func (f tokenCacheFile) mac(secret string) []byte {
+	mac := hmac.New(sha256.New, f.Key)
+	mac.Write([]byte(secret))
+	return mac.Sum(nil)
+}
+
+// Load returns nil when the cache has no token for the key, or only one
+// stored with another secret.
+func (c *TokenCache) Load(key, secret string) (*oauth2.Token, error) {
+	file, err := c.read()
+	if err != nil {
+		return nil, err
+	}
+
+	cached, ok := file.Tokens[key]
+	if !ok || !hmac.Equal(cached.Secret, file.mac(secret)) {
+		return nil, nil
+	}
+
+	return cached.Token, nil
+}
+
+func (c *TokenCache) Store(key, secret string, token *oauth2.Token) error {
+	file, err := c.read()
+	if err != nil {
+		return err
+	}
+
+	if len(file.Key) == 0 {
+		file.Key = make([]byte, sha256.Size)
+		_, err = rand.Read(file.Key)
+		if err != nil {
+			return fmt.Errorf("could not generate a key for the token cache: %w", err)
+		}
+
+		// the tokens of another key cannot be loaded anymore
+		file.Tokens = map[string]cachedToken{}
+	}
+
+	file.Tokens[key] = cachedToken{Token: token, Secret: file.mac(secret)}
+	return c.write(file)
+}
+
+func (c *TokenCache) Delete(key string) error {
+	file, err := c.read()
+	if err != nil {
+		return err
+	}
+
+	if _, ok := file.Tokens[key]; !ok {
+		return nil
+	}
+
+	delete(file.Tokens, key)
+	return c.write(file)
+}
+
+// Clear removes the tokens of every principal and target.
+func (c *TokenCache) Clear() error {
+	err := os.Remove(c.path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("could not clear the token cache: %w", err)
+	}
+
+	return nil
+}
+
+func (c *TokenCache) read() (tokenCacheFile, error) {
+	file := tokenCacheFile{Tokens: map[string]cachedToken{}}
+
+	contents, err := os.ReadFile(c.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return file, nil
+	}
+	if err != nil {
+		return file, fmt.Errorf("could not read the token cache: %w", err)
+	}
+
+	err = json.Unmarshal(contents, &file)
+	if err != nil || file.Tokens == nil {
+		// a corrupt cache only costs a new token
+		return tokenCacheFile{Tokens: map[string]cachedToken{}}, nil
+	}
+
+	return file, nil
+}
+
+// write replaces the cache file atomically, so that om invocations running
+// at the same time never read a partially written cache.
+func (c *TokenCache) write(cache tokenCacheFile) error {
+	err := os.MkdirAll(filepath.Dir(c.path), 0700)
+	if err != nil {
+		return fmt.Errorf("could not write the token cache: %w", err)
+	}
+
+	contents, err := json.Marshal(cache)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(c.path), ".uaa-tokens-*")
+	if err != nil {
+		return fmt.Errorf("could not write the token cache: %w", err)
+	}
+	defer os.Remove(file.Name())
+
+	err = file.Chmod(0600)
+	if err == nil {
+		_, err = file.Write(contents)
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("could not write the token cache: %w", err)
+	}
+
+	err = os.Rename(file.Name(), c.path)
+	if err != nil {
+		return fmt.Errorf("could not write the token cache: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,170 @@
+package network_test
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"golang.org/x/oauth2"
+
+	"github.com/pivotal-cf/om/network"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("TokenCache", func() {
+	var (
+		cachePath string
+		cache     *network.TokenCache
+	)
+
+	BeforeEach(func() {
+		cachePath = filepath.Join(GinkgoT().TempDir(), "om", "uaa-tokens.json")
+		cache = network.NewTokenCache(cachePath)
+	})
+
+	It("stores and loads tokens by key", func() {
+		expiry := time.Now().Add(time.Hour).Round(time.Second)
+
+		err := cache.Store("some-key", "some-secret", &oauth2.Token{AccessToken: "some-token", RefreshToken: "some-refresh-token", Expiry: expiry})
+		Expect(err).ToNot(HaveOccurred())
+		err = cache.Store("other-key", "other-secret", &oauth2.Token{AccessToken: "other-token"})
+		Expect(err).ToNot(HaveOccurred())
+
+		token, err := cache.Load("some-key", "some-secret")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(token.AccessToken).To(Equal("some-token"))
+		Expect(token.RefreshToken).To(Equal("some-refresh-token"))
+		Expect(token.Expiry.Equal(expiry)).To(BeTrue())
+
+		token, err = cache.Load("other-key", "other-secret")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(token.AccessToken).To(Equal("other-token"))
+	})
+
+	It("does not load a token stored with another secret", func() {
+		Expect(cache.Store("some-key", "some-secret", &oauth2.Token{AccessToken: "some-token"})).To(Succeed())
+
+		token, err := cache.Load("some-key", "other-secret")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(token).To(BeNil())
+	})
+
+	It("stores an HMAC of the secret with a key of its own", func() {
+		Expect(cache.Store("some-key", "some-secret", &oauth2.Token{AccessToken: "some-token"})).To(Succeed())
+
+		contents, err := os.ReadFile(cachePath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(contents)).ToNot(ContainSubstring("some-secret"))
+
+		unsaltedHash := sha256.Sum256([]byte("some-secret"))
+		Expect(string(contents)).ToNot(ContainSubstring(fmt.Sprintf("%x", unsaltedHash)))
+		Expect(string(contents)).ToNot(ContainSubstring(base64.StdEncoding.EncodeToString(unsaltedHash[:])))
+
+		otherPath := filepath.Join(GinkgoT().TempDir(), "uaa-tokens.json")
+		Expect(network.NewTokenCache(otherPath).Store("some-key", "some-secret", &oauth2.Token{AccessToken: "some-token"})).To(Succeed())
+		otherContents, err := os.ReadFile(otherPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(otherContents).ToNot(Equal(contents))
+	})
+
+	It("only lets the current user read the cache", func() {
+		err := cache.Store("some-key", "some-secret", &oauth2.Token{AccessToken: "some-token"})
+		Expect(err).ToNot(HaveOccurred())
+
+		info, err := os.Stat(cachePath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(info.Mode().Perm()).To(Equal(os.FileMode(0600)))
+
+		info, err = os.Stat(filepath.Dir(cachePath))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(info.Mode().Perm()).To(Equal(os.FileMode(0700)))
+	})
+
+	It("returns no token when the cache does not exist", func() {
+		token, err := cache.Load("some-key", "some-secret")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(token).To(BeNil())
+	})
+
+	It("returns no token when the cache is corrupt", func() {
+		Expect(os.MkdirAll(filepath.Dir(cachePath), 0700)).To(Succeed())
+		Expect(os.WriteFile(cachePath, []byte("{not json"), 0600)).To(Succeed())
+
+		token, err := cache.Load("some-key", "some-secret")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(token).To(BeNil())
+	})
+
+	It("deletes the token of a key", func() {
+		Expect(cache.Store("some-key", "some-secret", &oauth2.Token{AccessToken: "some-token"})).To(Succeed())
+		Expect(cache.Store("other-key", "other-secret", &oauth2.Token{AccessToken: "other-token"})).To(Succeed())
+
+		Expect(cache.Delete("some-key")).To(Succeed())
+
+		token, err := cache.Load("some-key", "some-secret")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(token).To(BeNil())
+
+		token, err = cache.Load("other-key", "other-secret")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(token.AccessToken).To(Equal("other-token"))
+	})
+
+	It("clears every token", func() {
+		Expect(cache.Store("some-key", "some-secret", &oauth2.Token{AccessToken: "some-token"})).To(Succeed())
+
+		Expect(cache.Clear()).To(Succeed())
+		Expect(cachePath).ToNot(BeAnExistingFile())
+
+		By("succeeding when there is nothing to clear")
+		Expect(cache.Clear()).To(Succeed())
+	})
+
+	Describe("DefaultTokenCachePath", func() {
+		It("is under $XDG_CACHE_HOME", func() {
+			GinkgoT().Setenv("XDG_CACHE_HOME", "/some/cache")
+
+			path, err := network.DefaultTokenCachePath()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(path).To(Equal("/some/cache/om/uaa-tokens.json"))
+		})
+	})
+
+	Describe("TokenCacheKey", func() {
+		It("differs by target, UAA and principal", func() {
+			key, err := network.TokenCacheKey("opsman.example.com", "", "some-user")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(key).To(Equal("https://opsman.example.com https://opsman.example.com/uaa some-user"))
+
+			otherKey, err := network.TokenCacheKey("opsman.example.com", "https://uaa.example.com", "some-user")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(otherKey).ToNot(Equal(key))
+
+			principal, _ := network.TokenCachePrincipal("", "", "", "", "")
+			otherKey, err = network.TokenCacheKey("opsman.example.com", "", principal)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(otherKey).To(Equal("https://opsman.example.com https://opsman.example.com/uaa sso"))
+		})
+	})
+
+	Describe("TokenCachePrincipal", func() {
+		It("binds the token of the principal to its password or client secret", func() {
+			principal, secret := network.TokenCachePrincipal("some-user", "some-password", "", "", "")
+			Expect(principal).To(Equal("some-user"))
+			Expect(secret).To(Equal("some-password"))
+
+			principal, secret = network.TokenCachePrincipal("", "", "some-client", "some-secret", "")
+			Expect(principal).To(Equal("some-client"))
+			Expect(secret).To(Equal("some-secret"))
+
+			principal, secret = network.TokenCachePrincipal("", "", "", "", "vault-om-credentials")
+			Expect(principal).To(Equal("credential-process"))
+			Expect(secret).To(Equal("vault-om-credentials"))
+		})
+	})
+})
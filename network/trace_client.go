@@ -18,12 +18,16 @@ type httpClient interface {
 type TraceClient struct {
 	client httpClient
 	writer io.Writer
+	redact bool
 }
 
-func NewTraceClient(client httpClient, writer io.Writer) *TraceClient {
+// NewTraceClient prints each request and response to the writer. With
+// redact, credentials in headers and bodies are printed as [REDACTED].
+func NewTraceClient(client httpClient, writer io.Writer, redact bool) *TraceClient {
 	return &TraceClient{
 		client: client,
 		writer: writer,
+		redact: redact,
 	}
 }
 
@@ -33,7 +37,16 @@ func (c *TraceClient) Do(request *http.Request) (*http.Response, error) {
 		dumpRequestBody = false
 	}
 
-	requestOutput, err := httputil.DumpRequest(request, dumpRequestBody)
+	traced := request
+	if c.redact {
+		var err error
+		traced, err = redactedRequest(request, dumpRequestBody)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	requestOutput, err := httputil.DumpRequest(traced, dumpRequestBody)
 	if err != nil {
 		return nil, err
 	}
@@ -49,7 +62,16 @@ func (c *TraceClient) Do(request *http.Request) (*http.Response, error) {
 	if response.ContentLength >= maxBodySize {
 		dumpResponseBody = false
 	}
-	responseOutput, err := httputil.DumpResponse(response, dumpResponseBody)
+
+	tracedResponse := response
+	if c.redact {
+		tracedResponse, err = redactedResponse(response, dumpResponseBody)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	responseOutput, err := httputil.DumpResponse(tracedResponse, dumpResponseBody)
 	if err != nil {
 		return nil, err
 	}
@@ -1,6 +1,7 @@
 package network
 
 import (
+	"crypto/tls"
 	"net/http"
 	"time"
 )
@@ -10,8 +11,8 @@ type UnauthenticatedClient struct {
 	client *http.Client
 }
 
-func NewUnauthenticatedClient(target string, insecureSkipVerify bool, caCert string, connectTimeout time.Duration, requestTimeout time.Duration) (UnauthenticatedClient, error) {
-	client, err := newHTTPClient(insecureSkipVerify, caCert, requestTimeout, connectTimeout)
+func NewUnauthenticatedClient(target string, insecureSkipVerify bool, caCert string, clientCertificate *tls.Certificate, proxy ProxyConfig, connectTimeout time.Duration, requestTimeout time.Duration) (UnauthenticatedClient, error) {
+	client, err := newHTTPClient(insecureSkipVerify, caCert, clientCertificate, proxy, requestTimeout, connectTimeout)
 	if err != nil {
 		return UnauthenticatedClient{}, err
 	}
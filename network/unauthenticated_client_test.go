@@ -36,7 +36,7 @@ var _ = Describe("UnauthenticatedClient", func() {
 			}))
 			server.Config.ErrorLog = log.New(GinkgoWriter, "", 0)
 
-			client, _ := network.NewUnauthenticatedClient(server.URL, true, "", time.Duration(5)*time.Second, time.Duration(30)*time.Second)
+			client, _ := network.NewUnauthenticatedClient(server.URL, true, "", nil, network.ProxyConfig{}, time.Duration(5)*time.Second, time.Duration(30)*time.Second)
 
 			request, err := http.NewRequest("GET", "/path?query", strings.NewReader("request"))
 			Expect(err).ToNot(HaveOccurred())
@@ -77,7 +77,7 @@ var _ = Describe("UnauthenticatedClient", func() {
 				noScheme.Scheme = ""
 				finalURL := strings.Replace(noScheme.String(), "//", "", 1)
 
-				client, _ := network.NewUnauthenticatedClient(finalURL, true, "", time.Duration(5)*time.Second, time.Duration(30)*time.Second)
+				client, _ := network.NewUnauthenticatedClient(finalURL, true, "", nil, network.ProxyConfig{}, time.Duration(5)*time.Second, time.Duration(30)*time.Second)
 				Expect(err).ToNot(HaveOccurred())
 
 				request, err := http.NewRequest("GET", "/some/path", strings.NewReader("request-body"))
@@ -105,7 +105,7 @@ var _ = Describe("UnauthenticatedClient", func() {
 				Expect(err).ToNot(HaveOccurred())
 				pemCert := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}))
 
-				client, err := network.NewUnauthenticatedClient(server.URL, false, pemCert, time.Duration(5)*time.Second, time.Duration(30)*time.Second)
+				client, err := network.NewUnauthenticatedClient(server.URL, false, pemCert, nil, network.ProxyConfig{}, time.Duration(5)*time.Second, time.Duration(30)*time.Second)
 				Expect(err).ToNot(HaveOccurred())
 
 				request, err := http.NewRequest("GET", "/path?query", strings.NewReader("request"))
@@ -120,7 +120,7 @@ var _ = Describe("UnauthenticatedClient", func() {
 				Expect(err).ToNot(HaveOccurred())
 				pemCert := writeFile(string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})))
 
-				client, err := network.NewUnauthenticatedClient(server.URL, false, pemCert, time.Duration(5)*time.Second, time.Duration(30)*time.Second)
+				client, err := network.NewUnauthenticatedClient(server.URL, false, pemCert, nil, network.ProxyConfig{}, time.Duration(5)*time.Second, time.Duration(30)*time.Second)
 				Expect(err).ToNot(HaveOccurred())
 
 				request, err := http.NewRequest("GET", "/path?query", strings.NewReader("request"))
@@ -137,7 +137,7 @@ var _ = Describe("UnauthenticatedClient", func() {
 			nonTLS12Server.Config.ErrorLog = log.New(GinkgoWriter, "", 0)
 			defer nonTLS12Server.Close()
 
-			client, _ := network.NewUnauthenticatedClient(nonTLS12Server.URL, true, "", time.Duration(5)*time.Second, time.Duration(30)*time.Second)
+			client, _ := network.NewUnauthenticatedClient(nonTLS12Server.URL, true, "", nil, network.ProxyConfig{}, time.Duration(5)*time.Second, time.Duration(30)*time.Second)
 
 			req, err := http.NewRequest("GET", "/some/path", strings.NewReader("request-body"))
 			Expect(err).ToNot(HaveOccurred())
@@ -149,7 +149,7 @@ var _ = Describe("UnauthenticatedClient", func() {
 		Context("failure cases", func() {
 			When("the target url is empty", func() {
 				It("returns an error", func() {
-					client, _ := network.NewUnauthenticatedClient("", false, "", time.Duration(5)*time.Second, time.Duration(30)*time.Second)
+					client, _ := network.NewUnauthenticatedClient("", false, "", nil, network.ProxyConfig{}, time.Duration(5)*time.Second, time.Duration(30)*time.Second)
 					_, err := client.Do(&http.Request{})
 					Expect(err).To(MatchError("target flag is required, run `om help` for more info"))
 				})
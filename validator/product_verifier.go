@@ -0,0 +1,127 @@
+package validator
+
+import (
+	"archive/zip"
+	"crypto/sha1"
+	"fmt"
+	"hash"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/pivotal-cf/om/extractor"
+)
+
+type ProductVerifier struct{}
+
+func NewProductVerifier() ProductVerifier {
+	return ProductVerifier{}
+}
+
+type ProductVerification struct {
+	Entries  int
+	Releases []VerifiedRelease
+}
+
+type VerifiedRelease struct {
+	Name    string
+	Version string
+	File    string
+	// SHA1 is empty when the metadata does not provide a SHA1 to verify
+	// the release against.
+	SHA1 string
+}
+
+// Verify reads every entry of a product file, which checks the entries
+// against the CRC32 and sizes recorded in the zip central directory, and
+// checks that each release listed in the metadata is bundled with the SHA1
+// the metadata records for it. All the problems found are returned as a
+// single error.
+func (v ProductVerifier) Verify(productPath string) (ProductVerification, error) {
+	var verification ProductVerification
+
+	inspection, err := extractor.NewMetadataExtractor().InspectFile(productPath, false)
+	if err != nil {
+		return verification, fmt.Errorf("could not read the product metadata: %w", err)
+	}
+
+	zipReader, err := zip.OpenReader(productPath)
+	if err != nil {
+		return verification, err
+	}
+	defer zipReader.Close()
+
+	var problems []string
+	releaseHashes := map[string]hash.Hash{}
+	seen := map[string]bool{}
+
+	for _, file := range zipReader.File {
+		if seen[file.Name] {
+			problems = append(problems, fmt.Sprintf("the zip contains %s more than once", file.Name))
+		}
+		seen[file.Name] = true
+
+		if path.IsAbs(file.Name) || strings.HasPrefix(path.Clean(file.Name), "../") {
+			problems = append(problems, fmt.Sprintf("the zip entry %s points outside of the product", file.Name))
+		}
+
+		if file.FileInfo().IsDir() {
+			continue
+		}
+
+		verification.Entries++
+
+		var writer io.Writer = io.Discard
+		if strings.HasPrefix(file.Name, "releases/") {
+			digest := sha1.New()
+			releaseHashes[path.Base(file.Name)] = digest
+			writer = digest
+		}
+
+		err = readZipEntry(file, writer)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("the zip entry %s is corrupt: %s", file.Name, err))
+		}
+	}
+
+	for _, release := range inspection.Releases {
+		verification.Releases = append(verification.Releases, VerifiedRelease{
+			Name:    release.Name,
+			Version: release.Version,
+			File:    release.File,
+			SHA1:    release.SHA1,
+		})
+
+		digest, ok := releaseHashes[release.File]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("the release %s %s is missing: releases/%s is not in the product", release.Name, release.Version, release.File))
+			continue
+		}
+
+		if release.SHA1 == "" {
+			continue
+		}
+
+		actual := fmt.Sprintf("%x", digest.Sum(nil))
+		if actual != release.SHA1 {
+			problems = append(problems, fmt.Sprintf("the release %s %s has SHA1 %s, but the metadata expects %s", release.Name, release.Version, actual, release.SHA1))
+		}
+	}
+
+	if len(problems) > 0 {
+		return verification, fmt.Errorf("%s failed verification:\n- %s", productPath, strings.Join(problems, "\n- "))
+	}
+
+	return verification, nil
+}
+
+func readZipEntry(file *zip.File, writer io.Writer) error {
+	reader, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	_, err = io.Copy(writer, reader)
+	return err
+}
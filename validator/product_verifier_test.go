@@ -0,0 +1,194 @@
+package validator_test
+
+import (
+	"archive/zip"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+
+	"github.com/pivotal-cf/om/validator"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const verifiedMetadata = `---
+name: some-product
+product_version: 1.2.3
+releases:
+- name: some-release
+  version: 1.0.0
+  file: some-release-1.0.0.tgz
+  sha1: %s
+- name: other-release
+  version: 2.0.0
+  file: other-release-2.0.0.tgz
+`
+
+type zipEntry struct {
+	name     string
+	contents string
+	crc32    uint32
+}
+
+func createProduct(entries ...zipEntry) string {
+	productPath := filepath.Join(GinkgoT().TempDir(), "product.pivotal")
+	file, err := os.Create(productPath)
+	Expect(err).ToNot(HaveOccurred())
+	defer file.Close()
+
+	z := zip.NewWriter(file)
+	for _, entry := range entries {
+		checksum := entry.crc32
+		if checksum == 0 {
+			checksum = crc32.ChecksumIEEE([]byte(entry.contents))
+		}
+
+		writer, err := z.CreateRaw(&zip.FileHeader{
+			Name:               entry.name,
+			Method:             zip.Store,
+			CRC32:              checksum,
+			CompressedSize64:   uint64(len(entry.contents)),
+			UncompressedSize64: uint64(len(entry.contents)),
+		})
+		Expect(err).ToNot(HaveOccurred())
+		_, err = writer.Write([]byte(entry.contents))
+		Expect(err).ToNot(HaveOccurred())
+	}
+	Expect(z.Close()).To(Succeed())
+
+	return productPath
+}
+
+func sha1Of(contents string) string {
+	return fmt.Sprintf("%x", sha1.Sum([]byte(contents)))
+}
+
+var _ = Describe("ProductVerifier", func() {
+	var verifier validator.ProductVerifier
+
+	BeforeEach(func() {
+		verifier = validator.NewProductVerifier()
+	})
+
+	Describe("Verify", func() {
+		It("verifies every entry and release of the product", func() {
+			productPath := createProduct(
+				zipEntry{name: "metadata/some-product.yml", contents: fmt.Sprintf(verifiedMetadata, sha1Of("some-release"))},
+				zipEntry{name: "releases/some-release-1.0.0.tgz", contents: "some-release"},
+				zipEntry{name: "releases/other-release-2.0.0.tgz", contents: "other-release"},
+			)
+
+			verification, err := verifier.Verify(productPath)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(verification).To(Equal(validator.ProductVerification{
+				Entries: 3,
+				Releases: []validator.VerifiedRelease{
+					{Name: "some-release", Version: "1.0.0", File: "some-release-1.0.0.tgz", SHA1: sha1Of("some-release")},
+					{Name: "other-release", Version: "2.0.0", File: "other-release-2.0.0.tgz"},
+				},
+			}))
+		})
+
+		It("reports every problem with the product", func() {
+			productPath := createProduct(
+				zipEntry{name: "metadata/some-product.yml", contents: fmt.Sprintf(verifiedMetadata, sha1Of("some-release"))},
+				zipEntry{name: "releases/some-release-1.0.0.tgz", contents: "tampered-release"},
+				zipEntry{name: "migrations/v1/some-migration.js", contents: "corrupted", crc32: 1234},
+				zipEntry{name: "migrations/v1/some-migration.js", contents: "corrupted"},
+				zipEntry{name: "../outside", contents: "outside"},
+			)
+
+			_, err := verifier.Verify(productPath)
+			Expect(err).To(MatchError(productPath + ` failed verification:
+- the zip entry migrations/v1/some-migration.js is corrupt: zip: checksum error
+- the zip contains migrations/v1/some-migration.js more than once
+- the zip entry ../outside points outside of the product
+- the release some-release 1.0.0 has SHA1 ` + sha1Of("tampered-release") + `, but the metadata expects ` + sha1Of("some-release") + `
+- the release other-release 2.0.0 is missing: releases/other-release-2.0.0.tgz is not in the product`))
+		})
+
+		It("errors when the product has no metadata", func() {
+			productPath := createProduct(zipEntry{name: "releases/some-release-1.0.0.tgz", contents: "some-release"})
+
+			_, err := verifier.Verify(productPath)
+			Expect(err).To(MatchError("could not read the product metadata: no metadata file was found in provided .pivotal"))
+		})
+	})
+
+	Describe("VerifySignature", func() {
+		var productPath, publicKeyPath, signaturePath string
+
+		writePublicKey := func(publicKey crypto.PublicKey) {
+			der, err := x509.MarshalPKIXPublicKey(publicKey)
+			Expect(err).ToNot(HaveOccurred())
+			publicKeyPath = filepath.Join(GinkgoT().TempDir(), "key.pub")
+			Expect(os.WriteFile(publicKeyPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0600)).To(Succeed())
+		}
+
+		BeforeEach(func() {
+			productPath = filepath.Join(GinkgoT().TempDir(), "product.pivotal")
+			Expect(os.WriteFile(productPath, []byte("some-product"), 0600)).To(Succeed())
+			signaturePath = productPath + ".sig"
+		})
+
+		It("verifies an RSA signature", func() {
+			key, err := rsa.GenerateKey(rand.Reader, 2048)
+			Expect(err).ToNot(HaveOccurred())
+			writePublicKey(&key.PublicKey)
+
+			digest := sha256.Sum256([]byte("some-product"))
+			signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+			Expect(err).ToNot(HaveOccurred())
+			Expect(os.WriteFile(signaturePath, signature, 0600)).To(Succeed())
+
+			Expect(verifier.VerifySignature(productPath, signaturePath, publicKeyPath)).To(Succeed())
+		})
+
+		It("verifies a base64 encoded ECDSA signature", func() {
+			key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+			Expect(err).ToNot(HaveOccurred())
+			writePublicKey(&key.PublicKey)
+
+			digest := sha256.Sum256([]byte("some-product"))
+			signature, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+			Expect(err).ToNot(HaveOccurred())
+			Expect(os.WriteFile(signaturePath, []byte(base64.StdEncoding.EncodeToString(signature)+"\n"), 0600)).To(Succeed())
+
+			Expect(verifier.VerifySignature(productPath, signaturePath, publicKeyPath)).To(Succeed())
+		})
+
+		It("errors when the signature does not match", func() {
+			key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+			Expect(err).ToNot(HaveOccurred())
+			writePublicKey(&key.PublicKey)
+
+			digest := sha256.Sum256([]byte("another-product"))
+			signature, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+			Expect(err).ToNot(HaveOccurred())
+			Expect(os.WriteFile(signaturePath, signature, 0600)).To(Succeed())
+
+			err = verifier.VerifySignature(productPath, signaturePath, publicKeyPath)
+			Expect(err).To(MatchError(fmt.Sprintf("the signature %s of %s does not match the public key %s", signaturePath, productPath, publicKeyPath)))
+		})
+
+		It("errors when the public key is not PEM encoded", func() {
+			publicKeyPath = filepath.Join(GinkgoT().TempDir(), "key.pub")
+			Expect(os.WriteFile(publicKeyPath, []byte("not a key"), 0600)).To(Succeed())
+
+			err := verifier.VerifySignature(productPath, signaturePath, publicKeyPath)
+			Expect(err).To(MatchError(fmt.Sprintf("could not read the public key %s: it is not PEM encoded", publicKeyPath)))
+		})
+	})
+})
@@ -0,0 +1,78 @@
+package validator
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"os"
+)
+
+// VerifySignature checks a detached signature of a file, made with an RSA or
+// ECDSA private key over the SHA256 of the file, such as the ones made by
+// `openssl dgst -sha256 -sign` or `cosign sign-blob`. The signature can be
+// raw or base64 encoded, and the public key is a PEM encoded PKIX key.
+func (v ProductVerifier) VerifySignature(filePath, signaturePath, publicKeyPath string) error {
+	contents, err := os.ReadFile(publicKeyPath)
+	if err != nil {
+		return fmt.Errorf("could not read the public key: %w", err)
+	}
+
+	block, _ := pem.Decode(contents)
+	if block == nil {
+		return fmt.Errorf("could not read the public key %s: it is not PEM encoded", publicKeyPath)
+	}
+
+	publicKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return fmt.Errorf("could not parse the public key %s: %w", publicKeyPath, err)
+	}
+
+	signature, err := os.ReadFile(signaturePath)
+	if err != nil {
+		return fmt.Errorf("could not read the signature: %w", err)
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(signature)))
+	if err == nil {
+		signature = decoded
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	digest := sha256.New()
+	_, err = io.Copy(digest, file)
+	if err != nil {
+		return err
+	}
+
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+		err = rsa.VerifyPKCS1v15(key, crypto.SHA256, digest.Sum(nil), signature)
+		if err != nil {
+			err = rsa.VerifyPSS(key, crypto.SHA256, digest.Sum(nil), signature, nil)
+		}
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(key, digest.Sum(nil), signature) {
+			err = fmt.Errorf("ecdsa: verification error")
+		}
+	default:
+		return fmt.Errorf("unsupported public key type %T: only RSA and ECDSA keys are supported", publicKey)
+	}
+
+	if err != nil {
+		return fmt.Errorf("the signature %s of %s does not match the public key %s", signaturePath, filePath, publicKeyPath)
+	}
+
+	return nil
+}